@@ -0,0 +1,173 @@
+package cqrskit
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errors ...
+var (
+	ErrStaleAggregate   = errors.New("aggregate is behind the last commit of it's stream")
+	ErrConcurrentWrites = errors.New("concurrent write occured; version used")
+)
+
+//*******************************************************************************
+// Aggregate Loading and Saving
+//*******************************************************************************
+
+// Aggregate embodies a type whose state is built by applying the EventCommits of
+// it's stream, as generated for structs annotated with @escqrs.
+type Aggregate interface {
+	Apply(EventCommit) error
+}
+
+// SnapshotMarshaler embodies an Aggregate which can be stored as the Payload of a Snapshot
+// and restored from one, letting loads skip the commits already captured by the snapshot.
+type SnapshotMarshaler interface {
+	MarshalSnapshot() (interface{}, error)
+	UnmarshalSnapshot(payload interface{}) error
+}
+
+// LoadAggregate builds the state of the aggregate instance into target, returning the version of
+// the last commit applied. If target implements SnapshotMarshaler and es has Snapshots, it is first
+// restored from the latest snapshot of the instance and only later commits are applied. The data of
+// events is decoded with the registry before being applied, where a nil registry uses Events.
+func LoadAggregate(ctx context.Context, es ESCQRS, registry *EventRegistry, aggregateID string, instanceID string, target Aggregate) (int, error) {
+	if registry == nil {
+		registry = Events
+	}
+
+	version, err := restoreSnapshot(ctx, es, aggregateID, instanceID, target)
+	if err != nil {
+		return 0, err
+	}
+
+	reader, err := es.Events.Reader(aggregateID, instanceID)
+	if err != nil {
+		return 0, err
+	}
+
+	iter, err := reader.Stream(ctx, int64(version+1))
+	if err != nil {
+		return 0, err
+	}
+
+	defer iter.Close()
+
+	for iter.Next() {
+		commit := iter.Commit()
+		for index, event := range commit.Events {
+			if commit.Events[index], err = registry.Decode(event); err != nil {
+				return version, err
+			}
+		}
+
+		if err := target.Apply(commit); err != nil {
+			return version, err
+		}
+
+		version = commit.Version
+	}
+
+	return version, iter.Err()
+}
+
+// restoreSnapshot restores the target from the snapshot of the aggregate instance with the
+// highest revision if possible, returning the version the snapshot was taken at. Revisions
+// need not start at one or follow each other.
+func restoreSnapshot(ctx context.Context, es ESCQRS, aggregateID string, instanceID string, target Aggregate) (int, error) {
+	marshaler, ok := target.(SnapshotMarshaler)
+	if !ok || es.Snapshots == nil {
+		return 0, nil
+	}
+
+	reader, err := es.Snapshots.Reader(aggregateID, instanceID)
+	if err != nil {
+		return 0, err
+	}
+
+	snaps, err := reader.ReadAll(ctx)
+	if err != nil || len(snaps) == 0 {
+		return 0, err
+	}
+
+	latest := snaps[0]
+	for _, snap := range snaps[1:] {
+		if snap.Revision > latest.Revision || (snap.Revision == latest.Revision && snap.ToVersion > latest.ToVersion) {
+			latest = snap
+		}
+	}
+
+	if err := marshaler.UnmarshalSnapshot(latest.Payload); err != nil {
+		return 0, err
+	}
+
+	return latest.ToVersion, nil
+}
+
+// SaveAggregate writes the events produced by a command for the aggregate instance as a single
+// commit. If version is zero or above, it must equal the version of the last commit of the
+// instance, else ErrStaleAggregate is returned, guarding against saving an aggregate changed
+// since it was loaded. The commit is requested at the version following it, so the repository
+// also rejects it if another save of the same version lands first. A version below zero skips
+// said check.
+func SaveAggregate(ctx context.Context, es ESCQRS, aggregateID string, instanceID string, version int, command string, events ...Event) (CommitHeader, error) {
+	writer, err := es.Events.Writer(aggregateID, instanceID)
+	if err != nil {
+		return CommitHeader{}, err
+	}
+
+	var expected int
+	if version >= 0 {
+		last, err := lastVersion(ctx, writer)
+		if err != nil {
+			return CommitHeader{}, err
+		}
+
+		if last != version {
+			return CommitHeader{}, ErrStaleAggregate
+		}
+
+		expected = version + 1
+	}
+
+	header, err := writer.Write(ctx, EventCommitRequest{
+		ID:      NewCommitID(),
+		Command: command,
+		Version: expected,
+		Events:  events,
+		Created: time.Now(),
+	})
+	if err == ErrConcurrentWrites && expected > 0 {
+		return header, ErrStaleAggregate
+	}
+
+	return header, err
+}
+
+// lastVersion returns the version of the last commit of the WriteRepo, or zero if it
+// has none.
+func lastVersion(ctx context.Context, writer WriteRepo) (int, error) {
+	total, err := writer.Count(ctx)
+	if err != nil || total == 0 {
+		return 0, err
+	}
+
+	header, err := writer.LastCommitVersion(ctx)
+	return header.Version, err
+}
+
+// NewCommitID returns a new random (version 4) UUID for the ID of a EventCommitRequest.
+func NewCommitID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
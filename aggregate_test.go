@@ -0,0 +1,192 @@
+package cqrskit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+)
+
+func TestSaveAggregateConcurrently(t *testing.T) {
+	store := &memoryStream{}
+	es := cqrskit.ESCQRS{Events: store}
+
+	// Both saves read the last version before either writes, so only the version
+	// requested of the repository can tell them apart.
+	store.barrier.Add(2)
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := cqrskit.SaveAggregate(context.Background(), es, "users", "user-1", 0, "CreateUser")
+			errs <- err
+		}()
+	}
+
+	var saved, stale int
+	for i := 0; i < 2; i++ {
+		switch err := <-errs; err {
+		case nil:
+			saved++
+		case cqrskit.ErrStaleAggregate:
+			stale++
+		default:
+			tests.FailedWithError(err, "Should have either saved or rejected aggregate as stale")
+		}
+	}
+
+	if saved != 1 || stale != 1 || len(store.commits) != 1 {
+		tests.Info("Received: %d saved, %d stale, %d commits", saved, stale, len(store.commits))
+		tests.Failed("Should have saved only one of concurrent saves of the same version")
+	}
+	tests.Passed("Should have saved only one of concurrent saves of the same version")
+}
+
+func TestLoadAggregateLatestSnapshot(t *testing.T) {
+	store := &memoryStream{
+		commits: []cqrskit.EventCommit{
+			{Version: 7, Events: []cqrskit.Event{{Type: "Renamed", Data: "eight"}}},
+		},
+	}
+
+	// Revisions with gaps, in no particular order.
+	snapshots := &memorySnapshots{
+		snapshots: []cqrskit.Snapshot{
+			{Revision: 2, ToVersion: 3, Payload: "three"},
+			{Revision: 5, ToVersion: 6, Payload: "six"},
+			{Revision: 4, ToVersion: 5, Payload: "five"},
+		},
+	}
+
+	var target namedAggregate
+	version, err := cqrskit.LoadAggregate(context.Background(), cqrskit.ESCQRS{Events: store, Snapshots: snapshots}, nil, "users", "user-1", &target)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully loaded aggregate")
+	}
+	tests.Passed("Should have successfully loaded aggregate")
+
+	if target.restored != "six" || target.name != "eight" || version != 7 {
+		tests.Info("Received: %+v at version %d", target, version)
+		tests.Failed("Should have restored snapshot of highest revision")
+	}
+	tests.Passed("Should have restored snapshot of highest revision")
+}
+
+// namedAggregate implements cqrskit.Aggregate and cqrskit.SnapshotMarshaler.
+type namedAggregate struct {
+	name     string
+	restored string
+}
+
+func (n *namedAggregate) Apply(commit cqrskit.EventCommit) error {
+	for _, event := range commit.Events {
+		n.name = event.Data.(string)
+	}
+	return nil
+}
+
+func (n *namedAggregate) MarshalSnapshot() (interface{}, error) {
+	return n.name, nil
+}
+
+func (n *namedAggregate) UnmarshalSnapshot(payload interface{}) error {
+	n.name = payload.(string)
+	n.restored = n.name
+	return nil
+}
+
+// memoryStream implements the repositories of a single stream, enforcing the
+// version requested of writes. Writes wait on the barrier, if set, before any
+// of them is committed.
+type memoryStream struct {
+	cqrskit.WriteRepo
+	cqrskit.ReadRepo
+	ml      sync.Mutex
+	barrier sync.WaitGroup
+	commits []cqrskit.EventCommit
+}
+
+func (m *memoryStream) Writer(aggregateID string, instanceID string) (cqrskit.WriteRepo, error) {
+	return m, nil
+}
+
+func (m *memoryStream) Reader(aggregateID string, instanceID string) (cqrskit.ReadRepo, error) {
+	return m, nil
+}
+
+func (m *memoryStream) Count(ctx context.Context) (int, error) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+	return len(m.commits), nil
+}
+
+func (m *memoryStream) LastCommitVersion(ctx context.Context) (cqrskit.CommitHeader, error) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+	return cqrskit.CommitHeader{Version: m.commits[len(m.commits)-1].Version}, nil
+}
+
+func (m *memoryStream) Write(ctx context.Context, req cqrskit.EventCommitRequest) (cqrskit.CommitHeader, error) {
+	m.barrier.Done()
+	m.barrier.Wait()
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	version := len(m.commits) + 1
+	if req.Version > 0 && req.Version != version {
+		return cqrskit.CommitHeader{}, cqrskit.ErrConcurrentWrites
+	}
+
+	m.commits = append(m.commits, cqrskit.EventCommit{CommitID: req.ID, Version: version, Events: req.Events})
+	return cqrskit.CommitHeader{CommitID: req.ID, Version: version}, nil
+}
+
+func (m *memoryStream) Stream(ctx context.Context, fromVersion int64) (cqrskit.CommitIterator, error) {
+	var commits []cqrskit.EventCommit
+	for _, commit := range m.commits {
+		if int64(commit.Version) >= fromVersion {
+			commits = append(commits, commit)
+		}
+	}
+	return &memoryIterator{commits: commits, index: -1}, nil
+}
+
+// memorySnapshots implements the snapshot repositories of a single stream.
+type memorySnapshots struct {
+	cqrskit.SnapshotReader
+	cqrskit.SnapshotWriterRepository
+	snapshots []cqrskit.Snapshot
+}
+
+func (m *memorySnapshots) Reader(aggregateID string, instanceID string) (cqrskit.SnapshotReader, error) {
+	return m, nil
+}
+
+func (m *memorySnapshots) ReadAll(ctx context.Context) ([]cqrskit.Snapshot, error) {
+	return m.snapshots, nil
+}
+
+type memoryIterator struct {
+	commits []cqrskit.EventCommit
+	index   int
+}
+
+func (m *memoryIterator) Next() bool {
+	m.index++
+	return m.index < len(m.commits)
+}
+
+func (m *memoryIterator) Commit() cqrskit.EventCommit {
+	return m.commits[m.index]
+}
+
+func (m *memoryIterator) Err() error {
+	return nil
+}
+
+func (m *memoryIterator) Close() error {
+	return nil
+}
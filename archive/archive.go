@@ -0,0 +1,363 @@
+// Package archive implements the export and import of the commit streams of cqrskit stores
+// as newline-delimited archives, where each line holds a single commit encoded with a
+// cqrskit.Encoder, such as the cqrskit.JSONEncoder:
+//
+//	{"commit_id":"...","instance_id":"...","aggregate_id":"...","version":1,...}
+//	{"commit_id":"...","instance_id":"...","aggregate_id":"...","version":2,...}
+//
+// Commits carry their aggregate and instance ids, so an archive may hold any number of
+// streams, each in order of version. Archives are used to migrate streams between stores
+// and to seed test environments, and may be gzipped.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gokit/cqrskit"
+)
+
+// errors ...
+var (
+	ErrNoAggregate     = errors.New("aggregate id is required to archive an instance")
+	ErrNoStream        = errors.New("commit has no aggregate or instance id")
+	ErrVersionTaken    = errors.New("commit version already taken in stream")
+	ErrVersionGap      = errors.New("commit version does not follow last version of stream, and repository can not import commits")
+	ErrMultilineCommit = errors.New("encoded commit spans multiple lines")
+	ErrNoReaders       = errors.New("dry runs require readers of the target store")
+)
+
+//*******************************************************************************
+// Export
+//*******************************************************************************
+
+// Stream embodies the stream of commits of an aggregate instance.
+type Stream struct {
+	AggregateID string `json:"aggregate_id"`
+	InstanceID  string `json:"instance_id"`
+}
+
+// Streams returns the streams archived for the giving ids: the stream of the instance if
+// both ids are set, all streams of the aggregate if only the aggregate id is set, or all
+// streams of the store if neither is set. Tombstoned streams are left out.
+func Streams(ctx context.Context, catalog cqrskit.Catalog, aggregateID string, instanceID string) ([]Stream, error) {
+	if instanceID != "" {
+		if aggregateID == "" {
+			return nil, ErrNoAggregate
+		}
+		return []Stream{{AggregateID: aggregateID, InstanceID: instanceID}}, nil
+	}
+
+	aggregates := []string{aggregateID}
+	if aggregateID == "" {
+		var err error
+		if aggregates, err = catalog.Aggregates(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var streams []Stream
+	for _, aggregate := range aggregates {
+		stats, err := catalog.Instances(ctx, aggregate)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stat := range stats {
+			if !stat.Deleted.IsZero() {
+				continue
+			}
+			streams = append(streams, Stream{AggregateID: aggregate, InstanceID: stat.InstanceID})
+		}
+	}
+
+	return streams, nil
+}
+
+// Export writes all commits of the streams read from the repository into w, one per line
+// as encoded by the encoder, returning the total of commits written.
+func Export(ctx context.Context, w io.Writer, readers cqrskit.ReadRepository, encoder cqrskit.Encoder, streams ...Stream) (int, error) {
+	var total int
+
+	writer := bufio.NewWriter(w)
+	for _, stream := range streams {
+		written, err := exportStream(ctx, writer, readers, encoder, stream)
+		total += written
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, writer.Flush()
+}
+
+// exportStream writes all commits of the stream into w, returning the total written.
+func exportStream(ctx context.Context, w *bufio.Writer, readers cqrskit.ReadRepository, encoder cqrskit.Encoder, stream Stream) (int, error) {
+	reader, err := readers.Reader(stream.AggregateID, stream.InstanceID)
+	if err != nil {
+		return 0, err
+	}
+
+	commits, err := reader.Stream(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	defer commits.Close()
+
+	var total int
+	for commits.Next() {
+		encoded, err := encoder.Encode(commits.Commit())
+		if err != nil {
+			return total, err
+		}
+
+		if bytes.IndexByte(encoded, '\n') != -1 {
+			return total, ErrMultilineCommit
+		}
+
+		if _, err := w.Write(append(encoded, '\n')); err != nil {
+			return total, err
+		}
+
+		total++
+	}
+
+	return total, commits.Err()
+}
+
+//*******************************************************************************
+// Import
+//*******************************************************************************
+
+// LineError embodies an error met importing the commit of a line of an archive.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (le LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", le.Line, le.Err)
+}
+
+// Report embodies the totals of an import.
+type Report struct {
+	Streams  int `json:"streams"`
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Importer imports archives into the repositories of Writers, decoding their lines with
+// the Decoder.
+//
+// Commits are imported through the Import method of repositories implementing the
+// cqrskit.CommitImporter, keeping their CommitID, Version and Created time. Other
+// repositories get a Write of each commit, which keeps the version only as long as the
+// commits of a stream follow each other without gaps, else an ErrVersionGap is returned.
+//
+// A commit whose version is already taken in it's stream fails the import with an
+// ErrVersionTaken, unless Resume is set, in which case it is skipped, so an interrupted
+// import can be run again. With DryRun set, archives are read and checked against the
+// streams read through Readers, without getting any writer of Writers, as getting one may
+// itself write records into the store. Dry runs take commits to keep their versions only
+// if Writers implements CommitImporters.
+type Importer struct {
+	Writers cqrskit.WriteRepository
+	Readers cqrskit.ReadRepository
+	Decoder cqrskit.Decoder
+	DryRun  bool
+	Resume  bool
+}
+
+// CommitImporters embodies a cqrskit.WriteRepository telling whether it's repositories
+// implement cqrskit.CommitImporter, without getting one.
+type CommitImporters interface {
+	ImportsCommits() bool
+}
+
+// importStream embodies the state of a stream being imported.
+type importStream struct {
+	repo      cqrskit.WriteRepo
+	last      int
+	canImport bool
+}
+
+// Import imports all commits of the archive read from r, which is unzipped if gzipped,
+// returning the Report of commits imported or skipped so far. Errors of commits are
+// returned as a LineError.
+func (im Importer) Import(ctx context.Context, r io.Reader) (Report, error) {
+	var report Report
+
+	if im.DryRun && im.Readers == nil {
+		return report, ErrNoReaders
+	}
+
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		unzipped, err := gzip.NewReader(reader)
+		if err != nil {
+			return report, err
+		}
+
+		defer unzipped.Close()
+		reader = bufio.NewReader(unzipped)
+	}
+
+	streams := map[Stream]*importStream{}
+
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		encoded, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return report, err
+		}
+
+		if trimmed := bytes.TrimSpace(encoded); len(trimmed) != 0 {
+			if lineErr := im.importLine(ctx, streams, &report, trimmed); lineErr != nil {
+				return report, LineError{Line: line, Err: lineErr}
+			}
+		}
+
+		if err == io.EOF {
+			return report, nil
+		}
+	}
+}
+
+// importLine imports the commit encoded in the line.
+func (im Importer) importLine(ctx context.Context, streams map[Stream]*importStream, report *Report, encoded []byte) error {
+	commit, err := im.Decoder.Decode(encoded)
+	if err != nil {
+		return err
+	}
+
+	if commit.AggregateID == "" || commit.InstanceID == "" {
+		return ErrNoStream
+	}
+
+	key := Stream{AggregateID: commit.AggregateID, InstanceID: commit.InstanceID}
+	stream, ok := streams[key]
+	if !ok {
+		if stream, err = im.stream(ctx, key); err != nil {
+			return err
+		}
+
+		streams[key] = stream
+		report.Streams++
+	}
+
+	if commit.Version <= stream.last {
+		if im.Resume {
+			report.Skipped++
+			return nil
+		}
+		return ErrVersionTaken
+	}
+
+	if !stream.canImport && commit.Version != stream.last+1 {
+		return ErrVersionGap
+	}
+
+	if !im.DryRun {
+		if importer, ok := stream.repo.(cqrskit.CommitImporter); ok {
+			_, err = importer.Import(ctx, commit)
+		} else {
+			err = im.write(ctx, stream.repo, commit)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	stream.last = commit.Version
+	report.Imported++
+	return nil
+}
+
+// stream returns the importStream of the giving stream, with it's last commit version.
+func (im Importer) stream(ctx context.Context, key Stream) (*importStream, error) {
+	if im.DryRun {
+		return im.readStream(ctx, key)
+	}
+
+	repo, err := im.Writers.Writer(key.AggregateID, key.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, canImport := repo.(cqrskit.CommitImporter)
+	stream := &importStream{repo: repo, canImport: canImport}
+
+	// Repositories have their own errors for streams without commits, so the last
+	// version is only asked for once the stream is known to have some.
+	count, err := repo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if count != 0 {
+		header, err := repo.LastCommitVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stream.last = header.Version
+	}
+
+	return stream, nil
+}
+
+// readStream returns the importStream of the giving stream for a dry run, reading it's last
+// commit version through Readers.
+func (im Importer) readStream(ctx context.Context, key Stream) (*importStream, error) {
+	stream := &importStream{}
+	if importers, ok := im.Writers.(CommitImporters); ok {
+		stream.canImport = importers.ImportsCommits()
+	}
+
+	reader, err := im.Readers.Reader(key.AggregateID, key.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := reader.Read(ctx, cqrskit.ReadBackward, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(last) != 0 {
+		stream.last = last[0].Version
+	}
+
+	return stream, nil
+}
+
+// write writes the commit as a new commit of the repository, which must be given the
+// commit's version.
+func (im Importer) write(ctx context.Context, repo cqrskit.WriteRepo, commit cqrskit.EventCommit) error {
+	header, err := repo.Write(ctx, cqrskit.EventCommitRequest{
+		ID:      commit.CommitID,
+		Command: commit.Command,
+		Events:  commit.Events,
+		Created: commit.Created,
+		Header:  commit.Header,
+	})
+	if err != nil {
+		return err
+	}
+
+	if header.Version != commit.Version {
+		return fmt.Errorf("commit %q written as version %d instead of %d", commit.CommitID, header.Version, commit.Version)
+	}
+
+	return nil
+}
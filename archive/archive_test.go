@@ -0,0 +1,394 @@
+package archive_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/gokit/cqrskit/archive"
+)
+
+var created = time.Date(2018, 3, 4, 10, 20, 30, 0, time.UTC)
+
+func newSource() *memoryStore {
+	source := newMemoryStore()
+	source.add(cqrskit.EventCommit{AggregateID: "users", InstanceID: "user-1", CommitID: "c1", Version: 1, Command: "CreateUser", Created: created})
+	source.add(cqrskit.EventCommit{AggregateID: "users", InstanceID: "user-1", CommitID: "c2", Version: 2, Command: "UpdateEmail", Created: created.Add(time.Minute)})
+	source.add(cqrskit.EventCommit{AggregateID: "users", InstanceID: "user-2", CommitID: "c3", Version: 1, Command: "CreateUser", Created: created.Add(time.Hour)})
+	source.add(cqrskit.EventCommit{AggregateID: "wallets", InstanceID: "wallet-1", CommitID: "c4", Version: 1, Command: "OpenWallet", Created: created})
+	source.deleted["wallets/wallet-2"] = true
+	return source
+}
+
+func export(t *testing.T, source *memoryStore, aggregateID string, instanceID string) *bytes.Buffer {
+	streams, err := archive.Streams(context.Background(), source, aggregateID, instanceID)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully listed streams")
+	}
+	tests.Passed("Should have successfully listed streams")
+
+	var out bytes.Buffer
+	if _, err := archive.Export(context.Background(), &out, source, cqrskit.JSONEncoder{}, streams...); err != nil {
+		tests.FailedWithError(err, "Should have successfully exported streams")
+	}
+	tests.Passed("Should have successfully exported streams")
+
+	return &out
+}
+
+func TestStreams(t *testing.T) {
+	source := newSource()
+
+	if _, err := archive.Streams(context.Background(), source, "", "user-1"); err != archive.ErrNoAggregate {
+		tests.Failed("Should have required aggregate id of instance")
+	}
+	tests.Passed("Should have required aggregate id of instance")
+
+	for _, scope := range []struct {
+		aggregateID string
+		instanceID  string
+		lines       int
+	}{
+		{"users", "user-1", 2},
+		{"users", "", 3},
+		{"", "", 4},
+	} {
+		out := export(t, source, scope.aggregateID, scope.instanceID)
+		if lines := strings.Count(out.String(), "\n"); lines != scope.lines {
+			tests.Info("Scope: %q/%q", scope.aggregateID, scope.instanceID)
+			tests.Info("Expected: %d, Received: %d", scope.lines, lines)
+			tests.Failed("Should have exported one line per commit of scope")
+		}
+		tests.Passed("Should have exported one line per commit of scope")
+	}
+}
+
+func TestImport(t *testing.T) {
+	source := newSource()
+	out := export(t, source, "", "")
+
+	target := newMemoryStore()
+	importer := archive.Importer{Writers: target, Decoder: cqrskit.JSONDecoder{}}
+
+	report, err := importer.Import(context.Background(), bytes.NewReader(out.Bytes()))
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully imported archive")
+	}
+	tests.Passed("Should have successfully imported archive")
+
+	if report != (archive.Report{Streams: 3, Imported: 4}) {
+		tests.Info("Received: %+v", report)
+		tests.Failed("Should have imported all commits")
+	}
+	tests.Passed("Should have imported all commits")
+
+	for key, commits := range source.streams {
+		imported := target.streams[key]
+		if len(imported) != len(commits) {
+			tests.Info("Stream: %s", key)
+			tests.Failed("Should have imported all commits of stream")
+		}
+
+		for index, commit := range commits {
+			got := imported[index]
+			if got.CommitID != commit.CommitID || got.Version != commit.Version || !got.Created.Equal(commit.Created) {
+				tests.Info("Expected: %+v", commit)
+				tests.Info("Received: %+v", got)
+				tests.Failed("Should have kept commit id, version and creation time")
+			}
+		}
+	}
+	tests.Passed("Should have kept commit id, version and creation time")
+
+	if _, err := importer.Import(context.Background(), bytes.NewReader(out.Bytes())); err == nil {
+		tests.Failed("Should have rejected commits of taken versions")
+	} else if lineErr, ok := err.(archive.LineError); !ok || lineErr.Line != 1 || lineErr.Err != archive.ErrVersionTaken {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected commits of taken versions")
+	}
+	tests.Passed("Should have rejected commits of taken versions")
+}
+
+func TestImportGzipped(t *testing.T) {
+	out := export(t, newSource(), "users", "")
+
+	var zipped bytes.Buffer
+	zipper := gzip.NewWriter(&zipped)
+	if _, err := zipper.Write(out.Bytes()); err != nil {
+		tests.FailedWithError(err, "Should have successfully gzipped archive")
+	}
+	zipper.Close()
+	tests.Passed("Should have successfully gzipped archive")
+
+	target := newMemoryStore()
+	importer := archive.Importer{Writers: target, Decoder: cqrskit.JSONDecoder{}}
+
+	report, err := importer.Import(context.Background(), &zipped)
+	if err != nil || report.Imported != 3 {
+		tests.Info("Received: %+v, %+v", report, err)
+		tests.Failed("Should have successfully imported gzipped archive")
+	}
+	tests.Passed("Should have successfully imported gzipped archive")
+}
+
+func TestImportDryRunAndResume(t *testing.T) {
+	out := export(t, newSource(), "users", "")
+
+	target := newMemoryStore()
+	target.add(cqrskit.EventCommit{AggregateID: "users", InstanceID: "user-1", CommitID: "c1", Version: 1, Command: "CreateUser", Created: created})
+
+	dryRun := archive.Importer{Writers: target, Readers: target, Decoder: cqrskit.JSONDecoder{}, DryRun: true, Resume: true}
+	report, err := dryRun.Import(context.Background(), bytes.NewReader(out.Bytes()))
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully checked archive")
+	}
+	tests.Passed("Should have successfully checked archive")
+
+	if report != (archive.Report{Streams: 2, Imported: 2, Skipped: 1}) || target.total() != 1 {
+		tests.Info("Received: %+v with %d commits", report, target.total())
+		tests.Failed("Should have reported commits without writing them")
+	}
+	tests.Passed("Should have reported commits without writing them")
+
+	if target.writers != 0 {
+		tests.Info("Received: %d writers", target.writers)
+		tests.Failed("Should have left store untouched by getting no writer")
+	}
+	tests.Passed("Should have left store untouched by getting no writer")
+
+	if _, err := (archive.Importer{Writers: target, Decoder: cqrskit.JSONDecoder{}, DryRun: true}).Import(context.Background(), bytes.NewReader(out.Bytes())); err != archive.ErrNoReaders {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have required readers for dry run")
+	}
+	tests.Passed("Should have required readers for dry run")
+
+	resume := archive.Importer{Writers: target, Decoder: cqrskit.JSONDecoder{}, Resume: true}
+	report, err = resume.Import(context.Background(), bytes.NewReader(out.Bytes()))
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully resumed import")
+	}
+	tests.Passed("Should have successfully resumed import")
+
+	if report != (archive.Report{Streams: 2, Imported: 2, Skipped: 1}) || target.total() != 3 {
+		tests.Info("Received: %+v with %d commits", report, target.total())
+		tests.Failed("Should have skipped commits already imported")
+	}
+	tests.Passed("Should have skipped commits already imported")
+}
+
+func TestImportVersionGap(t *testing.T) {
+	// A truncated stream, whose first commits were dropped.
+	source := newMemoryStore()
+	source.add(cqrskit.EventCommit{AggregateID: "users", InstanceID: "user-1", CommitID: "c3", Version: 3, Created: created})
+	source.add(cqrskit.EventCommit{AggregateID: "users", InstanceID: "user-1", CommitID: "c4", Version: 4, Created: created})
+
+	out := export(t, source, "users", "user-1")
+
+	writers := archive.Importer{Writers: newMemoryStore(), Decoder: cqrskit.JSONDecoder{}}
+	if _, err := writers.Import(context.Background(), bytes.NewReader(out.Bytes())); err == nil || err.(archive.LineError).Err != archive.ErrVersionGap {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected gap in versions of repository without imports")
+	}
+	tests.Passed("Should have rejected gap in versions of repository without imports")
+
+	target := newMemoryStore()
+	target.importing = true
+
+	dryRun := archive.Importer{Writers: target, Readers: target, Decoder: cqrskit.JSONDecoder{}, DryRun: true}
+	if _, err := dryRun.Import(context.Background(), bytes.NewReader(out.Bytes())); err != nil || target.writers != 0 {
+		tests.Info("Received: %+v with %d writers", err, target.writers)
+		tests.Failed("Should have successfully checked gaps through CommitImporters without writers")
+	}
+	tests.Passed("Should have successfully checked gaps through CommitImporters without writers")
+
+	importers := archive.Importer{Writers: target, Decoder: cqrskit.JSONDecoder{}}
+	if _, err := importers.Import(context.Background(), bytes.NewReader(out.Bytes())); err != nil {
+		tests.FailedWithError(err, "Should have successfully imported through CommitImporter")
+	}
+	tests.Passed("Should have successfully imported through CommitImporter")
+
+	if commits := target.streams["users/user-1"]; len(commits) != 2 || commits[0].Version != 3 || commits[1].Version != 4 {
+		tests.Info("Received: %+v", commits)
+		tests.Failed("Should have kept versions of truncated stream")
+	}
+	tests.Passed("Should have kept versions of truncated stream")
+}
+
+//*******************************************************************************
+// Memory Store
+//*******************************************************************************
+
+// memoryStore implements the cqrskit.Catalog, cqrskit.ReadRepository and
+// cqrskit.WriteRepository over streams held in memory, whose repositories implement
+// cqrskit.CommitImporter if importing is set. Writers counts the writers gotten.
+type memoryStore struct {
+	cqrskit.Catalog
+	importing bool
+	writers   int
+	order     []string
+	streams   map[string][]cqrskit.EventCommit
+	deleted   map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		streams: map[string][]cqrskit.EventCommit{},
+		deleted: map[string]bool{},
+	}
+}
+
+func (m *memoryStore) add(commit cqrskit.EventCommit) {
+	key := commit.AggregateID + "/" + commit.InstanceID
+	if _, ok := m.streams[key]; !ok {
+		m.order = append(m.order, key)
+	}
+	m.streams[key] = append(m.streams[key], commit)
+}
+
+func (m *memoryStore) total() int {
+	var total int
+	for _, commits := range m.streams {
+		total += len(commits)
+	}
+	return total
+}
+
+func (m *memoryStore) Aggregates(ctx context.Context) ([]string, error) {
+	var aggregates []string
+	seen := map[string]bool{}
+	for _, key := range m.order {
+		aggregate := strings.Split(key, "/")[0]
+		if !seen[aggregate] {
+			seen[aggregate] = true
+			aggregates = append(aggregates, aggregate)
+		}
+	}
+	return aggregates, nil
+}
+
+func (m *memoryStore) Instances(ctx context.Context, aggregateID string) ([]cqrskit.InstanceStat, error) {
+	var stats []cqrskit.InstanceStat
+	for _, key := range m.order {
+		if strings.HasPrefix(key, aggregateID+"/") {
+			stats = append(stats, cqrskit.InstanceStat{AggregateID: aggregateID, InstanceID: strings.TrimPrefix(key, aggregateID+"/")})
+		}
+	}
+
+	for key := range m.deleted {
+		if strings.HasPrefix(key, aggregateID+"/") {
+			stats = append(stats, cqrskit.InstanceStat{AggregateID: aggregateID, InstanceID: strings.TrimPrefix(key, aggregateID+"/"), Deleted: created})
+		}
+	}
+	return stats, nil
+}
+
+func (m *memoryStore) Reader(aggregateID string, instanceID string) (cqrskit.ReadRepo, error) {
+	if m.deleted[aggregateID+"/"+instanceID] {
+		return nil, cqrskit.StreamDeletedError{AggregateID: aggregateID, InstanceID: instanceID}
+	}
+	return &memoryStream{store: m, aggregateID: aggregateID, instanceID: instanceID}, nil
+}
+
+func (m *memoryStore) ImportsCommits() bool {
+	return m.importing
+}
+
+func (m *memoryStore) Writer(aggregateID string, instanceID string) (cqrskit.WriteRepo, error) {
+	m.writers++
+	stream := &memoryStream{store: m, aggregateID: aggregateID, instanceID: instanceID}
+	if m.importing {
+		return memoryImporter{stream}, nil
+	}
+	return stream, nil
+}
+
+// memoryStream implements the cqrskit.ReadRepo and cqrskit.WriteRepo of a stream.
+type memoryStream struct {
+	cqrskit.ReadRepo
+	cqrskit.WriteRepo
+	store       *memoryStore
+	aggregateID string
+	instanceID  string
+}
+
+func (m *memoryStream) commits() []cqrskit.EventCommit {
+	return m.store.streams[m.aggregateID+"/"+m.instanceID]
+}
+
+func (m *memoryStream) Count(ctx context.Context) (int, error) {
+	return len(m.commits()), nil
+}
+
+func (m *memoryStream) LastCommitVersion(ctx context.Context) (cqrskit.CommitHeader, error) {
+	commits := m.commits()
+	last := commits[len(commits)-1]
+	return cqrskit.CommitHeader{Version: last.Version, CommitID: last.CommitID}, nil
+}
+
+func (m *memoryStream) Write(ctx context.Context, req cqrskit.EventCommitRequest) (cqrskit.CommitHeader, error) {
+	version := 1
+	if commits := m.commits(); len(commits) != 0 {
+		version = commits[len(commits)-1].Version + 1
+	}
+
+	m.store.add(cqrskit.EventCommit{
+		AggregateID: m.aggregateID,
+		InstanceID:  m.instanceID,
+		CommitID:    req.ID,
+		Version:     version,
+		Command:     req.Command,
+		Created:     req.Created,
+		Events:      req.Events,
+		Header:      req.Header,
+	})
+	return cqrskit.CommitHeader{Version: version, CommitID: req.ID}, nil
+}
+
+func (m *memoryStream) Read(ctx context.Context, direction cqrskit.ReadDirection, fromVersion int64, limit int) ([]cqrskit.EventCommit, error) {
+	commits := m.commits()
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	return []cqrskit.EventCommit{commits[len(commits)-1]}, nil
+}
+
+func (m *memoryStream) Stream(ctx context.Context, fromVersion int64) (cqrskit.CommitIterator, error) {
+	return &memoryIterator{commits: m.commits(), index: -1}, nil
+}
+
+type memoryImporter struct {
+	*memoryStream
+}
+
+func (m memoryImporter) Import(ctx context.Context, commit cqrskit.EventCommit) (cqrskit.CommitHeader, error) {
+	m.store.add(commit)
+	return cqrskit.CommitHeader{Version: commit.Version, CommitID: commit.CommitID}, nil
+}
+
+type memoryIterator struct {
+	commits []cqrskit.EventCommit
+	index   int
+}
+
+func (m *memoryIterator) Next() bool {
+	m.index++
+	return m.index < len(m.commits)
+}
+
+func (m *memoryIterator) Commit() cqrskit.EventCommit {
+	return m.commits[m.index]
+}
+
+func (m *memoryIterator) Err() error {
+	return nil
+}
+
+func (m *memoryIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gokit/cqrskit"
+	"github.com/gokit/cqrskit/archive"
+	"github.com/gokit/cqrskit/repositories/mgorp"
+	"github.com/gokit/cqrskit/repositories/mgorp/mdb"
+
+	"github.com/influx6/faux/flags"
+)
+
+// exportCommand returns the command exporting streams of a mongo event store into an
+// archive.
+func exportCommand() flags.Command {
+	return flags.Command{
+		Name:      "export",
+		ShortDesc: "Exports streams of a mongo event store into an archive.",
+		Desc:      "Exports the stream of an instance, all streams of an aggregate, or all streams of a mongo event store into an archive of newline-delimited json, one commit per line, optionally gzipped. Tombstoned streams are left out.",
+		Usages: []string{
+			"cqrskit -export.db=events -export.file=events.ndjson export",
+			"cqrskit -export.aggregate=f7091ac77d9b52a3ec5609891cd9f54f -export.gzip -export.file=users.ndjson.gz export",
+		},
+		Action: exitOnError(func(ctx flags.Context) error {
+			aggregate, _ := ctx.GetString("aggregate")
+			instance, _ := ctx.GetString("instance")
+			file, _ := ctx.GetString("file")
+			zipped, _ := ctx.GetBool("gzip")
+
+			db := mdb.NewMongoDB(mongoConfig(ctx))
+
+			streams, err := archive.Streams(ctx, mgorp.NewCatalog(db), aggregate, instance)
+			if err != nil {
+				return err
+			}
+
+			var out io.Writer = os.Stdout
+
+			var archiveFile *os.File
+			if file != "" {
+				if archiveFile, err = os.Create(file); err != nil {
+					return err
+				}
+
+				defer func() {
+					if archiveFile != nil {
+						archiveFile.Close()
+					}
+				}()
+
+				out = archiveFile
+			}
+
+			var zipper *gzip.Writer
+			if zipped {
+				zipper = gzip.NewWriter(out)
+				out = zipper
+			}
+
+			total, err := archive.Export(ctx, out, mgorp.NewReadMaster(db), cqrskit.JSONEncoder{}, streams...)
+			if err != nil {
+				return err
+			}
+
+			// The gzip writer flushes the tail of the archive into the file on close, so
+			// it's closed before the file, and a failure of either is returned.
+			var zipErr, fileErr error
+			if zipper != nil {
+				zipErr = zipper.Close()
+			}
+
+			if archiveFile != nil {
+				fileErr = archiveFile.Close()
+				archiveFile = nil
+			}
+
+			switch {
+			case zipErr != nil && fileErr != nil:
+				return fmt.Errorf("failed to close gzip writer: %s; failed to close file: %s", zipErr, fileErr)
+			case zipErr != nil:
+				return fmt.Errorf("failed to close gzip writer: %s", zipErr)
+			case fileErr != nil:
+				return fmt.Errorf("failed to close file: %s", fileErr)
+			}
+
+			fmt.Fprintf(os.Stderr, "exported %d commits of %d streams\n", total, len(streams))
+			return nil
+		}),
+		Flags: append(mongoFlags(),
+			&flags.StringFlag{
+				Name: "aggregate",
+				Desc: "aggregate id of streams exported, defaults to all aggregates",
+			},
+			&flags.StringFlag{
+				Name: "instance",
+				Desc: "instance id of the single stream of the aggregate exported",
+			},
+			&flags.StringFlag{
+				Name: "file",
+				Desc: "file archive is written into, defaults to stdout",
+			},
+			&flags.BoolFlag{
+				Name: "gzip",
+				Desc: "gzip compresses the archive.",
+			},
+		),
+	}
+}
+
+// importCommand returns the command importing an archive into a mongo event store.
+func importCommand() flags.Command {
+	return flags.Command{
+		Name:      "import",
+		ShortDesc: "Imports an archive into a mongo event store.",
+		Desc:      "Imports an archive of newline-delimited json, as written by the export command and optionally gzipped, into a mongo event store, keeping the commit id, version and creation time of each commit. Imported commits are not dispatched.",
+		Usages: []string{
+			"cqrskit -import.db=events -import.file=events.ndjson -import.dry-run import",
+			"cqrskit -import.db=events -import.file=users.ndjson.gz -import.resume import",
+		},
+		Action: exitOnError(func(ctx flags.Context) error {
+			file, _ := ctx.GetString("file")
+			dryRun, _ := ctx.GetBool("dry-run")
+			resume, _ := ctx.GetBool("resume")
+			twoPhase, _ := ctx.GetBool("two-phase")
+
+			db := mdb.NewMongoDB(mongoConfig(ctx))
+
+			writers := mgorp.NewWriteMaster(db)
+			if twoPhase {
+				writers = mgorp.NewTwoPhaseWriteMaster(db)
+			}
+
+			var in io.ReadCloser = os.Stdin
+			if file != "" {
+				var err error
+				if in, err = os.Open(file); err != nil {
+					return err
+				}
+			}
+
+			defer in.Close()
+
+			importer := archive.Importer{
+				Writers: writers,
+				Readers: mgorp.NewReadMaster(db),
+				Decoder: cqrskit.JSONDecoder{},
+				DryRun:  dryRun,
+				Resume:  resume,
+			}
+
+			report, err := importer.Import(ctx, in)
+
+			verb := "imported"
+			if dryRun {
+				verb = "would import"
+			}
+
+			fmt.Fprintf(os.Stderr, "%s %d commits into %d streams, skipped %d\n", verb, report.Imported, report.Streams, report.Skipped)
+			return err
+		}),
+		Flags: append(mongoFlags(),
+			&flags.StringFlag{
+				Name: "file",
+				Desc: "file archive is read from, defaults to stdin",
+			},
+			&flags.BoolFlag{
+				Name: "dry-run",
+				Desc: "dry-run checks the archive against the store without writing any commit.",
+			},
+			&flags.BoolFlag{
+				Name: "resume",
+				Desc: "resume skips commits whose version is already taken, continuing an interrupted import.",
+			},
+			&flags.BoolFlag{
+				Name: "two-phase",
+				Desc: "two-phase imports commits through two-phase commits, for stores written by two-phase writers.",
+			},
+		),
+	}
+}
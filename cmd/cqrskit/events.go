@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/gokit/cqrskit/repositories/mgorp"
+	"github.com/gokit/cqrskit/repositories/mgorp/mdb"
+
+	"github.com/influx6/faux/flags"
+)
+
+// errors ...
+var (
+	errUnknownFormat     = errors.New("format must be json or table")
+	errUnknownSubCommand = errors.New("unknown sub command, see: cqrskit events help")
+	errNoAggregate       = errors.New("-events.aggregate is required")
+	errNoInstance        = errors.New("-events.instance is required")
+)
+
+// mongoFlags returns the flags of the connection to a mongo event store, defaulting to
+// the environment.
+func mongoFlags() []flags.Flag {
+	return []flags.Flag{
+		&flags.StringFlag{
+			Name:    "host",
+			Default: os.Getenv("MONGO_HOST"),
+			Desc:    "host address of mongo, defaults to $MONGO_HOST",
+		},
+		&flags.StringFlag{
+			Name:    "db",
+			Default: os.Getenv("MONGO_DB"),
+			Desc:    "database of event store, defaults to $MONGO_DB",
+		},
+		&flags.StringFlag{
+			Name:    "user",
+			Default: os.Getenv("MONGO_USER"),
+			Desc:    "user to authenticate as, defaults to $MONGO_USER",
+		},
+		&flags.StringFlag{
+			Name:    "password",
+			Default: os.Getenv("MONGO_PASSWORD"),
+			Desc:    "password of user, defaults to $MONGO_PASSWORD",
+		},
+		&flags.StringFlag{
+			Name:    "authdb",
+			Default: os.Getenv("MONGO_AUTHDB"),
+			Desc:    "database to authenticate against, defaults to $MONGO_AUTHDB",
+		},
+	}
+}
+
+// mongoConfig returns the mdb.Config of the mongoFlags of a command.
+func mongoConfig(ctx flags.Context) mdb.Config {
+	var config mdb.Config
+	config.DB, _ = ctx.GetString("db")
+	config.Host, _ = ctx.GetString("host")
+	config.User, _ = ctx.GetString("user")
+	config.AuthDB, _ = ctx.GetString("authdb")
+	config.Password, _ = ctx.GetString("password")
+	return config
+}
+
+// eventsCommand returns the command inspecting a mongo event store, which never
+// modifies it.
+func eventsCommand() flags.Command {
+	return flags.Command{
+		Name:      "events",
+		ShortDesc: "Inspects the streams of a mongo event store.",
+		Desc: `Inspects the aggregates, streams, snapshots and dispatches of a mongo event store, without modifying it, through the sub commands:
+
+	aggregates list    lists all aggregate ids.
+	streams list       lists the instances of an aggregate with their commits.
+	stream read        reads the commits of an instance of an aggregate from and to a version.
+	snapshots list     lists the snapshots of an aggregate, or of one of it's instances.
+	dispatch pending   lists the undispatched commits of an aggregate, or of one of it's instances.`,
+		Usages: []string{
+			"cqrskit -events.db=events events aggregates list",
+			"cqrskit -events.aggregate=f7091ac77d9b52a3ec5609891cd9f54f events streams list",
+			"cqrskit -events.aggregate=f7091ac77d9b52a3ec5609891cd9f54f -events.instance=1 -events.from=10 -events.format=json events stream read",
+		},
+		Action: exitOnError(func(ctx flags.Context) error {
+			format, _ := ctx.GetString("format")
+			if format != "json" && format != "table" {
+				return errUnknownFormat
+			}
+
+			aggregate, _ := ctx.GetString("aggregate")
+			instance, _ := ctx.GetString("instance")
+			from, _ := ctx.GetInt64("from")
+			to, _ := ctx.GetInt64("to")
+
+			db := mdb.NewMongoDB(mongoConfig(ctx))
+
+			in := inspector{
+				out:       os.Stdout,
+				format:    format,
+				encoder:   cqrskit.JSONEncoder{},
+				catalog:   mgorp.NewCatalog(db),
+				readers:   mgorp.NewReadMaster(db),
+				snapshots: mgorp.NewSnapshotReaders(db),
+				dispatch:  mgorp.NewDispatchMaster(db),
+			}
+
+			switch strings.Join(ctx.Args(), " ") {
+			case "aggregates list":
+				return in.Aggregates(ctx)
+			case "streams list":
+				return in.Streams(ctx, aggregate)
+			case "stream read":
+				return in.Stream(ctx, aggregate, instance, from, to)
+			case "snapshots list":
+				return in.Snapshots(ctx, aggregate, instance)
+			case "dispatch pending":
+				return in.Pending(ctx, aggregate, instance)
+			}
+
+			return errUnknownSubCommand
+		}),
+		Flags: append(mongoFlags(),
+			&flags.StringFlag{
+				Name: "aggregate",
+				Desc: "aggregate id of streams, snapshots or dispatches",
+			},
+			&flags.StringFlag{
+				Name: "instance",
+				Desc: "instance id of a stream of the aggregate",
+			},
+			&flags.Int64Flag{
+				Name: "from",
+				Desc: "version of the first commit read",
+			},
+			&flags.Int64Flag{
+				Name:    "to",
+				Default: -1,
+				Desc:    "version of the last commit read, defaults to the last commit of the stream",
+			},
+			&flags.StringFlag{
+				Name:    "format",
+				Default: "table",
+				Desc:    "format records are printed in, json (one per line) or table",
+			},
+		),
+	}
+}
+
+//*******************************************************************************
+// Inspector
+//*******************************************************************************
+
+// inspector implements the events sub commands over the repositories of an event
+// store, printing records into out in the format, where commits printed as json are
+// encoded with the encoder.
+type inspector struct {
+	out       io.Writer
+	format    string
+	encoder   cqrskit.Encoder
+	catalog   cqrskit.Catalog
+	readers   cqrskit.ReadRepository
+	snapshots cqrskit.SnapshotReaderRepository
+	dispatch  cqrskit.DispatchRepository
+}
+
+// Aggregates prints the ids of all aggregates of the store.
+func (in inspector) Aggregates(ctx context.Context) error {
+	aggregates, err := in.catalog.Aggregates(ctx)
+	if err != nil {
+		return err
+	}
+
+	printer := in.printer("AGGREGATE")
+	for _, aggregate := range aggregates {
+		printer.Print(aggregate, aggregate)
+	}
+	return printer.Flush()
+}
+
+// Streams prints the instances of the aggregate with their commits.
+func (in inspector) Streams(ctx context.Context, aggregate string) error {
+	if aggregate == "" {
+		return errNoAggregate
+	}
+
+	stats, err := in.catalog.Instances(ctx, aggregate)
+	if err != nil {
+		return err
+	}
+
+	printer := in.printer("INSTANCE", "COMMITS", "LAST VERSION", "LAST COMMIT", "DELETED")
+	for _, stat := range stats {
+		printer.Print(stat, stat.InstanceID, stat.Commits, stat.LastVersion, formatTime(stat.LastCommit), formatTime(stat.Deleted))
+	}
+	return printer.Flush()
+}
+
+// Stream prints the commits of the instance of the aggregate with versions from the
+// from version to the to version, or to it's last commit if to is negative.
+func (in inspector) Stream(ctx context.Context, aggregate string, instance string, from int64, to int64) error {
+	if aggregate == "" {
+		return errNoAggregate
+	}
+
+	if instance == "" {
+		return errNoInstance
+	}
+
+	reader, err := in.readers.Reader(aggregate, instance)
+	if err != nil {
+		return err
+	}
+
+	commits, err := reader.Stream(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	defer commits.Close()
+
+	printer := in.printer("VERSION", "COMMIT", "CREATED", "COMMAND", "EVENTS")
+	for commits.Next() {
+		commit := commits.Commit()
+		if to >= 0 && int64(commit.Version) > to {
+			break
+		}
+
+		if in.format == "json" {
+			encoded, err := in.encoder.Encode(commit)
+			if err != nil {
+				return err
+			}
+			printer.Line(encoded)
+			continue
+		}
+
+		types := make([]string, 0, len(commit.Events))
+		for _, event := range commit.Events {
+			types = append(types, event.Type)
+		}
+
+		printer.Print(commit, commit.Version, commit.CommitID, formatTime(commit.Created), commit.Command, strings.Join(types, ","))
+	}
+
+	if err := commits.Err(); err != nil {
+		return err
+	}
+
+	return printer.Flush()
+}
+
+// Snapshots prints the snapshots of the instance of the aggregate, or of all it's
+// instances if instance is empty.
+func (in inspector) Snapshots(ctx context.Context, aggregate string, instance string) error {
+	instances, err := in.instances(ctx, aggregate, instance)
+	if err != nil {
+		return err
+	}
+
+	printer := in.printer("INSTANCE", "REVISION", "FROM VERSION", "TO VERSION", "SNAPSHOT")
+	for _, instanceID := range instances {
+		reader, err := in.snapshots.Reader(aggregate, instanceID)
+		if err != nil {
+			return err
+		}
+
+		snapshots, err := reader.ReadAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range snapshots {
+			printer.Print(snapshot, instanceID, snapshot.Revision, snapshot.FromVersion, snapshot.ToVersion, snapshot.SnapID)
+		}
+	}
+	return printer.Flush()
+}
+
+// Pending prints the undispatched commits of the instance of the aggregate, or of all
+// it's instances if instance is empty.
+func (in inspector) Pending(ctx context.Context, aggregate string, instance string) error {
+	instances, err := in.instances(ctx, aggregate, instance)
+	if err != nil {
+		return err
+	}
+
+	printer := in.printer("INSTANCE", "DISPATCH", "COMMIT")
+	for _, instanceID := range instances {
+		dispatcher, err := in.dispatch.Dispatcher(aggregate, instanceID)
+		if err != nil {
+			return err
+		}
+
+		pending, err := dispatcher.Undispatched(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, dispatch := range pending {
+			printer.Print(dispatch, instanceID, dispatch.DispatchID, dispatch.CommitID)
+		}
+	}
+	return printer.Flush()
+}
+
+// instances returns the giving instance, or all instances of the aggregate if empty.
+func (in inspector) instances(ctx context.Context, aggregate string, instance string) ([]string, error) {
+	if aggregate == "" {
+		return nil, errNoAggregate
+	}
+
+	if instance != "" {
+		return []string{instance}, nil
+	}
+
+	stats, err := in.catalog.Instances(ctx, aggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		instances = append(instances, stat.InstanceID)
+	}
+	return instances, nil
+}
+
+// printer returns a recordPrinter into the out of the inspector, with the giving
+// columns of tables.
+func (in inspector) printer(columns ...string) *recordPrinter {
+	printer := &recordPrinter{json: in.format == "json", out: in.out}
+	if !printer.json {
+		printer.table = tabwriter.NewWriter(in.out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(printer.table, strings.Join(columns, "\t"))
+	}
+	return printer
+}
+
+// recordPrinter prints records either as json, one per line, or as the rows of a table.
+type recordPrinter struct {
+	json  bool
+	out   io.Writer
+	table *tabwriter.Writer
+	err   error
+}
+
+// Print prints the record as json or the cells of it's row.
+func (p *recordPrinter) Print(record interface{}, cells ...interface{}) {
+	if p.err != nil {
+		return
+	}
+
+	if p.json {
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			p.err = err
+			return
+		}
+		p.Line(encoded)
+		return
+	}
+
+	row := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		row = append(row, fmt.Sprint(cell))
+	}
+	_, p.err = fmt.Fprintln(p.table, strings.Join(row, "\t"))
+}
+
+// Line prints an already encoded record.
+func (p *recordPrinter) Line(encoded []byte) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintln(p.out, string(encoded))
+}
+
+// Flush returns the first error met while printing, after flushing the table.
+func (p *recordPrinter) Flush() error {
+	if p.table != nil {
+		if err := p.table.Flush(); err != nil && p.err == nil {
+			p.err = err
+		}
+	}
+	return p.err
+}
+
+// formatTime returns the time in RFC3339, or - for zero times.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/gokit/cqrskit/internal/cqrsgen"
+	"github.com/gokit/cqrskit/repositories/mgorp"
+	"github.com/gokit/cqrskit/repositories/mgorp/mdb"
 
 	"github.com/influx6/faux/flags"
 	"github.com/influx6/faux/metrics"
@@ -17,8 +20,11 @@ func main() {
 		Name:      "generate",
 		ShortDesc: "Generates a cqrs+es like API for struct types.",
 		Desc:      "Generates a EventSourcing+CQRS API from target structs to create ES based services with.",
-		Action: func(ctx flags.Context) error {
+		Action: exitOnError(func(ctx flags.Context) error {
 			force, _ := ctx.GetBool("force")
+			check, _ := ctx.GetBool("check")
+			catalog, _ := ctx.GetBool("catalog")
+			strict, _ := ctx.GetBool("strict")
 			dest, _ := ctx.GetString("dest")
 			target, _ := ctx.GetString("target")
 			verbose, _ := ctx.GetBool("verbose")
@@ -40,16 +46,81 @@ func main() {
 
 			currentdir = filepath.Join(currentdir, target)
 
+			lockFile := filepath.Join(dest, cqrsgen.LockFileName)
+			lock, err := cqrsgen.ReadLock(lockFile)
+			if err != nil {
+				return err
+			}
+
+			generator := cqrsgen.Generator{Strict: strict, Lock: lock}
+
 			generators := ast.NewAnnotationRegistryWith(logs)
-			generators.Register("@escqrs", cqrsgen.ESCQRSGen)
+			generators.Register("@escqrs", generator.Generate)
+			generators.Register(cqrsgen.ProjectionAnnotation, generator.GenerateProjection)
+			generators.Register(cqrsgen.SagaAnnotation, generator.GenerateSaga)
 
 			res, err := ast.ParseAnnotations(logs, currentdir)
 			if err != nil {
 				return err
 			}
 
-			return ast.SimplyParse(dest, logs, generators, force, res...)
-		},
+			var drifts []cqrsgen.Drift
+			if check {
+				drifts, err = cqrsgen.Check(dest, generators, res...)
+			} else {
+				err = ast.SimplyParse(dest, logs, generators, force, res...)
+			}
+
+			if err == nil {
+				err = generator.Release()
+			}
+
+			if err == nil && !check {
+				err = lock.Write(lockFile)
+			}
+
+			if err == nil && catalog && !check {
+				events := generator.Catalog()
+				if err = cqrsgen.WriteJSON(filepath.Join(dest, cqrsgen.CatalogFileName), events); err == nil {
+					err = cqrsgen.WriteJSON(filepath.Join(dest, cqrsgen.AsyncAPIFileName), events.AsyncAPI(filepath.Base(dest)))
+				}
+			}
+
+			for _, diagnostic := range generator.Diagnostics() {
+				if diagnostic.Severity == cqrsgen.Warning || verbose {
+					if rel, relErr := filepath.Rel(currentdir, diagnostic.File); relErr == nil && diagnostic.File != "" {
+						diagnostic.File = rel
+					}
+					fmt.Fprintln(os.Stderr, diagnostic)
+				}
+			}
+
+			if err != nil {
+				return err
+			}
+
+			// flags.Run does not exit with the error of an action, so drift is
+			// reported with a non-zero exit code directly.
+			for _, drift := range drifts {
+				if rel, relErr := filepath.Rel(dest, drift.File); relErr == nil {
+					drift.File = rel
+				}
+
+				if drift.Missing {
+					fmt.Printf("%s: missing\n", drift.File)
+				} else {
+					fmt.Printf("%s: out of date\n", drift.File)
+				}
+				fmt.Print(drift.Diff)
+			}
+
+			if len(drifts) != 0 {
+				fmt.Fprintf(os.Stderr, "%d generated file(s) out of date, run cqrskit generate\n", len(drifts))
+				os.Exit(1)
+			}
+
+			return nil
+		}),
 		Flags: []flags.Flag{
 			&flags.BoolFlag{
 				Name: "verbose",
@@ -59,6 +130,18 @@ func main() {
 				Name: "force",
 				Desc: "force regeneration of packages annotation directives.",
 			},
+			&flags.BoolFlag{
+				Name: "check",
+				Desc: "check compares generated code with existing files without writing them, exiting non-zero on drift.",
+			},
+			&flags.BoolFlag{
+				Name: "catalog",
+				Desc: "catalog writes a JSON Schema catalog and an AsyncAPI document of all handled events.",
+			},
+			&flags.BoolFlag{
+				Name: "strict",
+				Desc: "strict fails generation when any Handle or Execute method is skipped.",
+			},
 			&flags.StringFlag{
 				Name:    "dest",
 				Default: "./",
@@ -70,5 +153,31 @@ func main() {
 				Desc:    "-target=./ defines relative path of target for code gen",
 			},
 		},
-	})
+	}, flags.Command{
+		Name:      "migrate-indexes",
+		ShortDesc: "Rebuilds the indexes of a mongo event store.",
+		Desc:      "Rebuilds the indexes of a mongo event store created by older versions, scoping unique keys to each aggregate instance.",
+		Action: exitOnError(func(ctx flags.Context) error {
+			dropped, err := mgorp.MigrateIndexes(mdb.NewMongoDB(mongoConfig(ctx)))
+			for _, index := range dropped {
+				fmt.Printf("dropped index %q of collection %q\n", index.Name, index.Collection)
+			}
+
+			return err
+		}),
+		Flags: mongoFlags(),
+	}, eventsCommand(), exportCommand(), importCommand())
+}
+
+// exitOnError returns the action exiting with a non-zero code on error, as flags.Run
+// prints the error of an action without a newline and exits with zero, so failures
+// of CI runs and scripts would go unnoticed.
+func exitOnError(action flags.Action) flags.Action {
+	return func(ctx flags.Context) error {
+		if err := action(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return nil
+	}
 }
@@ -66,6 +66,17 @@ func (ss staticSaga) Transition(m Message) error {
 	return ss.saga.Transition(m, ss.action)
 }
 
+//*******************************************************************************
+// Projection Types
+//*******************************************************************************
+
+// Projection defines a read model built by projecting the events of EventCommits
+// into it, as generated for structs annotated with @escqrs-projection. Events a
+// Projection does not handle are ignored.
+type Projection interface {
+	Project(EventCommit) error
+}
+
 //*******************************************************************************
 // Snapshot Type
 //*******************************************************************************
@@ -165,6 +176,18 @@ type Decoder interface {
 	Decode([]byte) (EventCommit, error)
 }
 
+// EventEncoder defines a type which embodies the conversion or serialization of a
+// single EventMessage into a byte slice.
+type EventEncoder interface {
+	EncodeEvent(EventMessage) ([]byte, error)
+}
+
+// EventDecoder defines a type which embodies the deserialization of a byte slice into
+// a EventMessage.
+type EventDecoder interface {
+	DecodeEvent([]byte) (EventMessage, error)
+}
+
 //*******************************************************************************
 // ESCQRS Repository Struct
 //*******************************************************************************
@@ -207,9 +230,14 @@ type CommitHeader struct {
 // All ID values must be UUID and must commit from client of request
 // and not any other valid to ensure and maximize idempotent insertions and
 // de-duplication of event commit requests.
+//
+// If Version is above zero, the commit must be written at that version, else the
+// repository returns ErrConcurrentWrites without writing it, so a commit computed
+// from an older state of the stream never lands after commits it did not see.
 type EventCommitRequest struct {
 	ID      string
 	Command string
+	Version int
 	Events  []Event
 	Created time.Time
 	Header  map[string]interface{}
@@ -225,11 +253,55 @@ type WriteRepository interface {
 // WriteRepo embodies a repository which houses the store
 // of events for giving type .
 type WriteRepo interface {
+	StreamLifecycle
+
 	Count(context.Context) (int, error)
 	LastCommitVersion(context.Context) (CommitHeader, error)
 	Write(context.Context, EventCommitRequest) (CommitHeader, error)
 }
 
+// CommitImporter embodies a WriteRepo which can store a commit exactly as giving, keeping
+// it's CommitID, Version and Created time, as done when migrating streams between stores.
+// The commit's version must be above the last commit version of the stream, gaps being
+// allowed, and imported commits are never dispatched again.
+type CommitImporter interface {
+	Import(context.Context, EventCommit) (CommitHeader, error)
+}
+
+// StreamLifecycle embodies the operations which end or shorten the stream of commits of
+// an aggregate instance.
+//
+// Tombstone soft deletes the stream, keeping it's commits, after which all writes and
+// reads of the stream fail with a StreamDeletedError. Delete hard deletes the stream and
+// all records of it, returning total commits removed, after which the instance starts
+// afresh. TruncateBefore removes all commits below the giving version, which must not be
+// above the last commit version, returning total commits removed; it is used to drop
+// history already captured by a snapshot.
+type StreamLifecycle interface {
+	Tombstone(context.Context) error
+	Delete(context.Context) (int, error)
+	TruncateBefore(ctx context.Context, version int64) (int, error)
+}
+
+// StreamDeletedError is returned by repositories when writing to or reading from the
+// stream of an aggregate instance which has being tombstoned.
+type StreamDeletedError struct {
+	AggregateID string
+	InstanceID  string
+	Deleted     time.Time
+}
+
+// Error implements the error interface.
+func (sde StreamDeletedError) Error() string {
+	return "stream " + sde.AggregateID + "/" + sde.InstanceID + " deleted at " + sde.Deleted.Format(time.RFC3339)
+}
+
+// IsStreamDeleted returns true/false if the error is a StreamDeletedError.
+func IsStreamDeleted(err error) bool {
+	_, ok := err.(StreamDeletedError)
+	return ok
+}
+
 //*******************************************************************************
 // Read Repository Interface
 //*******************************************************************************
@@ -252,6 +324,102 @@ type ReadRepo interface {
 	ReadSinceCount(ctx context.Context, count int) ([]EventCommit, error)
 	ReadSinceTime(ctx context.Context, last time.Time, limit int) ([]EventCommit, error)
 	ReadSinceVersion(ctx context.Context, version int64, limit int) ([]EventCommit, error)
+	Stream(ctx context.Context, fromVersion int64) (CommitIterator, error)
+	Read(ctx context.Context, direction ReadDirection, fromVersion int64, limit int) ([]EventCommit, error)
+	ReadRange(ctx context.Context, window TimeRange, cursor string, limit int) (CommitPage, error)
+}
+
+// CommitQuery embodies a reader over the commits of all aggregates and their instances,
+// ordered by their time of creation.
+type CommitQuery interface {
+	ReadRange(ctx context.Context, window TimeRange, cursor string, limit int) (CommitPage, error)
+}
+
+// TimeRange defines a window over the creation time of commits, where Since is inclusive
+// and Until is exclusive. A zero Since or Until leaves that end of the window open.
+type TimeRange struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+// Since returns a TimeRange of all commits created from the giving time.
+func Since(since time.Time) TimeRange {
+	return TimeRange{Since: since}
+}
+
+// Until returns a TimeRange of all commits created before the giving time.
+func Until(until time.Time) TimeRange {
+	return TimeRange{Until: until}
+}
+
+// Between returns a TimeRange of all commits created from since and before until.
+func Between(since time.Time, until time.Time) TimeRange {
+	return TimeRange{Since: since, Until: until}
+}
+
+// CommitPage embodies a page of commits read by a paginated query. Cursor holds the opaque
+// token used to read the next page, and is empty once no commits remain.
+type CommitPage struct {
+	Commits []EventCommit `json:"commits"`
+	Cursor  string        `json:"cursor"`
+}
+
+// Catalog embodies a read-only view over the aggregates, their instances and the commits
+// held within a store, used to inspect the store across streams.
+type Catalog interface {
+	Aggregates(ctx context.Context) ([]string, error)
+	Instances(ctx context.Context, aggregateID string) ([]InstanceStat, error)
+	FindByEventType(ctx context.Context, eventType string, window TimeRange, cursor string, limit int) (CommitPage, error)
+}
+
+// InstanceStat embodies the commit statistics of a single instance of an aggregate. The
+// LastVersion and LastCommit of an instance without commits are zero, as is the Deleted
+// time of an instance which has not being tombstoned.
+type InstanceStat struct {
+	AggregateID string    `json:"aggregate_id"`
+	InstanceID  string    `json:"instance_id"`
+	Commits     int       `json:"commits"`
+	LastVersion int       `json:"last_version"`
+	LastCommit  time.Time `json:"last_commit"`
+	Deleted     time.Time `json:"deleted"`
+}
+
+// ReadDirection defines the order in which ReadRepo.Read walks through the commits
+// of a aggregate instance.
+type ReadDirection int
+
+// consts of ReadDirection values.
+const (
+	// ReadForward reads commits from the version upwards, in ascending order of version.
+	ReadForward ReadDirection = iota
+
+	// ReadBackward reads commits from the version downwards, in descending order of version,
+	// where a version of zero or below starts from the latest commit.
+	ReadBackward
+)
+
+// CommitIterator embodies a iterator over a stream of EventCommits, which are retrieved
+// from the store as the iterator advances instead of all at once. Next must be called
+// before every call to Commit, and Close must always be called once done.
+//
+//	for iter.Next() {
+//		commit := iter.Commit()
+//	}
+//
+//	if err := iter.Err(); err != nil {}
+type CommitIterator interface {
+	// Next advances to the next EventCommit, returning false once no more are
+	// available or an error occurred.
+	Next() bool
+
+	// Commit returns the current EventCommit.
+	Commit() EventCommit
+
+	// Err returns the error which stopped the iterator, if any.
+	Err() error
+
+	// Close ends the iterator, releasing it's resources.
+	Close() error
 }
 
 //*******************************************************************************
@@ -281,6 +449,28 @@ type Publisher interface {
 	Publish(string, EventCommit, AckHandler) error
 }
 
+// EventMessage embodies a single event split out of a EventCommit to be published
+// on it's own, it carries the details of it's parent commit and the index of the event
+// within said commit.
+type EventMessage struct {
+	CommitID    string `json:"commit_id"`
+	InstanceID  string `json:"instance_id"`
+	AggregateID string `json:"aggregate_id"`
+	Version     int    `json:"version"`
+	Index       int    `json:"index"`
+	Total       int    `json:"total"`
+	Event       Event  `json:"event"`
+}
+
+// EventPublisher defines an interface which defines the implementation to be done
+// for the publishing of a single EventMessage using a desired namespace or tag.
+// It's expects the PublishEvent method returns an error if the giving EventMessage failed
+// to be pushed into the underline queue else will call the handler once said request
+// is added successfully into the queue.
+type EventPublisher interface {
+	PublishEvent(string, EventMessage, AckHandler) error
+}
+
 //*******************************************************************************
 // DispatchRepo Repository Interface
 //*******************************************************************************
@@ -21,3 +21,41 @@ func (JSONDecoder) Decode(data []byte) (EventCommit, error) {
 	err := json.Unmarshal(data, &commit)
 	return commit, err
 }
+
+// EncodeEvent attempts to encode a EventMessage into a json byte slice.
+// It returns an error if it failed.
+func (JSONEncoder) EncodeEvent(msg EventMessage) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// DecodeEvent attempts to decode byte slice of json into a EventMessage.
+// It returns an error if it failed.
+func (JSONDecoder) DecodeEvent(data []byte) (EventMessage, error) {
+	var msg EventMessage
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// EncodeEvent attempts to encode provided EventMessage with the giving Encoder, if
+// the Encoder does not implement the EventEncoder interface, then an ErrNoEventEncoder
+// is returned.
+func EncodeEvent(encoder Encoder, msg EventMessage) ([]byte, error) {
+	eventEncoder, ok := encoder.(EventEncoder)
+	if !ok {
+		return nil, ErrNoEventEncoder
+	}
+
+	return eventEncoder.EncodeEvent(msg)
+}
+
+// DecodeEvent attempts to decode provided byte slice into a EventMessage with the giving Decoder,
+// if the Decoder does not implement the EventDecoder interface, then an ErrNoEventDecoder
+// is returned.
+func DecodeEvent(decoder Decoder, data []byte) (EventMessage, error) {
+	eventDecoder, ok := decoder.(EventDecoder)
+	if !ok {
+		return EventMessage{}, ErrNoEventDecoder
+	}
+
+	return eventDecoder.DecodeEvent(data)
+}
@@ -0,0 +1,46 @@
+package users
+
+import (
+	"github.com/gokit/cqrskit"
+)
+
+// UserDirectory is a read model of the emails of all users.
+// @escqrs-projection
+type UserDirectory struct {
+	Emails []string
+}
+
+func (d *UserDirectory) OnUserEmailUpdated(ev UserEmailUpdated) error {
+	d.Emails = append(d.Emails, ev.New)
+	return nil
+}
+
+type SendVerification struct {
+	Email string
+}
+
+type EmailVerified struct {
+	Email string
+}
+
+// EmailVerification is a saga requesting verification of updated emails.
+// @escqrs-saga
+type EmailVerification struct {
+	Pending []string
+}
+
+func (v *EmailVerification) OnUserEmailUpdated(ev UserEmailUpdated, action cqrskit.SagaAction) error {
+	v.Pending = append(v.Pending, ev.New)
+	action([]cqrskit.Message{{Type: "SendVerification", Payload: SendVerification{Email: ev.New}}}, nil)
+	return nil
+}
+
+func (v *EmailVerification) OnEmailVerified(ev EmailVerified, action cqrskit.SagaAction) error {
+	for index, email := range v.Pending {
+		if email == ev.Email {
+			v.Pending = append(v.Pending[:index], v.Pending[index+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package users_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/gokit/cqrskit/examples/users"
+)
+
+func TestUserDirectoryProjectDecodedCommit(t *testing.T) {
+	encoded, err := cqrskit.JSONEncoder{}.Encode(cqrskit.EventCommit{
+		Version: 1,
+		Events:  []cqrskit.Event{users.NewUserEmailUpdatedEvent(users.UserEmailUpdated{New: "bob@example.com"})},
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully encoded commit")
+	}
+	tests.Passed("Should have successfully encoded commit")
+
+	// Events decoded from json hold their data as a map.
+	commit, err := cqrskit.JSONDecoder{}.Decode(encoded)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully decoded commit")
+	}
+	tests.Passed("Should have successfully decoded commit")
+
+	var directory users.UserDirectory
+	if err := directory.Project(commit); err != nil {
+		tests.FailedWithError(err, "Should have successfully projected decoded commit")
+	}
+	tests.Passed("Should have successfully projected decoded commit")
+
+	if len(directory.Emails) != 1 || directory.Emails[0] != "bob@example.com" {
+		tests.Info("Received: %+v", directory.Emails)
+		tests.Failed("Should have projected event of decoded commit")
+	}
+	tests.Passed("Should have projected event of decoded commit")
+}
+
+func TestEmailVerificationTransitionDecodedMessage(t *testing.T) {
+	encoded, err := json.Marshal(cqrskit.Message{Type: "UserEmailUpdated", Payload: users.UserEmailUpdated{New: "bob@example.com"}})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully encoded message")
+	}
+	tests.Passed("Should have successfully encoded message")
+
+	var msg cqrskit.Message
+	if err := json.Unmarshal(encoded, &msg); err != nil {
+		tests.FailedWithError(err, "Should have successfully decoded message")
+	}
+	tests.Passed("Should have successfully decoded message")
+
+	var sent []cqrskit.Message
+	var verification users.EmailVerification
+	if err := verification.Transition(msg, func(messages []cqrskit.Message, _ []cqrskit.EventCommit) {
+		sent = append(sent, messages...)
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully transited with decoded message")
+	}
+	tests.Passed("Should have successfully transited with decoded message")
+
+	if len(verification.Pending) != 1 || len(sent) != 1 {
+		tests.Info("Received: %+v pending, %+v sent", verification.Pending, sent)
+		tests.Failed("Should have handled payload of decoded message")
+	}
+	tests.Passed("Should have handled payload of decoded message")
+}
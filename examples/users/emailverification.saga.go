@@ -0,0 +1,41 @@
+package users
+
+import (
+	"github.com/gokit/cqrskit"
+)
+
+//*******************************************************************************
+// EmailVerification Saga
+//*******************************************************************************
+
+// Transition implements the cqrskit.Saga interface, transiting a EmailVerification by calling the
+// appropriate method for the type of the payload of the giving cqrskit.Message. The payload is
+// decoded with cqrskit.Events for the Type of the message first, as when read back from stores
+// or publishers as a map. cqrskit.ErrUnknownMessage is returned for messages without one.
+func (e *EmailVerification) Transition(msg cqrskit.Message, action cqrskit.SagaAction) error {
+	decoded, err := cqrskit.Events.Decode(cqrskit.Event{Type: msg.Type, Data: msg.Payload})
+	if err != nil {
+		return err
+	}
+
+	switch payload := decoded.Data.(type) {
+	case UserEmailUpdated:
+		return e.OnUserEmailUpdated(payload, action)
+	case EmailVerified:
+		return e.OnEmailVerified(payload, action)
+	}
+	return cqrskit.ErrUnknownMessage
+}
+
+var _ cqrskit.Saga = (*EmailVerification)(nil)
+
+// RegisterEmailVerificationMessages registers the types of the payloads of all messages transiting
+// a EmailVerification with the giving cqrskit.EventRegistry.
+func RegisterEmailVerificationMessages(registry *cqrskit.EventRegistry) {
+	registry.Register("UserEmailUpdated", UserEmailUpdated{})
+	registry.Register("EmailVerified", EmailVerified{})
+}
+
+func init() {
+	RegisterEmailVerificationMessages(cqrskit.Events)
+}
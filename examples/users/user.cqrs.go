@@ -10,6 +10,10 @@ var (
 	// UserAggregateID represents the unique aggregate id for all events
 	// related to the User type. It is the typeName hashed using a md5 sum.
 	UserAggregateID = "f7091ac77d9b52a3ec5609891cd9f54f"
+
+	// UserAggregateVersion represents the version of the aggregate id of the
+	// User type, set by the version parameter of it's @escqrs annotation (defaults to 1).
+	UserAggregateVersion = 1
 )
 
 //*******************************************************************************
@@ -22,11 +26,45 @@ func (u *User) Apply(evs cqrskit.EventCommit) error {
 	for _, event := range evs.Events {
 		switch ev := event.Data.(type) {
 		case UserEmailUpdated:
-			return u.HandleUserEmailUpdated(ev)
+			if err := u.HandleUserEmailUpdated(ev); err != nil {
+				return err
+			}
 		case events.UserNameUpdated:
-			return u.HandleUserNameUpdated(ev)
+			if err := u.HandleUserNameUpdated(ev); err != nil {
+				return err
+			}
 
 		}
 	}
 	return nil
 }
+
+//*******************************************************************************
+// User Events
+//*******************************************************************************
+
+// RegisterUserEvents registers the types of all events applied to a User
+// with the giving cqrskit.EventRegistry.
+func RegisterUserEvents(registry *cqrskit.EventRegistry) {
+	registry.Register(UserEmailUpdatedEventType, UserEmailUpdated{})
+	registry.Register(UserNameUpdatedEventType, events.UserNameUpdated{})
+}
+
+func init() {
+	RegisterUserEvents(cqrskit.Events)
+}
+
+//*******************************************************************************
+// User Command Dispatcher
+//*******************************************************************************
+
+// Execute dispatches the giving command to the Execute method of a User for it's
+// type, returning the events it produced. cqrskit.ErrUnknownCommand is returned for commands
+// without one.
+func (u *User) Execute(cmd interface{}) ([]cqrskit.Event, error) {
+	switch command := cmd.(type) {
+	case UpdateUserEmail:
+		return u.ExecuteUpdateUserEmail(command)
+	}
+	return nil, cqrskit.ErrUnknownCommand
+}
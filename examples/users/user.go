@@ -1,12 +1,16 @@
 package users
 
-import "github.com/gokit/cqrskit/examples/users/events"
+import (
+	"github.com/gokit/cqrskit"
+	"github.com/gokit/cqrskit/examples/users/events"
+)
 
 //@escqrs
 type User struct {
-	Version  int
-	Email    string
-	Username string
+	InstanceID string
+	Version    int
+	Email      string
+	Username   string
 }
 
 type UserEmailUpdated struct {
@@ -25,3 +29,13 @@ func (u *User) HandleUserNameUpdated(ev events.UserNameUpdated) error {
 func (u *User) HandleUserRackUpdated(ev UserEmailUpdated) error {
 	return nil
 }
+
+type UpdateUserEmail struct {
+	Email string
+}
+
+func (u *User) ExecuteUpdateUserEmail(cmd UpdateUserEmail) ([]cqrskit.Event, error) {
+	return []cqrskit.Event{
+		NewUserEmailUpdatedEvent(UserEmailUpdated{New: cmd.Email}),
+	}, nil
+}
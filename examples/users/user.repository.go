@@ -0,0 +1,63 @@
+package users
+
+import (
+	"context"
+
+	"github.com/gokit/cqrskit"
+)
+
+//*******************************************************************************
+// User Repository
+//*******************************************************************************
+
+// UserRepository provides typed loading and saving of User aggregates
+// over a cqrskit.ESCQRS, using UserAggregateID as their aggregate id.
+type UserRepository struct {
+	es       cqrskit.ESCQRS
+	registry *cqrskit.EventRegistry
+}
+
+// NewUserRepository returns a new instance of UserRepository, which decodes
+// events with the default cqrskit.Events registry.
+func NewUserRepository(es cqrskit.ESCQRS) *UserRepository {
+	return &UserRepository{es: es, registry: cqrskit.Events}
+}
+
+// Load returns the User of the giving instance, built from it's latest snapshot if
+// User implements cqrskit.SnapshotMarshaler, and all commits after it.
+func (r *UserRepository) Load(ctx context.Context, instanceID string) (*User, error) {
+	var u User
+	version, err := cqrskit.LoadAggregate(ctx, r.es, r.registry, UserAggregateID, instanceID, &u)
+	if err != nil {
+		return nil, err
+	}
+
+	u.InstanceID = instanceID
+	u.Version = version
+	return &u, nil
+}
+
+// Save writes the events produced by a command on the User as a single commit, then
+// applies them to it. The commit is rejected with cqrskit.ErrStaleAggregate if
+// the User is behind the last commit of it's instance, else it's Version is moved to
+// the new commit.
+func (r *UserRepository) Save(ctx context.Context, u *User, command string, events ...cqrskit.Event) (cqrskit.CommitHeader, error) {
+	header, err := cqrskit.SaveAggregate(ctx, r.es, UserAggregateID, u.InstanceID, u.Version, command, events...)
+	if err != nil {
+		return header, err
+	}
+
+	if err := u.Apply(cqrskit.EventCommit{
+		Events:      events,
+		Command:     command,
+		Version:     header.Version,
+		CommitID:    header.CommitID,
+		InstanceID:  header.InstanceID,
+		AggregateID: header.AggregateID,
+	}); err != nil {
+		return header, err
+	}
+
+	u.Version = header.Version
+	return header, nil
+}
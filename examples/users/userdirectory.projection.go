@@ -0,0 +1,42 @@
+package users
+
+import (
+	"github.com/gokit/cqrskit"
+)
+
+//*******************************************************************************
+// UserDirectory Projection
+//*******************************************************************************
+
+// Project embodies the internal logic necessary to project specific events into a UserDirectory
+// by calling appropriate methods. The data of events is decoded with cqrskit.Events first, as
+// when read back from stores or publishers as a map. Events without one are ignored.
+func (u *UserDirectory) Project(evs cqrskit.EventCommit) error {
+	for _, event := range evs.Events {
+		event, err := cqrskit.Events.Decode(event)
+		if err != nil {
+			return err
+		}
+
+		switch ev := event.Data.(type) {
+		case UserEmailUpdated:
+			if err := u.OnUserEmailUpdated(ev); err != nil {
+				return err
+			}
+
+		}
+	}
+	return nil
+}
+
+var _ cqrskit.Projection = (*UserDirectory)(nil)
+
+// RegisterUserDirectoryEvents registers the types of all events projected into a UserDirectory
+// with the giving cqrskit.EventRegistry.
+func RegisterUserDirectoryEvents(registry *cqrskit.EventRegistry) {
+	registry.Register("UserEmailUpdated", UserEmailUpdated{})
+}
+
+func init() {
+	RegisterUserDirectoryEvents(cqrskit.Events)
+}
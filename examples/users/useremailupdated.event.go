@@ -0,0 +1,22 @@
+package users
+
+import (
+	"github.com/gokit/cqrskit"
+)
+
+//*******************************************************************************
+// UserEmailUpdated Event
+//*******************************************************************************
+
+// UserEmailUpdatedEventType is the stable type name of UserEmailUpdated events, set as the Type of
+// their cqrskit.Event. It is shared by all aggregates of the package applying them.
+const UserEmailUpdatedEventType = "UserEmailUpdated"
+
+// NewUserEmailUpdatedEvent returns a cqrskit.Event of type UserEmailUpdatedEventType holding the
+// giving UserEmailUpdated.
+func NewUserEmailUpdatedEvent(ev UserEmailUpdated) cqrskit.Event {
+	return cqrskit.Event{
+		Type: UserEmailUpdatedEventType,
+		Data: ev,
+	}
+}
@@ -0,0 +1,24 @@
+package users
+
+import (
+	events "github.com/gokit/cqrskit/examples/users/events"
+
+	"github.com/gokit/cqrskit"
+)
+
+//*******************************************************************************
+// UserNameUpdated Event
+//*******************************************************************************
+
+// UserNameUpdatedEventType is the stable type name of events.UserNameUpdated events, set as the Type of
+// their cqrskit.Event. It is shared by all aggregates of the package applying them.
+const UserNameUpdatedEventType = "UserNameUpdated"
+
+// NewUserNameUpdatedEvent returns a cqrskit.Event of type UserNameUpdatedEventType holding the
+// giving events.UserNameUpdated.
+func NewUserNameUpdatedEvent(ev events.UserNameUpdated) cqrskit.Event {
+	return cqrskit.Event{
+		Type: UserNameUpdatedEventType,
+		Data: ev,
+	}
+}
@@ -0,0 +1,431 @@
+package cqrsgen
+
+import (
+	"encoding/json"
+	goast "go/ast"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influx6/moz/ast"
+)
+
+// consts of file names of the catalog of events written by the cqrskit generate command.
+const (
+	CatalogFileName  = "cqrskit.catalog.json"
+	AsyncAPIFileName = "cqrskit.asyncapi.json"
+)
+
+// Schema embodies a JSON Schema document.
+type Schema map[string]interface{}
+
+// Catalog embodies the machine-readable catalog of all events handled by the aggregates and
+// projections generated, with the JSON Schema of their payload.
+type Catalog struct {
+	Events []CatalogEvent `json:"events"`
+}
+
+// CatalogEvent embodies an event of a Catalog, with it's stable type name, the Go type of it's
+// payload and all types consuming it.
+type CatalogEvent struct {
+	Type      string            `json:"type"`
+	GoType    string            `json:"go_type"`
+	Schema    Schema            `json:"schema"`
+	Consumers []CatalogConsumer `json:"consumers"`
+}
+
+// CatalogConsumer embodies an aggregate or projection consuming an event through a method.
+type CatalogConsumer struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Package     string `json:"package"`
+	Method      string `json:"method"`
+	AggregateID string `json:"aggregate_id,omitempty"`
+}
+
+// catalog collects the events of a Generator by their Go type.
+type catalog struct {
+	ml     sync.Mutex
+	events map[string]*CatalogEvent
+}
+
+// record adds the events of the pairs of a generated struct of the giving kind to the
+// catalog of the Generator, reporting an event whose type name is used by another Go type.
+func (g *Generator) record(kind string, str ast.StructDeclaration, pkg ast.Package, aggregateID string, pairs []MethodEventPair) {
+	g.catalog.ml.Lock()
+	defer g.catalog.ml.Unlock()
+
+	if g.catalog.events == nil {
+		g.catalog.events = map[string]*CatalogEvent{}
+	}
+
+	for _, pair := range pairs {
+		goType := pair.EventPackage.Path + "." + pair.Event.Object.Name.Name
+
+		event, ok := g.catalog.events[goType]
+		if !ok {
+			for _, other := range g.catalog.events {
+				if other.Type == pair.Name {
+					g.report(Warning, str, pair.Method, "event type name %q is also used by %s", pair.Name, other.GoType)
+				}
+			}
+
+			schema := structSchema(pair.Event.Struct.Fields, pair.EventPackage, map[string]bool{pair.Event.Object.Name.Name: true})
+			schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+			schema["title"] = pair.Name
+
+			event = &CatalogEvent{Type: pair.Name, GoType: goType, Schema: schema}
+			g.catalog.events[goType] = event
+		}
+
+		event.Consumers = append(event.Consumers, CatalogConsumer{
+			Kind:        kind,
+			Name:        str.Name,
+			Package:     pkg.Path,
+			Method:      pair.Method.FuncName,
+			AggregateID: aggregateID,
+		})
+	}
+}
+
+// Catalog returns the Catalog of all events recorded so far, sorted by their type name.
+func (g *Generator) Catalog() Catalog {
+	g.catalog.ml.Lock()
+	defer g.catalog.ml.Unlock()
+
+	var catalog Catalog
+	for _, event := range g.catalog.events {
+		consumers := append([]CatalogConsumer(nil), event.Consumers...)
+		sort.Slice(consumers, func(i, j int) bool {
+			if consumers[i].Package != consumers[j].Package {
+				return consumers[i].Package < consumers[j].Package
+			}
+			return consumers[i].Name < consumers[j].Name
+		})
+
+		copied := *event
+		copied.Consumers = consumers
+		catalog.Events = append(catalog.Events, copied)
+	}
+
+	sort.Slice(catalog.Events, func(i, j int) bool {
+		if catalog.Events[i].Type != catalog.Events[j].Type {
+			return catalog.Events[i].Type < catalog.Events[j].Type
+		}
+		return catalog.Events[i].GoType < catalog.Events[j].GoType
+	})
+
+	return catalog
+}
+
+// AsyncAPI returns an AsyncAPI document with the giving title describing the channels events
+// of the Catalog are published to, by the NATS or SQS publishers, under a namespace. Whole
+// commits are published to the namespace itself, while single events published through a
+// cqrskit.PerEventPublisher with cqrskit.EventNamespace are published to the namespace with
+// their type name appended.
+func (c Catalog) AsyncAPI(title string) map[string]interface{} {
+	namespace := map[string]interface{}{
+		"namespace": map[string]interface{}{
+			"description": "namespace events are published to, e.g users.events",
+			"schema":      Schema{"type": "string"},
+		},
+	}
+
+	var payloads []interface{}
+	channels := map[string]interface{}{}
+	messages := map[string]interface{}{}
+	schemas := map[string]interface{}{}
+
+	for _, event := range c.Events {
+		if _, ok := messages[event.Type]; ok {
+			continue
+		}
+
+		ref := Schema{"$ref": "#/components/schemas/" + event.Type}
+		payloads = append(payloads, ref)
+
+		schema := Schema{}
+		for key, value := range event.Schema {
+			if key != "$schema" {
+				schema[key] = value
+			}
+		}
+		schemas[event.Type] = schema
+
+		messages[event.Type] = map[string]interface{}{
+			"name":        event.Type,
+			"title":       event.Type,
+			"contentType": "application/json",
+			"payload":     eventMessageSchema(event.Type, ref),
+		}
+
+		channels["{namespace}."+event.Type] = map[string]interface{}{
+			"description": "Single " + event.Type + " events, published with cqrskit.PerEventPublisher.",
+			"parameters":  namespace,
+			"subscribe": map[string]interface{}{
+				"message": Schema{"$ref": "#/components/messages/" + event.Type},
+			},
+		}
+	}
+
+	data := Schema{}
+	if len(payloads) != 0 {
+		data = Schema{"oneOf": payloads}
+	}
+
+	messages["EventCommit"] = map[string]interface{}{
+		"name":        "EventCommit",
+		"title":       "EventCommit",
+		"contentType": "application/json",
+		"payload":     eventCommitSchema(data),
+	}
+
+	channels["{namespace}"] = map[string]interface{}{
+		"description": "Whole commits of events.",
+		"parameters":  namespace,
+		"subscribe": map[string]interface{}{
+			"message": Schema{"$ref": "#/components/messages/EventCommit"},
+		},
+	}
+
+	return map[string]interface{}{
+		"asyncapi": "2.6.0",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": "1.0.0",
+		},
+		"servers": map[string]interface{}{
+			"nats": map[string]interface{}{
+				"url":      "{host}",
+				"protocol": "nats",
+				"variables": map[string]interface{}{
+					"host": map[string]interface{}{"default": "localhost:4222"},
+				},
+			},
+			"sqs": map[string]interface{}{
+				"url":      "{queue}",
+				"protocol": "sqs",
+				"variables": map[string]interface{}{
+					"queue": map[string]interface{}{"description": "url of the SQS queue"},
+				},
+			},
+		},
+		"channels": channels,
+		"components": map[string]interface{}{
+			"messages": messages,
+			"schemas":  schemas,
+		},
+	}
+}
+
+// WriteJSON writes the indented JSON of the value into the giving file.
+func WriteJSON(file string, value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(content, '\n'), 0644)
+}
+
+//*******************************************************************************
+// JSON Schema
+//*******************************************************************************
+
+// eventSchema returns the JSON Schema of a cqrskit.Event holding the giving data.
+func eventSchema(eventType string, data Schema) Schema {
+	kind := Schema{"type": "string"}
+	if eventType != "" {
+		kind["const"] = eventType
+	}
+
+	return Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id":     Schema{"type": "string"},
+			"type":   kind,
+			"meta":   Schema{},
+			"data":   data,
+			"header": Schema{"type": "object"},
+		},
+		"required": []string{"type", "data"},
+	}
+}
+
+// eventMessageSchema returns the JSON Schema of a cqrskit.EventMessage holding an event of
+// the giving type and data.
+func eventMessageSchema(eventType string, data Schema) Schema {
+	return Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"commit_id":    Schema{"type": "string"},
+			"instance_id":  Schema{"type": "string"},
+			"aggregate_id": Schema{"type": "string"},
+			"version":      Schema{"type": "integer"},
+			"index":        Schema{"type": "integer"},
+			"total":        Schema{"type": "integer"},
+			"event":        eventSchema(eventType, data),
+		},
+		"required": []string{"commit_id", "aggregate_id", "version", "event"},
+	}
+}
+
+// eventCommitSchema returns the JSON Schema of a cqrskit.EventCommit with events holding the
+// giving data.
+func eventCommitSchema(data Schema) Schema {
+	return Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"commit_id":    Schema{"type": "string"},
+			"instance_id":  Schema{"type": "string"},
+			"aggregate_id": Schema{"type": "string"},
+			"version":      Schema{"type": "integer"},
+			"command":      Schema{"type": "string"},
+			"created":      Schema{"type": "string", "format": "date-time"},
+			"events":       Schema{"type": "array", "items": eventSchema("", data)},
+			"header":       Schema{"type": "object"},
+		},
+		"required": []string{"commit_id", "aggregate_id", "version", "events"},
+	}
+}
+
+// structSchema returns the JSON Schema of an object with the fields of a struct, as encoded
+// by encoding/json. Structs named within fields are resolved from the giving package, where
+// seen holds the names of the structs being resolved to avoid recursion.
+func structSchema(fields *goast.FieldList, pkg ast.Package, seen map[string]bool) Schema {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	if fields != nil {
+		for _, field := range fields.List {
+			var tag reflect.StructTag
+			if field.Tag != nil {
+				tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+			}
+
+			name, options := tag.Get("json"), ""
+			if index := strings.Index(name, ","); index != -1 {
+				name, options = name[:index], name[index:]
+			}
+
+			if name == "-" && options == "" {
+				continue
+			}
+
+			// embedded structs have their fields promoted into the object.
+			if len(field.Names) == 0 && name == "" {
+				embedded := typeSchema(field.Type, pkg, seen)
+				if props, ok := embedded["properties"].(map[string]interface{}); ok {
+					for key, value := range props {
+						properties[key] = value
+					}
+					if req, ok := embedded["required"].([]string); ok {
+						required = append(required, req...)
+					}
+				}
+				continue
+			}
+
+			names := field.Names
+			if len(names) == 0 {
+				embedded := typeName(field.Type)
+				names = []*goast.Ident{goast.NewIdent(embedded[strings.LastIndex(embedded, ".")+1:])}
+			}
+
+			for _, ident := range names {
+				if !ident.IsExported() {
+					continue
+				}
+
+				key := name
+				if key == "" {
+					key = ident.Name
+				}
+
+				properties[key] = typeSchema(field.Type, pkg, seen)
+				if !strings.Contains(options, ",omitempty") {
+					required = append(required, key)
+				}
+			}
+		}
+	}
+
+	sort.Strings(required)
+
+	schema := Schema{
+		"type":       "object",
+		"properties": properties,
+	}
+
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// typeSchema returns the JSON Schema of the type of a field.
+func typeSchema(expr goast.Expr, pkg ast.Package, seen map[string]bool) Schema {
+	switch t := expr.(type) {
+	case *goast.Ident:
+		switch t.Name {
+		case "string":
+			return Schema{"type": "string"}
+		case "bool":
+			return Schema{"type": "boolean"}
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune", "uintptr":
+			return Schema{"type": "integer"}
+		case "float32", "float64":
+			return Schema{"type": "number"}
+		}
+
+		if seen[t.Name] {
+			return Schema{}
+		}
+
+		if str, ok := pkg.StructFor(t.Name); ok && str.Struct != nil {
+			nested := map[string]bool{t.Name: true}
+			for name := range seen {
+				nested[name] = true
+			}
+			return structSchema(str.Struct.Fields, pkg, nested)
+		}
+
+		return Schema{}
+	case *goast.StarExpr:
+		return typeSchema(t.X, pkg, seen)
+	case *goast.ArrayType:
+		if ident, ok := t.Elt.(*goast.Ident); ok && ident.Name == "byte" && t.Len == nil {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": typeSchema(t.Elt, pkg, seen)}
+	case *goast.MapType:
+		return Schema{"type": "object", "additionalProperties": typeSchema(t.Value, pkg, seen)}
+	case *goast.StructType:
+		return structSchema(t.Fields, pkg, seen)
+	case *goast.SelectorExpr:
+		switch typeName(t) {
+		case "time.Time":
+			return Schema{"type": "string", "format": "date-time"}
+		case "time.Duration":
+			return Schema{"type": "integer"}
+		}
+	}
+
+	return Schema{}
+}
+
+// typeName returns the name of a named type, with it's package if qualified.
+func typeName(expr goast.Expr) string {
+	switch t := expr.(type) {
+	case *goast.Ident:
+		return t.Name
+	case *goast.StarExpr:
+		return typeName(t.X)
+	case *goast.SelectorExpr:
+		return typeName(t.X) + "." + t.Sel.Name
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+package cqrsgen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influx6/gobuild/srcpath"
+	"github.com/influx6/moz/ast"
+)
+
+// diffContext sets the total unchanged lines shown around changes of a Drift.
+const diffContext = 3
+
+// Drift embodies a generated file whose content differs from the file existing on disk.
+type Drift struct {
+	File    string
+	Missing bool
+	Diff    string
+}
+
+// Check runs the generators of the registry over the giving packages in memory, comparing
+// each generated file with the one existing in the toDir directory. It writes nothing and
+// returns a Drift for every file which is missing or out of date.
+func Check(toDir string, registry *ast.AnnotationRegistry, pkgs ...ast.Package) ([]Drift, error) {
+	if !filepath.IsAbs(toDir) {
+		return nil, fmt.Errorf("destination path %q must be absolute", toDir)
+	}
+
+	toSrcPath, err := srcpath.RelativeToSrc(toDir)
+	if err != nil {
+		return nil, fmt.Errorf("destination path is not within current GOPATH: %+q", err.Error())
+	}
+
+	var drifts []Drift
+
+	// Files shared by generated structs, such as those of events, are only checked once.
+	checked := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, declr := range pkg.Packages {
+			directives, err := registry.ParseDeclr(pkg, declr, toSrcPath)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, directive := range directives {
+				if directive.Writer == nil || directive.FileName == "" {
+					continue
+				}
+
+				var generated bytes.Buffer
+				if _, err := directive.Writer.WriteTo(&generated); err != nil {
+					return nil, err
+				}
+
+				name := filepath.Join(directive.Dir, directive.FileName)
+				if checked[name] {
+					continue
+				}
+				checked[name] = true
+
+				file := filepath.Join(toDir, name)
+				existing, err := ioutil.ReadFile(file)
+				if err != nil && !os.IsNotExist(err) {
+					return nil, err
+				}
+
+				if err == nil && bytes.Equal(existing, generated.Bytes()) {
+					continue
+				}
+
+				drifts = append(drifts, Drift{
+					File:    file,
+					Missing: err != nil,
+					Diff:    Diff(name, name+" (generated)", existing, generated.Bytes()),
+				})
+			}
+		}
+	}
+
+	return drifts, nil
+}
+
+//*******************************************************************************
+// Diff
+//*******************************************************************************
+
+// diffLine is a line of a diff, with it's kind being ' ', '-' or '+', and the
+// index of the lines of both sides it appears before.
+type diffLine struct {
+	kind byte
+	text string
+	a, b int
+}
+
+// Diff returns a unified diff of the lines of a and b, using the giving names as
+// their labels. An empty string is returned if both are equal.
+func Diff(aName string, bName string, a []byte, b []byte) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var out bytes.Buffer
+	for start := 0; start < len(lines); start++ {
+		if lines[start].kind == ' ' {
+			continue
+		}
+
+		// extend the hunk over all changes closer than twice the context.
+		end := start
+		for next := start + 1; next < len(lines) && next <= end+2*diffContext; next++ {
+			if lines[next].kind != ' ' {
+				end = next
+			}
+		}
+
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+
+		to := end + diffContext + 1
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+		}
+
+		writeHunk(&out, lines[from:to])
+		start = to - 1
+	}
+
+	return out.String()
+}
+
+// writeHunk writes the header and lines of a hunk of a unified diff.
+func writeHunk(out *bytes.Buffer, hunk []diffLine) {
+	var aCount, bCount int
+	for _, line := range hunk {
+		if line.kind != '+' {
+			aCount++
+		}
+		if line.kind != '-' {
+			bCount++
+		}
+	}
+
+	aStart, bStart := hunk[0].a+1, hunk[0].b+1
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, line := range hunk {
+		out.WriteByte(line.kind)
+		out.WriteString(line.text)
+		out.WriteByte('\n')
+	}
+}
+
+// diffLines returns the lines of a and b marked as kept, removed or added, using the
+// longest common subsequence of both.
+func diffLines(a []string, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	var i, j int
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{kind: ' ', text: a[i], a: i, b: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{kind: '-', text: a[i], a: i, b: j})
+			i++
+		default:
+			lines = append(lines, diffLine{kind: '+', text: b[j], a: i, b: j})
+			j++
+		}
+	}
+
+	return lines
+}
+
+// splitLines returns the lines of content, without a trailing empty line.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
@@ -2,7 +2,6 @@ package cqrsgen
 
 import (
 	"fmt"
-	"log"
 	"strings"
 	"text/template"
 
@@ -14,109 +13,103 @@ import (
 )
 
 type MethodEventPair struct {
+	Name         string
+	TypeName     string
+	Argument     ast.ArgType
+	Method       ast.FuncDeclaration
+	Def          ast.FunctionDefinition
+	Event        ast.StructDeclaration
+	EventPackage ast.Package
+}
+
+// CommandPair embodies a Execute{{Command}} method of an aggregate with the type of
+// the command it receives.
+type CommandPair struct {
 	Name     string
 	TypeName string
 	Argument ast.ArgType
 	Method   ast.FuncDeclaration
 	Def      ast.FunctionDefinition
-	Event    ast.StructDeclaration
 }
 
-// ESCQRSGen generates scaffolding code for
+// Default is the Generator used by ESCQRSGen, collecting the diagnostics and catalog of all
+// structs it generates. Set it's Strict field to have ESCQRSGen fail for structs with warnings.
+var Default = &Generator{}
+
+// ESCQRSGen generates scaffolding code for structs annotated with @escqrs using the Default
+// Generator, where skipped methods are reported through it's Diagnostics. See Generator.Generate.
 func ESCQRSGen(toPackage string, an ast.AnnotationDeclaration, str ast.StructDeclaration, declr ast.PackageDeclaration, pkg ast.Package) ([]gen.WriteDirective, error) {
+	return Default.Generate(toPackage, an, str, declr, pkg)
+}
+
+// Generate generates scaffolding code for structs annotated with @escqrs, from their Handle{{Event}}
+// and Execute{{Command}} methods. Methods which can not be used are skipped and reported as warning
+// diagnostics, while methods annotated with @escqrs-method-skip (or @escqrs-skip) are reported as
+// info diagnostics. In Strict mode, no code is generated for a struct with warnings, and a
+// StrictError is returned instead.
+func (g *Generator) Generate(toPackage string, an ast.AnnotationDeclaration, str ast.StructDeclaration, declr ast.PackageDeclaration, pkg ast.Package) ([]gen.WriteDirective, error) {
 	structName := str.Object.Name.Name
 	structNameLower := strings.ToLower(structName)
 
+	identity, err := identityFor(an, structName)
+	if err != nil {
+		return nil, err
+	}
+
 	//packageName := fmt.Sprintf("%scqrs", structNameLower)
 
 	var methodImports []gen.ImportItemDeclr
 
 	var pairs []MethodEventPair
+	var events []gen.WriteDirective
+	var commands []CommandPair
+	var warnings []Diagnostic
 	methods, _ := declr.MethodFor(str.Object.Name.Name)
 	for _, method := range methods {
-		if !strings.HasPrefix(method.FuncName, "Handle") {
+		if !strings.HasPrefix(method.FuncName, "Execute") && !strings.HasPrefix(method.FuncName, "Handle") {
 			continue
 		}
 
-		if method.HasAnnotation("@escqrs-skip") {
-			log.Printf("Skipping: Function definition for %q in %q\n", method.FuncName, str.Name)
+		if hasSkipAnnotation(method) {
+			g.report(Info, str, method, "skipped, annotated with %s", SkipAnnotation)
 			continue
 		}
 
-		def, err := ast.GetFunctionDefinitionFromDeclaration(method, &declr)
-		if err != nil {
-			log.Printf("Skipping: Unable to get function definition for %q in %q\n", method.FuncName, str.Name)
-			continue
-		}
+		if strings.HasPrefix(method.FuncName, "Execute") {
+			command, imports, diagnostic, ok := commandPair(method, str, declr)
+			if !ok {
+				warnings = append(warnings, g.report(Warning, str, method, "%s", diagnostic))
+				continue
+			}
 
-		if def.TotalArgs() == 0 {
-			log.Printf("Skipping: Unable to use function %q with no arguments in %q as event handler\n", method.FuncName, str.Name)
+			commands = append(commands, command)
+			methodImports = append(methodImports, imports...)
 			continue
 		}
 
-		if def.TotalArgs() != 1 {
-			log.Printf("Skipping: Unable to use function %q with more than 1 arguments in %q as event handler\n", method.FuncName, str.Name)
+		pair, imports, diagnostic, ok := handlerPair(method, declr, pkg, "Handle", "event")
+		if !ok {
+			warnings = append(warnings, g.report(Warning, str, method, "%s", diagnostic))
 			continue
 		}
 
-		// Get the argument detail for the argument.
-		eventArg := def.Args[0]
-
-		if eventArg.StructObject == nil {
-			log.Printf("Skipping: Unable to use function %q with non-struct argument %q in %q\n", method.FuncName, eventArg.ExType, str.Name)
-		}
-
-		var typeName string
-
-		// Get name of Event from Handle{{Event}} method
-		eventStructName := strings.TrimPrefix(method.FuncName, "Handle")
-
-		var ok bool
-		var eventStruct ast.StructDeclaration
-		if eventArg.Package != declr.Package {
-			pkg, ok = declr.ImportedPackageFor(eventArg.Package)
-			if !ok {
-				log.Printf("Skipping: Unable to find package for function %q with argument  %q in %q\n", method.FuncName, eventStructName, str.Name)
-				continue
-			}
-
-			methodImports = append(methodImports, gen.Import(pkg.Path, eventArg.Package))
-
-			structName := strings.TrimPrefix(strings.TrimPrefix(eventArg.ExType, eventArg.Package), ".")
-			eventStruct, ok = pkg.StructFor(structName)
-			if !ok {
-				log.Printf("Skipping: Unable to find struct %q in package %q for function %q in %q\n", structName, pkg.Path, method.FuncName, str.Name)
-				continue
-			}
-
-			typeName = eventArg.ExType
-		} else {
-			// Search for event struct declared in package.
-			eventStruct, ok = pkg.StructFor(eventStructName)
-			if !ok {
-				log.Printf("Skipping: Unable to find %q struct definition for method %+q on %q\n", eventStructName, method.FuncName, str.Name)
-				continue
-			}
-
-			typeName = eventStruct.Name
-		}
+		pairs = append(pairs, pair)
+		events = append(events, eventDocument(declr, pair, imports))
+		methodImports = append(methodImports, imports...)
+	}
 
-		if !def.HasReturnType("error") {
-			log.Printf("Skipping: Method %q must return an error after receiving %q in %q\n", method.FuncName, eventStructName, str.Name)
-			continue
+	if g.Lock != nil {
+		if message, ok := g.Lock.claim(structName, identity); !ok {
+			warnings = append(warnings, g.report(Warning, str, ast.FuncDeclaration{}, "%s", message))
 		}
+	}
 
-		pairs = append(pairs, MethodEventPair{
-			Def:      def,
-			Method:   method,
-			Argument: eventArg,
-			TypeName: typeName,
-			Event:    eventStruct,
-			Name:     eventStructName,
-		})
+	if g.Strict && len(warnings) != 0 {
+		return nil, StrictError{Struct: str.Name, Warnings: warnings}
 	}
 
-	methodImports = append(methodImports, gen.Import("context", ""))
+	g.record("aggregate", str, pkg, identity.ID, pairs)
+
 	methodImports = append(methodImports, gen.Import("github.com/gokit/cqrskit", ""))
 
 	readWriteRepo := gen.Package(
@@ -131,15 +124,21 @@ func ESCQRSGen(toPackage string, an ast.AnnotationDeclaration, str ast.StructDec
 					template.FuncMap{},
 				),
 				struct {
-					Str   ast.StructDeclaration
-					Pkg   ast.PackageDeclaration
-					An    ast.AnnotationDeclaration
-					Pairs []MethodEventPair
+					Str      ast.StructDeclaration
+					Pkg      ast.PackageDeclaration
+					An       ast.AnnotationDeclaration
+					Identity Identity
+					Explicit bool
+					Pairs    []MethodEventPair
+					Commands []CommandPair
 				}{
-					An:    an,
-					Str:   str,
-					Pkg:   declr,
-					Pairs: pairs,
+					An:       an,
+					Str:      str,
+					Pkg:      declr,
+					Identity: identity,
+					Explicit: identity.explicit,
+					Pairs:    pairs,
+					Commands: commands,
 				},
 			),
 		),
@@ -152,5 +151,218 @@ func ESCQRSGen(toPackage string, an ast.AnnotationDeclaration, str ast.StructDec
 		},
 	}
 
+	documents = append(documents, events...)
+
+	// The typed repository needs the instance id of an aggregate to save it, so it is
+	// only generated for structs with an InstanceID field.
+	fields, _ := str.Fields()
+	if !hasField(fields, "InstanceID", "string") {
+		g.report(Info, str, ast.FuncDeclaration{}, "repository skipped, struct has no InstanceID string field")
+		return documents, nil
+	}
+
+	repository := gen.Package(
+		gen.Name(declr.Package),
+		gen.Imports(
+			gen.Import("context", ""),
+			gen.Import("github.com/gokit/cqrskit", ""),
+		),
+		gen.Block(
+			gen.SourceTextWith(
+				"cqrskit:repository",
+				string(static.MustReadFile("repository.tml", true)),
+				gen.ToTemplateFuncs(
+					ast.ASTTemplatFuncs,
+					template.FuncMap{},
+				),
+				struct {
+					Str       ast.StructDeclaration
+					Pkg       ast.PackageDeclaration
+					Versioned bool
+				}{
+					Str:       str,
+					Pkg:       declr,
+					Versioned: hasField(fields, "Version", "int"),
+				},
+			),
+		),
+	)
+
+	documents = append(documents, gen.WriteDirective{
+		FileName: fmt.Sprintf("%s.repository.go", structNameLower),
+		Writer:   fmtwriter.New(repository, true, true),
+	})
+
 	return documents, nil
 }
+
+// eventDocument returns the WriteDirective of the file declaring the type name and constructor
+// of the event of the pair. The file is named after the event, so aggregates of a package
+// applying the same event generate the same file, declaring both only once.
+func eventDocument(declr ast.PackageDeclaration, pair MethodEventPair, imports []gen.ImportItemDeclr) gen.WriteDirective {
+	event := gen.Package(
+		gen.Name(declr.Package),
+		gen.Imports(append(imports, gen.Import("github.com/gokit/cqrskit", ""))...),
+		gen.Block(
+			gen.SourceTextWith(
+				"cqrskit:event",
+				string(static.MustReadFile("event.tml", true)),
+				gen.ToTemplateFuncs(
+					ast.ASTTemplatFuncs,
+					template.FuncMap{},
+				),
+				struct {
+					Pair MethodEventPair
+				}{
+					Pair: pair,
+				},
+			),
+		),
+	)
+
+	return gen.WriteDirective{
+		FileName: fmt.Sprintf("%s.event.go", strings.ToLower(pair.Name)),
+		Writer:   fmtwriter.New(event, true, true),
+	}
+}
+
+// hasField returns true/false if the fields have a field of the giving name and type.
+func hasField(fields []ast.FieldDeclaration, name string, typeName string) bool {
+	for _, field := range fields {
+		if field.FieldName == name && field.FieldTypeName == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerPair returns the MethodEventPair of a {{prefix}}{{Type}} method, which must receive a single
+// struct of the giving kind (e.g event), followed by arguments of the extra types, and return an
+// error, else the reason it can not be used is returned.
+func handlerPair(method ast.FuncDeclaration, declr ast.PackageDeclaration, pkg ast.Package, prefix string, kind string, extra ...string) (MethodEventPair, []gen.ImportItemDeclr, string, bool) {
+	var pair MethodEventPair
+
+	def, err := ast.GetFunctionDefinitionFromDeclaration(method, &declr)
+	if err != nil {
+		return pair, nil, fmt.Sprintf("unable to get function definition: %s", err), false
+	}
+
+	if def.TotalArgs() == 0 {
+		return pair, nil, fmt.Sprintf("%s handler receives no %s", kind, kind), false
+	}
+
+	if def.TotalArgs() != len(extra)+1 {
+		if len(extra) == 0 {
+			return pair, nil, fmt.Sprintf("%s handler receives more than 1 argument", kind), false
+		}
+		return pair, nil, fmt.Sprintf("%s handler must receive a %s and %s", kind, kind, strings.Join(extra, ", ")), false
+	}
+
+	for index, exType := range extra {
+		if def.Args[index+1].ExType != exType {
+			return pair, nil, fmt.Sprintf("%s handler must receive %s as argument %d", kind, exType, index+2), false
+		}
+	}
+
+	// Get the argument detail for the argument.
+	eventArg := def.Args[0]
+
+	// structs declared in other files of the package are not resolved by the argument.
+	if eventArg.StructObject == nil {
+		if _, ok := pkg.StructFor(eventArg.Type); !ok || eventArg.Package != declr.Package {
+			return pair, nil, fmt.Sprintf("%s handler receives non-struct argument %q", kind, eventArg.ExType), false
+		}
+	}
+
+	var typeName string
+	var imports []gen.ImportItemDeclr
+
+	// Get name of Event from {{prefix}}{{Event}} method
+	eventStructName := strings.TrimPrefix(method.FuncName, prefix)
+
+	var ok bool
+	var eventPackage ast.Package
+	var eventStruct ast.StructDeclaration
+	if eventArg.Package != declr.Package {
+		eventPkg, ok := declr.ImportedPackageFor(eventArg.Package)
+		if !ok {
+			return pair, nil, fmt.Sprintf("unable to find package %q of %s %q", eventArg.Package, kind, eventArg.ExType), false
+		}
+
+		structName := strings.TrimPrefix(strings.TrimPrefix(eventArg.ExType, eventArg.Package), ".")
+		eventStruct, ok = eventPkg.StructFor(structName)
+		if !ok {
+			return pair, nil, fmt.Sprintf("unable to find struct %q in package %q", structName, eventPkg.Path), false
+		}
+
+		imports = append(imports, gen.Import(eventPkg.Path, eventArg.Package))
+
+		eventPackage = eventPkg
+
+		typeName = eventArg.ExType
+	} else {
+		// Search for event struct declared in package.
+		eventStruct, ok = pkg.StructFor(eventStructName)
+		if !ok {
+			return pair, nil, fmt.Sprintf("unable to find struct %q of %s", eventStructName, kind), false
+		}
+
+		typeName = eventStruct.Name
+		eventPackage = pkg
+	}
+
+	if !def.HasReturnType("error") {
+		return pair, nil, fmt.Sprintf("%s handler must return an error", kind), false
+	}
+
+	pair.Def = def
+	pair.Method = method
+	pair.Argument = eventArg
+	pair.TypeName = typeName
+	pair.Event = eventStruct
+	pair.EventPackage = eventPackage
+	pair.Name = eventStructName
+	return pair, imports, "", true
+}
+
+// commandPair returns the CommandPair of a Execute{{Command}} method, which must receive a single
+// command and return a slice of cqrskit.Event and an error, else the reason it can not be used
+// is returned.
+func commandPair(method ast.FuncDeclaration, str ast.StructDeclaration, declr ast.PackageDeclaration) (CommandPair, []gen.ImportItemDeclr, string, bool) {
+	var command CommandPair
+
+	def, err := ast.GetFunctionDefinitionFromDeclaration(method, &declr)
+	if err != nil {
+		return command, nil, fmt.Sprintf("unable to get function definition: %s", err), false
+	}
+
+	if def.TotalArgs() != 1 {
+		return command, nil, "command handler must receive exactly 1 argument", false
+	}
+
+	if def.TotalReturns() != 2 || def.Returns[0].ExType != "[]cqrskit.Event" || def.Returns[1].Type != "error" {
+		return command, nil, "command handler must return ([]cqrskit.Event, error)", false
+	}
+
+	cmdArg := def.Args[0]
+
+	var imports []gen.ImportItemDeclr
+
+	typeName := cmdArg.Type
+	if cmdArg.Package != "" && cmdArg.Package != declr.Package {
+		pkg, ok := declr.ImportedPackageFor(cmdArg.Package)
+		if !ok {
+			return command, nil, fmt.Sprintf("unable to find package %q of command %q", cmdArg.Package, cmdArg.ExType), false
+		}
+
+		imports = append(imports, gen.Import(pkg.Path, cmdArg.Package))
+		typeName = cmdArg.ExType
+	}
+
+	command.Def = def
+	command.Method = method
+	command.Argument = cmdArg
+	command.TypeName = typeName
+	command.Name = strings.TrimPrefix(method.FuncName, "Execute")
+	return command, imports, "", true
+}
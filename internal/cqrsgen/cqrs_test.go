@@ -0,0 +1,519 @@
+package cqrsgen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/tests"
+	"github.com/influx6/moz/ast"
+
+	"github.com/gokit/cqrskit/internal/cqrsgen"
+)
+
+var update = flag.Bool("update", false, "update golden files of testdata with generated output")
+
+func TestGenerateUsers(t *testing.T) {
+	generator := &cqrsgen.Generator{Strict: true}
+	files, err := generate(filepath.Join("testdata", "users"), generator)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully generated users fixture in strict mode")
+	}
+	tests.Passed("Should have successfully generated users fixture in strict mode")
+
+	if len(files) != 4 {
+		tests.Info("Files: %d", len(files))
+		tests.Failed("Should have generated appliers, repository and events of User")
+	}
+	tests.Passed("Should have generated appliers, repository and events of User")
+
+	for name, content := range files {
+		golden(t, filepath.Join("testdata", "users", name+".golden"), content)
+	}
+
+	golden(t, filepath.Join("testdata", "users", "diagnostics.golden"), diagnostics(generator))
+}
+
+func TestGenerateSharedEvents(t *testing.T) {
+	dir := filepath.Join("testdata", "shared")
+
+	files, err := generate(dir, &cqrsgen.Generator{Strict: true})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully generated shared fixture in strict mode")
+	}
+	tests.Passed("Should have successfully generated shared fixture in strict mode")
+
+	for name, content := range files {
+		golden(t, filepath.Join(dir, name+".golden"), content)
+	}
+
+	fixture, err := ioutil.ReadFile(filepath.Join(dir, "shared.go"))
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read shared fixture")
+	}
+	files["shared.go"] = fixture
+
+	// Aggregates applying the same event must not declare it's type name or constructor
+	// twice within their package.
+	declared := map[string]string{}
+	fset := token.NewFileSet()
+	for name, content := range files {
+		file, err := parser.ParseFile(fset, name, content, 0)
+		if err != nil {
+			tests.FailedWithError(err, "Should have successfully parsed %q", name)
+		}
+
+		for _, decl := range file.Decls {
+			for _, ident := range declaredNames(decl) {
+				if other, ok := declared[ident]; ok {
+					tests.Info("Declared by %q and %q", other, name)
+					tests.Failed("Should have declared %q only once", ident)
+				}
+				declared[ident] = name
+			}
+		}
+	}
+	tests.Passed("Should have declared events shared by aggregates only once")
+}
+
+// declaredNames returns the package-level names declared by decl, leaving out methods.
+func declaredNames(decl goast.Decl) []string {
+	var names []string
+	switch declr := decl.(type) {
+	case *goast.FuncDecl:
+		if declr.Recv == nil && declr.Name.Name != "init" {
+			names = append(names, declr.Name.Name)
+		}
+	case *goast.GenDecl:
+		for _, spec := range declr.Specs {
+			switch spec := spec.(type) {
+			case *goast.ValueSpec:
+				for _, name := range spec.Names {
+					names = append(names, name.Name)
+				}
+			case *goast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	generator := &cqrsgen.Generator{}
+	files, err := generate(filepath.Join("testdata", "invalid"), generator)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully generated invalid fixture")
+	}
+	tests.Passed("Should have successfully generated invalid fixture")
+
+	if _, ok := files["account.repository.go"]; ok {
+		tests.Failed("Should have skipped repository of Account without InstanceID field")
+	}
+	tests.Passed("Should have skipped repository of Account without InstanceID field")
+
+	for name, content := range files {
+		golden(t, filepath.Join("testdata", "invalid", name+".golden"), content)
+	}
+
+	golden(t, filepath.Join("testdata", "invalid", "diagnostics.golden"), diagnostics(generator))
+}
+
+func TestGenerateInvalidStrict(t *testing.T) {
+	generator := &cqrsgen.Generator{Strict: true}
+	_, err := generate(filepath.Join("testdata", "invalid"), generator)
+
+	strictErr, ok := err.(cqrsgen.StrictError)
+	if !ok {
+		tests.FailedWithError(err, "Should have failed generation of invalid fixture in strict mode")
+	}
+	tests.Passed("Should have failed generation of invalid fixture in strict mode")
+
+	if strictErr.Struct != "Account" || len(strictErr.Warnings) != 6 {
+		tests.Info("Struct: %q, Warnings: %d", strictErr.Struct, len(strictErr.Warnings))
+		tests.Failed("Should have reported all skipped methods of Account")
+	}
+	tests.Passed("Should have reported all skipped methods of Account")
+}
+
+func TestESCQRSGenDiagnostics(t *testing.T) {
+	previous := cqrsgen.Default
+	defer func() { cqrsgen.Default = previous }()
+
+	cqrsgen.Default = &cqrsgen.Generator{Strict: true}
+
+	dir, err := filepath.Abs(filepath.Join("testdata", "invalid"))
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully resolved invalid fixture")
+	}
+
+	logs := metrics.New()
+
+	registry := ast.NewAnnotationRegistryWith(logs)
+	registry.Register("@escqrs", cqrsgen.ESCQRSGen)
+
+	pkgs, err := ast.ParseAnnotations(logs, dir)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully parsed invalid fixture")
+	}
+
+	for _, pkg := range pkgs {
+		for _, declr := range pkg.Packages {
+			_, err = registry.ParseDeclr(pkg, declr, dir)
+		}
+	}
+
+	if _, ok := err.(cqrsgen.StrictError); !ok {
+		tests.FailedWithError(err, "Should have failed generation of invalid fixture with strict Default generator")
+	}
+	tests.Passed("Should have failed generation of invalid fixture with strict Default generator")
+
+	if len(cqrsgen.Default.Diagnostics()) == 0 {
+		tests.Failed("Should have reported skipped methods through Default generator")
+	}
+	tests.Passed("Should have reported skipped methods through Default generator")
+}
+
+// generate runs a cqrsgen.Generator over the fixture package at dir, returning the content
+// of each generated file by it's name.
+func generate(dir string, generator *cqrsgen.Generator) (map[string][]byte, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := metrics.New()
+
+	registry := ast.NewAnnotationRegistryWith(logs)
+	registry.Register("@escqrs", generator.Generate)
+	registry.Register(cqrsgen.ProjectionAnnotation, generator.GenerateProjection)
+	registry.Register(cqrsgen.SagaAnnotation, generator.GenerateSaga)
+
+	pkgs, err := ast.ParseAnnotations(logs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, pkg := range pkgs {
+		for _, declr := range pkg.Packages {
+			directives, err := registry.ParseDeclr(pkg, declr, dir)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, directive := range directives {
+				var content bytes.Buffer
+				if _, err := directive.Writer.WriteTo(&content); err != nil {
+					return nil, err
+				}
+				files[directive.FileName] = content.Bytes()
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// diagnostics returns the diagnostics reported by the generator, one per line, sorted and
+// with file paths relative to their package.
+func diagnostics(generator *cqrsgen.Generator) []byte {
+	var lines []string
+	for _, diagnostic := range generator.Diagnostics() {
+		if diagnostic.File != "" {
+			diagnostic.File = filepath.Base(diagnostic.File)
+		}
+		lines = append(lines, diagnostic.String())
+	}
+
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// golden compares content with the golden file at path, rewriting it instead when the
+// -update flag is set.
+func golden(t *testing.T, path string, content []byte) {
+	if *update {
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			tests.FailedWithError(err, "Should have successfully updated golden file %q", path)
+		}
+		tests.Passed("Should have successfully updated golden file %q", path)
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read golden file %q", path)
+	}
+
+	if !bytes.Equal(expected, content) {
+		tests.Info("Expected:\n%s", expected)
+		tests.Info("Received:\n%s", content)
+		tests.Failed("Should have generated content matching golden file %q", path)
+	}
+	tests.Passed("Should have generated content matching golden file %q", path)
+}
+
+func TestGenerateExamples(t *testing.T) {
+	dir := filepath.Join("..", "..", "examples", "users")
+	files, err := generate(dir, &cqrsgen.Generator{})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully generated users example")
+	}
+	tests.Passed("Should have successfully generated users example")
+
+	for name, content := range files {
+		golden(t, filepath.Join(dir, name), content)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir, err := filepath.Abs(filepath.Join("..", "..", "examples", "users"))
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully resolved users example")
+	}
+
+	logs := metrics.New()
+
+	registry := ast.NewAnnotationRegistryWith(logs)
+	registry.Register("@escqrs", cqrsgen.ESCQRSGen)
+
+	pkgs, err := ast.ParseAnnotations(logs, dir)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully parsed users example")
+	}
+
+	drifts, err := cqrsgen.Check(dir, registry, pkgs...)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully checked users example")
+	}
+	tests.Passed("Should have successfully checked users example")
+
+	if len(drifts) != 0 {
+		tests.Info("Drift: %s", drifts[0].Diff)
+		tests.Failed("Should have found generated files of users example up to date")
+	}
+	tests.Passed("Should have found generated files of users example up to date")
+}
+
+func TestDiff(t *testing.T) {
+	if diff := cqrsgen.Diff("a", "b", []byte("one\ntwo\n"), []byte("one\ntwo\n")); diff != "" {
+		tests.Info("Diff: %s", diff)
+		tests.Failed("Should have found no difference between equal content")
+	}
+	tests.Passed("Should have found no difference between equal content")
+
+	old := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+	updated := []byte("1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n")
+
+	expected := "--- a\n+++ b\n@@ -3,7 +3,7 @@\n 3\n 4\n 5\n-6\n+six\n 7\n 8\n 9\n"
+	if diff := cqrsgen.Diff("a", "b", old, updated); diff != expected {
+		tests.Info("Diff:\n%s", diff)
+		tests.Failed("Should have produced unified diff of changed line")
+	}
+	tests.Passed("Should have produced unified diff of changed line")
+
+	expected = "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+1\n+2\n"
+	if diff := cqrsgen.Diff("a", "b", nil, []byte("1\n2\n")); diff != expected {
+		tests.Info("Diff:\n%s", diff)
+		tests.Failed("Should have produced unified diff of missing file")
+	}
+	tests.Passed("Should have produced unified diff of missing file")
+}
+
+func TestGenerateIdentity(t *testing.T) {
+	dir := filepath.Join("testdata", "identity")
+
+	lock := cqrsgen.NewLock()
+	files, err := generate(dir, &cqrsgen.Generator{Strict: true, Lock: lock})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully generated identity fixture")
+	}
+	tests.Passed("Should have successfully generated identity fixture")
+
+	for name, content := range files {
+		golden(t, filepath.Join(dir, name+".golden"), content)
+	}
+
+	identity, ok := lock.Identity("Wallet")
+	if !ok || identity.ID != "wallet" || identity.Version != 2 {
+		tests.Info("Identity: %#v", identity)
+		tests.Failed("Should have locked aggregate id and version of annotation")
+	}
+	tests.Passed("Should have locked aggregate id and version of annotation")
+}
+
+func TestLock(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "cqrsgen")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created lock directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, cqrsgen.LockFileName)
+	locked := `{"aggregates": {"Wallet": {"id": "purse", "version": 2}, "Purse": {"id": "coin-purse", "version": 1}}}`
+	if err := ioutil.WriteFile(file, []byte(locked), 0644); err != nil {
+		tests.FailedWithError(err, "Should have successfully written lock file")
+	}
+
+	lock, err := cqrsgen.ReadLock(file)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read lock file")
+	}
+	tests.Passed("Should have successfully read lock file")
+
+	generator := &cqrsgen.Generator{Lock: lock}
+	if _, err := generate(filepath.Join("testdata", "identity"), generator); err != nil {
+		tests.FailedWithError(err, "Should have successfully generated identity fixture")
+	}
+
+	if err := generator.Release(); err != nil {
+		tests.FailedWithError(err, "Should have successfully released ungenerated structs")
+	}
+
+	golden(t, filepath.Join("testdata", "identity", "lock.golden"), diagnostics(generator))
+
+	if identity, _ := lock.Identity("Wallet"); identity.ID != "purse" {
+		tests.Failed("Should have kept locked aggregate id changed without version bump")
+	}
+	tests.Passed("Should have kept locked aggregate id changed without version bump")
+
+	if err := lock.Write(file); err != nil {
+		tests.FailedWithError(err, "Should have successfully written lock file")
+	}
+
+	lock, err = cqrsgen.ReadLock(file)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read written lock file")
+	}
+
+	if identity, ok := lock.Identity("Purse"); !ok || !identity.Released || identity.ID != "coin-purse" {
+		tests.Info("Received: %+v", identity)
+		tests.Failed("Should have kept ungenerated struct in lock marked as released")
+	}
+	tests.Passed("Should have kept ungenerated struct in lock marked as released")
+
+	rerun := &cqrsgen.Generator{Lock: lock}
+	if _, err := generate(filepath.Join("testdata", "identity"), rerun); err != nil {
+		tests.FailedWithError(err, "Should have successfully generated identity fixture")
+	}
+
+	if err := rerun.Release(); err != nil {
+		tests.FailedWithError(err, "Should have successfully released ungenerated structs")
+	}
+
+	golden(t, filepath.Join("testdata", "identity", "lock.golden"), diagnostics(rerun))
+
+	lock, err = cqrsgen.ReadLock(file)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read written lock file")
+	}
+
+	strict := &cqrsgen.Generator{Strict: true, Lock: lock}
+	if _, err := generate(filepath.Join("testdata", "identity"), strict); err == nil {
+		tests.Failed("Should have failed generation of changed aggregate id in strict mode")
+	}
+	tests.Passed("Should have failed generation of changed aggregate id in strict mode")
+
+	lock, err = cqrsgen.ReadLock(file)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read written lock file")
+	}
+
+	if err := (&cqrsgen.Generator{Strict: true, Lock: lock}).Release(); err == nil {
+		tests.Failed("Should have failed releasing ungenerated struct in strict mode")
+	}
+	tests.Passed("Should have failed releasing ungenerated struct in strict mode")
+}
+
+func TestLockAdopted(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "cqrsgen")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created lock directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, cqrsgen.LockFileName)
+	locked := `{"aggregates": {"Purse": {"id": "wallet", "version": 1, "released": true}}}`
+	if err := ioutil.WriteFile(file, []byte(locked), 0644); err != nil {
+		tests.FailedWithError(err, "Should have successfully written lock file")
+	}
+
+	lock, err := cqrsgen.ReadLock(file)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read lock file")
+	}
+	tests.Passed("Should have successfully read lock file")
+
+	generator := &cqrsgen.Generator{Strict: true, Lock: lock}
+	if _, err := generate(filepath.Join("testdata", "identity"), generator); err != nil {
+		tests.FailedWithError(err, "Should have successfully generated identity fixture")
+	}
+
+	if err := generator.Release(); err != nil {
+		tests.FailedWithError(err, "Should have successfully released struct whose aggregate id was adopted")
+	}
+	tests.Passed("Should have successfully released struct whose aggregate id was adopted")
+
+	if _, ok := lock.Identity("Purse"); ok {
+		tests.Failed("Should have removed released struct whose aggregate id was adopted")
+	}
+	tests.Passed("Should have removed released struct whose aggregate id was adopted")
+}
+
+func TestGenerateReadModels(t *testing.T) {
+	dir := filepath.Join("testdata", "readmodels")
+
+	generator := &cqrsgen.Generator{}
+	files, err := generate(dir, generator)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully generated readmodels fixture")
+	}
+	tests.Passed("Should have successfully generated readmodels fixture")
+
+	if len(files) != 2 {
+		tests.Info("Files: %d", len(files))
+		tests.Failed("Should have generated projection and saga")
+	}
+	tests.Passed("Should have generated projection and saga")
+
+	for name, content := range files {
+		golden(t, filepath.Join(dir, name+".golden"), content)
+	}
+
+	golden(t, filepath.Join(dir, "diagnostics.golden"), diagnostics(generator))
+}
+
+func TestCatalog(t *testing.T) {
+	generator := &cqrsgen.Generator{}
+	for _, dir := range []string{"users", "readmodels"} {
+		if _, err := generate(filepath.Join("testdata", dir), generator); err != nil {
+			tests.FailedWithError(err, "Should have successfully generated %s fixture", dir)
+		}
+	}
+	tests.Passed("Should have successfully generated fixtures")
+
+	catalog := generator.Catalog()
+
+	content, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully encoded catalog")
+	}
+	golden(t, filepath.Join("testdata", "catalog.json.golden"), append(content, '\n'))
+
+	content, err = json.MarshalIndent(catalog.AsyncAPI("fixtures"), "", "  ")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully encoded AsyncAPI document")
+	}
+	golden(t, filepath.Join("testdata", "asyncapi.json.golden"), append(content, '\n'))
+}
@@ -0,0 +1,166 @@
+package cqrsgen
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/influx6/moz/ast"
+)
+
+// consts of annotations used on methods of @escqrs structs.
+const (
+	// SkipAnnotation marks a Handle{{Event}} or Execute{{Command}} method to be skipped.
+	SkipAnnotation = "@escqrs-method-skip"
+
+	// legacySkipAnnotation is the older spelling of SkipAnnotation, still honoured.
+	legacySkipAnnotation = "@escqrs-skip"
+)
+
+// hasSkipAnnotation returns true/false if the method is annotated to be skipped.
+func hasSkipAnnotation(method ast.FuncDeclaration) bool {
+	return method.HasAnnotation(SkipAnnotation) || method.HasAnnotation(legacySkipAnnotation)
+}
+
+//*******************************************************************************
+// Diagnostics
+//*******************************************************************************
+
+// Severity defines the level of a Diagnostic.
+type Severity int
+
+// consts of Severity levels.
+const (
+	Info Severity = iota
+	Warning
+)
+
+// String returns the name of the Severity.
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "info"
+}
+
+// Diagnostic embodies a report about a method of a @escqrs struct which the generator
+// skipped, with the file and line of said method.
+type Diagnostic struct {
+	Severity Severity
+	File     string
+	Line     int
+	Struct   string
+	Method   string
+	Message  string
+}
+
+// String returns the diagnostic in the form 'file:line: severity: Struct.Method: message'.
+func (d Diagnostic) String() string {
+	target := d.Struct
+	if d.Method != "" {
+		target += "." + d.Method
+	}
+
+	if d.File == "" {
+		return fmt.Sprintf("%s: %s: %s", d.Severity, target, d.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s: %s: %s", d.File, d.Line, d.Severity, target, d.Message)
+}
+
+// StrictError is returned by a Generator in Strict mode for a struct whose methods were
+// skipped with warnings.
+type StrictError struct {
+	Struct   string
+	Warnings []Diagnostic
+}
+
+// Error implements the error interface.
+func (se StrictError) Error() string {
+	lines := make([]string, 0, len(se.Warnings)+1)
+	lines = append(lines, fmt.Sprintf("cqrsgen: %d method(s) of %q skipped in strict mode:", len(se.Warnings), se.Struct))
+	for _, warning := range se.Warnings {
+		lines = append(lines, "\t"+warning.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+//*******************************************************************************
+// Generator
+//*******************************************************************************
+
+// Generator generates scaffolding code for structs annotated with @escqrs, collecting the
+// diagnostics of all methods it skips across structs and the Catalog of all events they
+// handle. It is safe for concurrent use.
+type Generator struct {
+	Strict bool
+
+	// Lock, if set, records the aggregate id generated for each struct, reporting
+	// a warning for a struct whose aggregate id changed without a version bump.
+	Lock *Lock
+
+	ml          sync.Mutex
+	diagnostics []Diagnostic
+	catalog     catalog
+}
+
+// Diagnostics returns all diagnostics reported so far.
+func (g *Generator) Diagnostics() []Diagnostic {
+	g.ml.Lock()
+	defer g.ml.Unlock()
+	return append([]Diagnostic(nil), g.diagnostics...)
+}
+
+// Release reports a warning for each struct of the Lock which was not generated since it
+// was read, as happens when a struct is renamed, moved or deleted, and marks them released
+// in the Lock, so they are reported again on every run till their aggregate id is set on
+// another struct or they are removed from the lock file. In Strict mode, a StrictError is
+// returned if any struct was released.
+func (g *Generator) Release() error {
+	if g.Lock == nil {
+		return nil
+	}
+
+	names, identities := g.Lock.release()
+
+	var warnings []Diagnostic
+	for index, name := range names {
+		warnings = append(warnings, g.report(Warning, ast.StructDeclaration{Name: name}, ast.FuncDeclaration{},
+			"aggregate id %q is no longer generated; set @escqrs(id=%q) on the struct if it was renamed or moved, or remove it from %s if it was deleted", identities[index].ID, identities[index].ID, LockFileName))
+	}
+
+	if g.Strict && len(warnings) != 0 {
+		return StrictError{Struct: strings.Join(names, ", "), Warnings: warnings}
+	}
+
+	return nil
+}
+
+// report adds and returns a Diagnostic for the method of the struct.
+func (g *Generator) report(severity Severity, str ast.StructDeclaration, method ast.FuncDeclaration, format string, args ...interface{}) Diagnostic {
+	diagnostic := Diagnostic{
+		Severity: severity,
+		Struct:   str.Name,
+		Method:   method.FuncName,
+		Message:  fmt.Sprintf(format, args...),
+	}
+
+	// diagnostics of the struct itself are located at it's declaration.
+	switch {
+	case method.FuncName != "":
+		diagnostic.File = method.FilePath
+		if method.Declr != nil && method.From <= len(method.Declr.Source) {
+			diagnostic.Line = 1 + strings.Count(method.Declr.Source[:method.From], "\n")
+		}
+	case str.Declr != nil:
+		diagnostic.File = str.FilePath
+		if str.From <= len(str.Declr.Source) {
+			diagnostic.Line = 1 + strings.Count(str.Declr.Source[:str.From], "\n")
+		}
+	}
+
+	g.ml.Lock()
+	defer g.ml.Unlock()
+	g.diagnostics = append(g.diagnostics, diagnostic)
+	return diagnostic
+}
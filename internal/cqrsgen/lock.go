@@ -0,0 +1,216 @@
+package cqrsgen
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influx6/moz/ast"
+)
+
+// LockFileName is the name of the lock file kept by the cqrskit generate command
+// in the destination directory of generated code.
+const LockFileName = "cqrskit.lock"
+
+//*******************************************************************************
+// Aggregate Identity
+//*******************************************************************************
+
+// Identity embodies the aggregate id and version generated for a @escqrs struct.
+type Identity struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+
+	// Released marks the struct as no longer generated, kept so it's aggregate id stays
+	// reported till it's adopted by another struct or removed from the lock file.
+	Released bool `json:"released,omitempty"`
+
+	explicit bool
+}
+
+// identityFor returns the Identity of a @escqrs struct, taken from the id and version parameters
+// of it's annotation, as in @escqrs(id="user", version=2). Without an id parameter, the id is
+// the md5 sum of 'Name:Aggregate', and the version defaults to 1.
+func identityFor(an ast.AnnotationDeclaration, structName string) (Identity, error) {
+	identity := Identity{Version: 1}
+
+	for _, argument := range an.Arguments {
+		separator := "=>"
+		if !strings.Contains(argument, separator) {
+			separator = "="
+		}
+
+		pieces := strings.SplitN(argument, separator, 2)
+		if len(pieces) != 2 {
+			return identity, fmt.Errorf("%s of %q: invalid parameter %q, expected key=value", an.Name, structName, argument)
+		}
+
+		key, value := strings.TrimSpace(pieces[0]), strings.TrimSpace(pieces[1])
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		switch key {
+		case "id":
+			if value == "" {
+				return identity, fmt.Errorf("%s of %q: id must not be empty", an.Name, structName)
+			}
+			identity.ID = value
+			identity.explicit = true
+		case "version":
+			version, err := strconv.Atoi(value)
+			if err != nil || version < 1 {
+				return identity, fmt.Errorf("%s of %q: version must be a positive integer, got %q", an.Name, structName, value)
+			}
+			identity.Version = version
+		default:
+			return identity, fmt.Errorf("%s of %q: unknown parameter %q", an.Name, structName, key)
+		}
+	}
+
+	if identity.ID == "" {
+		sum := md5.Sum([]byte(structName + ":Aggregate"))
+		identity.ID = hex.EncodeToString(sum[:])
+	}
+
+	return identity, nil
+}
+
+//*******************************************************************************
+// Lock
+//*******************************************************************************
+
+// Lock records the Identity previously generated for each @escqrs struct, by name, so
+// that a rename or move of a struct which would change it's aggregate id, orphaning it
+// from it's stored events, is caught. It is safe for concurrent use.
+type Lock struct {
+	ml         sync.Mutex
+	aggregates map[string]Identity
+	claimed    map[string]bool
+}
+
+// NewLock returns a new empty Lock.
+func NewLock() *Lock {
+	return &Lock{
+		aggregates: map[string]Identity{},
+		claimed:    map[string]bool{},
+	}
+}
+
+// ReadLock returns the Lock stored in the giving file, or an empty one if the file does not exist.
+func ReadLock(file string) (*Lock, error) {
+	lock := NewLock()
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, err
+	}
+
+	var stored struct {
+		Aggregates map[string]Identity `json:"aggregates"`
+	}
+
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, fmt.Errorf("invalid lock file %q: %s", file, err)
+	}
+
+	for name, identity := range stored.Aggregates {
+		lock.aggregates[name] = identity
+	}
+
+	return lock, nil
+}
+
+// Write stores the Lock into the giving file.
+func (l *Lock) Write(file string) error {
+	l.ml.Lock()
+	defer l.ml.Unlock()
+
+	content, err := json.MarshalIndent(struct {
+		Aggregates map[string]Identity `json:"aggregates"`
+	}{
+		Aggregates: l.aggregates,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(content, '\n'), 0644)
+}
+
+// Identity returns the Identity locked for the named struct.
+func (l *Lock) Identity(name string) (Identity, bool) {
+	l.ml.Lock()
+	defer l.ml.Unlock()
+	identity, ok := l.aggregates[name]
+	return identity, ok
+}
+
+// claim checks the Identity of the named struct against the locked one, returning a
+// message if it's id changed without a bump of it's version, in which case the locked
+// Identity is kept. Otherwise the Identity is locked for the struct.
+func (l *Lock) claim(name string, identity Identity) (string, bool) {
+	l.ml.Lock()
+	defer l.ml.Unlock()
+
+	l.claimed[name] = true
+
+	locked, ok := l.aggregates[name]
+	if ok && locked.ID != identity.ID && identity.Version <= locked.Version {
+		return fmt.Sprintf("aggregate id changed from %q to %q, orphaning it's stored events; set @escqrs(id=%q) to keep it or bump it's version above %d", locked.ID, identity.ID, locked.ID, locked.Version), false
+	}
+
+	l.aggregates[name] = identity
+	return "", true
+}
+
+// release marks all structs not claimed since the Lock was read as released, returning their
+// names with their locked Identity. Released structs whose aggregate id was adopted by a claimed
+// struct, as happens once a renamed struct sets the id of it's old name, are removed instead.
+func (l *Lock) release() ([]string, []Identity) {
+	l.ml.Lock()
+	defer l.ml.Unlock()
+
+	adopted := map[string]bool{}
+	for name, identity := range l.aggregates {
+		if l.claimed[name] {
+			adopted[identity.ID] = true
+		}
+	}
+
+	var names []string
+	for name, identity := range l.aggregates {
+		if l.claimed[name] {
+			continue
+		}
+
+		if adopted[identity.ID] {
+			delete(l.aggregates, name)
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	identities := make([]Identity, 0, len(names))
+	for _, name := range names {
+		identity := l.aggregates[name]
+		identity.Released = true
+		l.aggregates[name] = identity
+		identities = append(identities, identity)
+	}
+
+	return names, identities
+}
@@ -0,0 +1,120 @@
+package cqrsgen
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/influx6/faux/fmtwriter"
+
+	"github.com/gokit/cqrskit/internal/static"
+	"github.com/influx6/moz/ast"
+	"github.com/influx6/moz/gen"
+)
+
+// consts of annotations for read models and process managers.
+const (
+	// ProjectionAnnotation marks a struct as a projection, with On{{Event}} methods.
+	ProjectionAnnotation = "@escqrs-projection"
+
+	// SagaAnnotation marks a struct as a saga, with On{{Message}} methods.
+	SagaAnnotation = "@escqrs-saga"
+)
+
+// GenerateProjection generates a Project method for structs annotated with @escqrs-projection,
+// dispatching each event of a cqrskit.EventCommit to the On{{Event}} method receiving it's type,
+// so the struct implements cqrskit.Projection. Methods are skipped and reported as done by Generate.
+func (g *Generator) GenerateProjection(toPackage string, an ast.AnnotationDeclaration, str ast.StructDeclaration, declr ast.PackageDeclaration, pkg ast.Package) ([]gen.WriteDirective, error) {
+	pairs, imports, err := g.handlers(str, declr, pkg, "event")
+	if err != nil {
+		return nil, err
+	}
+
+	g.record("projection", str, pkg, "", pairs)
+
+	return g.document(str, declr, pairs, imports, "projection")
+}
+
+// GenerateSaga generates a Transition method for structs annotated with @escqrs-saga, dispatching
+// the payload of each cqrskit.Message to the On{{Message}} method receiving it's type along with a
+// cqrskit.SagaAction, so the struct implements cqrskit.Saga. Methods are skipped and reported as
+// done by Generate.
+func (g *Generator) GenerateSaga(toPackage string, an ast.AnnotationDeclaration, str ast.StructDeclaration, declr ast.PackageDeclaration, pkg ast.Package) ([]gen.WriteDirective, error) {
+	pairs, imports, err := g.handlers(str, declr, pkg, "message", "cqrskit.SagaAction")
+	if err != nil {
+		return nil, err
+	}
+
+	return g.document(str, declr, pairs, imports, "saga")
+}
+
+// handlers returns the MethodEventPair of all On{{Type}} methods of the struct, reporting
+// the methods skipped. In Strict mode, a StrictError is returned if any was skipped with
+// a warning.
+func (g *Generator) handlers(str ast.StructDeclaration, declr ast.PackageDeclaration, pkg ast.Package, kind string, extra ...string) ([]MethodEventPair, []gen.ImportItemDeclr, error) {
+	var pairs []MethodEventPair
+	var warnings []Diagnostic
+	var methodImports []gen.ImportItemDeclr
+
+	methods, _ := declr.MethodFor(str.Object.Name.Name)
+	for _, method := range methods {
+		if !strings.HasPrefix(method.FuncName, "On") {
+			continue
+		}
+
+		if hasSkipAnnotation(method) {
+			g.report(Info, str, method, "skipped, annotated with %s", SkipAnnotation)
+			continue
+		}
+
+		pair, imports, diagnostic, ok := handlerPair(method, declr, pkg, "On", kind, extra...)
+		if !ok {
+			warnings = append(warnings, g.report(Warning, str, method, "%s", diagnostic))
+			continue
+		}
+
+		pairs = append(pairs, pair)
+		methodImports = append(methodImports, imports...)
+	}
+
+	if g.Strict && len(warnings) != 0 {
+		return nil, nil, StrictError{Struct: str.Name, Warnings: warnings}
+	}
+
+	return pairs, append(methodImports, gen.Import("github.com/gokit/cqrskit", "")), nil
+}
+
+// document returns the WriteDirective of the file generated for the struct from the
+// template of the giving kind.
+func (g *Generator) document(str ast.StructDeclaration, declr ast.PackageDeclaration, pairs []MethodEventPair, imports []gen.ImportItemDeclr, kind string) ([]gen.WriteDirective, error) {
+	document := gen.Package(
+		gen.Name(declr.Package),
+		gen.Imports(imports...),
+		gen.Block(
+			gen.SourceTextWith(
+				"cqrskit:"+kind,
+				string(static.MustReadFile(kind+".tml", true)),
+				gen.ToTemplateFuncs(
+					ast.ASTTemplatFuncs,
+					template.FuncMap{},
+				),
+				struct {
+					Str   ast.StructDeclaration
+					Pkg   ast.PackageDeclaration
+					Pairs []MethodEventPair
+				}{
+					Str:   str,
+					Pkg:   declr,
+					Pairs: pairs,
+				},
+			),
+		),
+	)
+
+	return []gen.WriteDirective{
+		{
+			FileName: fmt.Sprintf("%s.%s.go", strings.ToLower(str.Object.Name.Name), kind),
+			Writer:   fmtwriter.New(document, true, true),
+		},
+	}, nil
+}
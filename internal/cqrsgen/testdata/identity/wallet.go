@@ -0,0 +1,15 @@
+package identity
+
+// @escqrs(id="wallet", version=2)
+type Wallet struct {
+	Balance int
+}
+
+type WalletCredited struct {
+	Amount int
+}
+
+func (w *Wallet) HandleWalletCredited(ev WalletCredited) error {
+	w.Balance += ev.Amount
+	return nil
+}
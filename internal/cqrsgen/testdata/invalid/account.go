@@ -0,0 +1,55 @@
+package invalid
+
+import "github.com/gokit/cqrskit"
+
+//@escqrs
+type Account struct {
+	Balance int
+}
+
+type AccountOpened struct {
+	Owner string
+}
+
+type AccountCredited struct {
+	Amount int
+}
+
+type AccountFrozen struct {
+	Reason string
+}
+
+type OpenAccount struct {
+	Owner string
+}
+
+func (a *Account) HandleAccountOpened(ev AccountOpened) error {
+	return nil
+}
+
+func (a *Account) HandleAccountClosed() error {
+	return nil
+}
+
+func (a *Account) HandleAccountCredited(ev AccountCredited, amount int) error {
+	return nil
+}
+
+func (a *Account) HandleAccountDebited(amount int) error {
+	return nil
+}
+
+func (a *Account) HandleAccountFrozen(ev AccountFrozen) {
+}
+
+func (a *Account) HandleAccountMoved(ev AccountMoved) error {
+	return nil
+}
+
+func (a *Account) ExecuteOpenAccount(cmd OpenAccount) error {
+	return nil
+}
+
+func (a *Account) ExecuteCloseAccount(cmd OpenAccount) ([]cqrskit.Event, error) {
+	return nil, nil
+}
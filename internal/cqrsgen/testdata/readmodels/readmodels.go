@@ -0,0 +1,87 @@
+package readmodels
+
+import (
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/gokit/cqrskit/internal/cqrsgen/testdata/users/events"
+)
+
+type Audit struct {
+	By string `json:"by"`
+}
+
+type OrderItem struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+type OrderPlaced struct {
+	Audit
+	OrderID string            `json:"order_id"`
+	Total   int               `json:"total"`
+	Placed  time.Time         `json:"placed"`
+	Items   []OrderItem       `json:"items"`
+	Notes   *string           `json:"notes,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Ignored string            `json:"-"`
+	secret  string
+}
+
+type OrderShipped struct {
+	OrderID string
+}
+
+type PaymentReceived struct {
+	OrderID string
+}
+
+type ShipOrder struct {
+	OrderID string
+}
+
+// @escqrs-projection
+type OrderSummary struct {
+	Placed  int
+	Shipped int
+	Names   []string
+}
+
+func (o *OrderSummary) OnOrderPlaced(ev OrderPlaced) error {
+	o.Placed++
+	return nil
+}
+
+func (o *OrderSummary) OnUserNameUpdated(ev events.UserNameUpdated) error {
+	o.Names = append(o.Names, ev.Name)
+	return nil
+}
+
+// @escqrs-method-skip
+func (o *OrderSummary) OnOrderShipped(ev OrderShipped) error {
+	return nil
+}
+
+func (o *OrderSummary) OnOrderCancelled() error {
+	return nil
+}
+
+// @escqrs-saga
+type Fulfilment struct {
+	Paid bool
+}
+
+func (f *Fulfilment) OnPaymentReceived(msg PaymentReceived, action cqrskit.SagaAction) error {
+	f.Paid = true
+	action([]cqrskit.Message{{Type: "ShipOrder", Payload: ShipOrder{OrderID: msg.OrderID}}}, nil)
+	return nil
+}
+
+func (f *Fulfilment) OnOrderPlaced(msg OrderPlaced) error {
+	return nil
+}
+
+func (f *Fulfilment) OnOrderShipped(msg OrderShipped, action string) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+package shared
+
+// EmailUpdated is applied by both the User and the Account aggregates.
+type EmailUpdated struct {
+	Email string
+}
+
+// @escqrs
+type User struct {
+	Email string
+}
+
+func (u *User) HandleEmailUpdated(ev EmailUpdated) error {
+	u.Email = ev.Email
+	return nil
+}
+
+// @escqrs
+type Account struct {
+	ContactEmail string
+}
+
+func (a *Account) HandleEmailUpdated(ev EmailUpdated) error {
+	a.ContactEmail = ev.Email
+	return nil
+}
@@ -0,0 +1,5 @@
+package events
+
+type UserNameUpdated struct {
+	Name string
+}
@@ -0,0 +1,48 @@
+package users
+
+import (
+	"github.com/gokit/cqrskit"
+	"github.com/gokit/cqrskit/internal/cqrsgen/testdata/users/events"
+)
+
+//@escqrs
+type User struct {
+	InstanceID string
+	Version    int
+	Email      string
+	Username   string
+}
+
+type UserEmailUpdated struct {
+	New string
+}
+
+func (u *User) HandleUserEmailUpdated(ev UserEmailUpdated) error {
+	u.Email = ev.New
+	return nil
+}
+
+func (u *User) HandleUserNameUpdated(ev events.UserNameUpdated) error {
+	u.Username = ev.Name
+	return nil
+}
+
+//@escqrs-method-skip
+func (u *User) HandleUserRackUpdated(ev UserEmailUpdated) error {
+	return nil
+}
+
+//@escqrs-skip
+func (u *User) HandleUserEmailReset(ev UserEmailUpdated) error {
+	return nil
+}
+
+type UpdateUserEmail struct {
+	Email string
+}
+
+func (u *User) ExecuteUpdateUserEmail(cmd UpdateUserEmail) ([]cqrskit.Event, error) {
+	return []cqrskit.Event{
+		NewUserEmailUpdatedEvent(UserEmailUpdated{New: cmd.Email}),
+	}, nil
+}
@@ -21,6 +21,14 @@ var (
         
           "appliers.tml",
         
+          "event.tml",
+        
+          "projection.tml",
+        
+          "repository.tml",
+        
+          "saga.tml",
+        
       },
     
   }
@@ -29,11 +37,35 @@ var (
     
       
         "appliers.tml": { // all .tml assets.
-          data: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x92\x41\x6b\xdc\x30\x10\x85\xcf\xd6\xaf\x78\x2c\x39\xd8\x21\x68\xe9\xa1\x97\x40\x0e\xa1\x69\x21\xd0\x96\x42\x4b\xaf\x41\x2b\xcf\xda\x6a\x6d\xc9\x3b\x92\x1d\x16\xad\xfe\x7b\x91\xbc\x09\x49\xa0\xb7\x76\xd9\x8b\xd1\x37\x6f\xde\xcc\xbc\x18\x71\xd1\x2b\xdb\x0e\x84\xeb\x1b\xd4\x7e\xde\x79\xc8\xef\x81\xe5\x57\x35\x12\xde\xe1\x84\xe0\x3e\xbb\x47\xe2\x06\x29\x89\x45\x31\x6a\x51\x6d\xb7\x88\xf1\x99\x4a\xe9\xb6\xeb\x98\x3a\x15\xe8\xfe\x0e\x4c\x13\x93\x27\x1b\x3c\x42\x4f\x98\xad\x39\xcc\x04\xf5\x44\xc0\xb4\xd8\x3b\x86\x1a\x06\xd0\x92\xb1\x22\xc7\x34\xa8\x40\x2d\x82\x2b\x55\xaf\xd4\x11\x8e\x13\x49\xdc\x07\x98\x55\x33\x7f\x17\x7b\xbd\xf2\x3d\xb5\x98\xbd\xb1\x1d\x14\xc6\xf6\x3d\xfc\x3c\x4a\x51\xfd\xd5\xdd\x0d\x62\xc4\x2f\x67\xec\x4f\xc5\x46\xb5\x46\x63\x73\xbd\x79\x31\xf0\xe6\x19\xde\xe0\x54\x04\x4f\x38\xcc\x2e\x50\x9e\xbe\x11\x62\xbb\xbd\xfc\xb7\x3f\xf1\x76\x97\xf8\x98\xb7\x82\xdb\x69\x1a\x0c\x31\xfe\x43\xc7\xdc\x32\xcb\x1f\x41\xe3\xce\xb5\x86\xd6\xad\x1a\x1b\x88\xad\x1a\x30\xb8\xce\x68\x58\xd2\xe4\xbd\xe2\x63\xbe\x89\x2a\xb8\x9f\x48\x9b\xbd\xd1\xe7\xc3\x95\x87\x37\xe6\x77\xc7\xac\xae\xd5\x30\x94\x93\x4c\x13\xbb\x89\x4d\xbe\xfb\x48\xa1\x77\xad\x97\x62\x3f\x5b\x8d\x3a\xc6\x73\xec\x52\xc2\xe5\x2b\x8d\x66\x35\x57\xd3\xe2\xa1\x0f\xec\x7f\x9b\x20\xcb\x4e\x3e\xb8\x71\x34\xa1\x01\x31\x3b\x46\x14\x55\xce\xd1\xc3\xd5\xea\x26\xa7\x97\x95\xed\x08\xb4\xf8\x95\xf7\x99\xa9\xfc\xa3\x09\xba\x07\x2d\x99\x28\xa8\xbc\x53\x41\xc9\x3a\xa7\xa8\x29\x48\x8c\xe7\xd2\x8b\x87\x2b\x5c\x4c\xca\x70\x66\xe5\x37\x65\xd8\x23\x25\xad\x7c\x4e\x64\x79\x90\x3f\xce\xd9\x4b\xe9\x5a\x54\x55\xc5\x14\x66\xb6\x78\x31\x8d\x7c\x22\xbf\x94\x81\xe5\xa7\xd9\xea\xb5\xa0\xa6\xa5\x29\xdd\xc8\xb6\x29\x89\xaa\x4a\x22\xff\xcf\x12\xd6\x0c\x22\x09\xf1\x27\x00\x00\xff\xff\x41\xd7\x6f\xed\x90\x03\x00\x00"),
+          data: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x96\x5d\x6b\xe4\x36\x14\x86\xaf\xad\x5f\x71\x0a\x81\xda\x61\x50\xd8\x8b\xde\xa4\x04\xba\x34\x29\x04\xda\x52\xfa\x75\x53\xca\xa2\x58\xc7\x33\x87\xb5\x25\x47\x92\x27\x3b\xa8\xfa\xef\xe5\xc8\xf2\x4e\x3c\xd9\x4d\xf7\xa2\x49\xc8\x4d\xa4\x57\xe7\x4b\x8f\x5e\x27\x46\x38\xdb\x29\xa3\x7b\x84\xcb\x2b\xa8\xfd\x74\xe7\x41\xfe\x16\x9c\xfc\x59\x0d\x08\x6f\xe0\x1f\x08\xf6\x47\xfb\x80\xae\x81\x94\xc4\x5e\x39\xa8\x45\x75\x71\x01\x31\x7e\x54\xa5\xf4\x76\xbb\x75\xb8\x55\x01\x6f\xaf\xc1\xe1\xe8\xd0\xa3\x09\x1e\xc2\x0e\x61\x32\x74\x3f\x21\xa8\x45\x01\xa4\xa1\xb3\x0e\x54\xdf\x03\xee\x59\x96\xc3\x39\xec\x55\x40\x0d\xc1\xe6\x53\xab\xe8\x10\x0e\x23\x4a\x88\x11\xa8\x03\x79\xf3\x61\xec\xa9\xa5\x00\x29\xdd\x06\x20\x0f\x1e\x03\xdc\x1d\xf2\x31\xd2\x30\x2a\xa7\x06\x0c\xe8\xc0\x76\x40\xe1\x6b\x0f\xdf\xa1\x6f\xef\x9d\x07\x65\x8c\x0d\x2a\x90\x35\x32\x46\xec\x3d\x2e\x01\xf8\x24\xa7\xc8\x1d\xef\x94\xdf\xa1\x86\xc9\x93\xd9\x82\x82\x41\x7f\x03\x7e\x1a\xf8\x84\xd1\x29\x89\xea\xb3\x7d\x5f\x71\x81\xf7\x93\x0d\x08\xf2\x56\xa3\x09\x14\x0e\xf2\xf6\x9a\xa7\xf6\xcc\xc0\xfe\x44\xe7\xc9\x9a\xd3\xa9\xed\xcb\xb2\xed\x72\x5f\xab\xe9\xcd\x6b\x4f\x63\xe6\x31\x6d\x1e\x8f\x63\x89\xf2\x25\x33\x81\x5a\x63\xa7\xa6\x9e\xaf\xcd\xc2\x9b\x46\x8a\xea\xf9\x8a\x73\xbf\xc7\x4e\x97\xe5\x94\x44\x23\xc4\xc5\xc5\xf9\xff\xfb\x23\x9e\x74\x7b\xc3\xf0\xc0\xdb\x71\xec\x09\x1d\xbc\x40\x46\x4e\xc9\xe1\x0f\x80\xc3\x9d\xd5\x84\xf3\xd5\x90\x09\xe8\x8c\xea\xa1\xb7\x5b\x6a\xc1\x60\x8b\xde\x2b\x77\xe0\xb1\xa9\x2c\xf7\x23\xb6\xd4\x51\x5b\xf8\xce\x1b\x27\xc5\xdf\x1d\x38\x7a\xab\xfa\x3e\x63\x36\x8e\xce\x8e\x8e\xf8\x82\x07\x0c\x3b\xab\xbd\x14\xdd\x64\x5a\xa8\x63\x2c\xaf\x33\x25\x38\x5f\xc5\x68\x72\xef\x87\x1a\xf7\x1e\x98\xef\xf7\x14\x64\x9e\xc9\xf7\x76\x18\x28\x34\x80\xce\x59\x07\x51\x54\xfc\xdc\xde\x6d\xe6\x6a\xf8\x91\x3b\x65\xb6\x08\xb8\xf7\xb3\xde\xb3\xa6\xf2\x0f\x14\xda\x1d\xe0\x9e\x15\x59\x2a\xaf\x55\x50\xb2\x66\xaa\x9a\x2c\x89\xb1\x1c\x3d\x7b\xb7\x81\xb3\x51\x91\x63\xad\xfc\x45\x91\xf3\x90\x52\xab\x3c\x42\x8c\x79\x43\xfe\x5e\xde\x53\x4a\x97\xa2\xaa\x2a\xea\xb8\x1c\x96\x3f\x6a\x48\x2e\xe2\x9f\x72\xcf\xf2\x87\xc9\xb4\xf3\x7c\x6a\xdc\x37\xdf\xe6\x13\x5f\x5d\x81\xa1\x3e\xa7\xaf\x2a\x87\x61\x72\x86\xd7\xf9\xcf\x94\x4b\x2a\xcf\xb2\x4a\x82\x7f\x8b\xc2\x50\x2f\x92\x10\xc5\x31\x96\x02\x5f\x8d\x4a\xff\x42\x34\xfe\x8a\x5b\xf2\x01\xdd\x2a\x65\xb9\x43\x57\xf6\x8e\x76\xe6\xd9\x01\x8f\x36\x9b\xe1\xa4\xd9\x62\xd5\xba\x6a\x6e\xe3\x81\xc2\x2e\x1f\xdd\xd2\x9e\x99\x5c\x21\x35\x27\x76\x87\x42\xe5\x33\x75\xd4\xae\x48\xe1\xfc\x93\x11\x98\xa4\xff\xe0\xa8\x5a\x42\xc8\x25\x4f\xbd\x80\xf2\x28\x13\x03\xb6\xf9\x04\x6e\x31\x35\xa2\x50\x91\xc4\x5c\x2e\x19\x0a\x35\x27\xae\x9e\x2b\x7c\x55\xae\x6f\x44\x5a\xc2\x14\x8a\xf8\x59\x29\xa3\x5f\x0b\xa4\x92\x0e\xae\xc9\x8f\x2a\xb4\x3b\x74\x2f\x04\xd5\xcd\x07\x6c\xa7\x80\xa0\x97\x44\x7e\x85\x41\x29\xa3\x7c\x97\x17\xf1\xec\x52\x19\xb0\x93\xba\xd9\x6c\xf8\xb3\xcb\x1d\x31\x85\x1b\x98\xdf\x24\x33\xc5\x71\x0b\x8c\x14\x60\x74\x56\x4f\x2d\x6a\x79\x64\xcd\xb9\x3f\xcc\x7b\x63\x1f\xcc\xd2\x3d\xf9\x72\x1c\xe7\xff\x1a\x4a\x39\x7e\x21\xd6\x4e\x01\xac\xc1\x2f\x71\xcb\x52\x7a\xdd\x0e\x7a\xf6\xf0\x4e\xb5\x18\x53\x03\xf5\x5f\x7f\xaf\xee\x7e\xc3\x06\x63\x5d\x06\xa6\xb8\xe2\x32\x85\xcb\x2b\x68\x07\x7d\xf4\xc4\x35\xc9\x8f\x54\xb2\x34\xc0\xb4\x54\x8b\x31\x96\xfd\x13\x6f\x2c\x96\x75\xe2\x8b\x8b\xf6\xa9\x35\x96\x9d\x8f\x94\xaf\x6d\x6f\xf3\xf9\x61\x8a\x24\x62\x44\xa3\x53\x12\xff\x0e\x00\x2f\x4c\xde\xc5\xf5\x09\x00\x00"),
           path: "appliers.tml",
           root: "appliers.tml",
         },
       
+        "event.tml": { // all .tml assets.
+          data: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x90\xb1\x4e\xc3\x30\x10\x86\x67\xdf\x53\xdc\x08\xa8\x72\xf6\x4a\x6c\x30\xb0\x54\x0c\xbc\xc0\x35\xbd\x3a\x56\x13\x27\xb5\x2f\x41\x91\x95\x77\x47\x97\x56\x88\xd2\x64\x83\x6c\xb9\xf3\xff\x7f\x9f\x0d\x45\xf1\xf4\xb7\x1f\x14\x05\xe6\x6c\xdf\xc9\x47\xbb\xa3\x86\xa7\x09\x5f\x07\x0e\xf2\x0f\xa4\x7b\xd4\x4c\xfa\x18\x3b\x46\x9f\x50\x2a\xc6\x24\xb4\xaf\x19\x45\x47\x81\x1a\xc6\xf6\xf8\x1d\xd1\x73\x57\x43\xd6\x5c\xda\x60\x62\x41\xba\x24\x75\x8b\xed\x51\x19\x52\xb1\x8f\x58\x9e\x63\x3a\x79\xb1\x33\xc3\xe2\x9b\x28\x23\x55\x14\xf9\x80\xfb\x11\xa9\xae\x91\x9c\x8b\xec\x48\x38\x29\x47\x5b\x3a\x2a\x4f\xe4\x18\xa9\xeb\xea\xd1\x07\xa7\xd5\x8d\x85\xb2\x0d\x49\x56\xdd\x9f\x31\xe7\x73\xdf\x0a\xe3\xcf\x3d\xa8\xca\x8e\x3f\x17\x52\x18\x59\xfa\x18\x12\xd2\xad\xe5\x6c\xa1\xf7\x58\x23\x55\x6d\x7d\xb8\x5a\x69\xbb\xf3\x83\xfe\xdd\x3f\x90\x85\x63\x1f\xca\x15\xfa\x03\x0f\x0b\x91\xc7\x5f\x2a\x19\xcc\xc5\xf2\x76\x9e\xc1\x18\xe5\x6c\x71\xa1\x59\x17\x1b\x30\xe6\x85\x84\xb6\xc8\xc3\x06\xcc\x04\x13\x7c\x0d\x00\x87\xd4\x72\x6b\xb5\x02\x00\x00"),
+          path: "event.tml",
+          root: "event.tml",
+        },
+      
+        "projection.tml": { // all .tml assets.
+          data: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x53\xc1\x6e\xdb\x3a\x10\x3c\x93\x5f\x31\x0f\xc8\x41\x0a\x0c\x19\xef\x9a\x07\x9f\x5e\xda\x53\x5b\x04\x6d\xee\x06\x2d\xad\x2c\x36\x32\xa9\x2c\x69\x1b\x06\xcb\x7f\x2f\x28\x51\x46\x95\xa6\xe9\xa5\xf5\xc9\x10\x67\x67\x66\x77\x76\x43\xc0\x4d\xa7\x4c\xd3\x13\xee\x36\x28\xdc\x71\xe7\x50\x7d\xf1\x5c\x7d\x52\x07\xc2\xbf\xf8\x06\x6f\x3f\xd8\x33\x71\x89\x18\xe5\x7a\x7d\xfb\x67\x7f\x72\xbd\x46\x08\x57\xc1\x18\xf1\xc0\xf6\x2b\xd5\x5e\x5b\xf3\x17\xd4\x92\x5c\x16\x00\x1d\x76\xb6\xd1\xe4\xe0\x3b\x82\x36\x9e\xd8\xa8\x1e\xbd\xdd\xeb\x1a\x86\x6a\x72\x4e\xf1\x05\xde\x62\xc8\x05\x6e\xa0\x5a\xb7\xba\x06\x9d\xc8\x78\x97\x6a\x2c\xd4\xd2\x7e\x12\xd8\x5d\x50\xab\xbe\xd7\x66\x0f\x35\x0c\x6c\x07\xd6\xca\x13\x0e\xe4\x3b\xdb\xb8\x0a\x8f\x1d\xa1\x51\x5e\xc1\xb6\x57\x2a\x87\x86\x6a\xdb\x50\x83\xb3\xf6\x1d\xea\x67\x76\x4f\xda\x57\xef\xa6\xe7\x56\xb3\xf3\x2b\x28\x97\xe8\xcf\x1d\x19\x30\xa9\x06\x3b\x55\x3f\xa1\x65\x7b\x80\xf3\x96\xc9\xc1\x32\x86\xe3\xae\xd7\xae\x23\x76\x50\x0e\x0a\x07\x35\x54\xc8\x34\x89\xda\x1e\x3d\xac\x21\x28\x26\xe8\xbd\xb1\x4c\x4d\x25\xdb\xa3\xa9\x51\x84\x90\x37\x21\x46\xdc\x2e\xba\x2a\xe7\xa1\x15\x74\x72\x4b\x73\xff\xdb\xc3\x41\xfb\x12\xc4\x6c\x19\x41\x8a\xd6\x32\xb6\xab\xa9\xb1\xb4\x52\xac\xcc\x9e\x40\x27\x37\x37\x13\x64\x08\xd0\x2d\xaa\x07\xa5\xd9\x21\x46\x21\x46\xf0\x2a\x71\xa4\x8a\x65\xf3\xd5\xfd\x38\x98\x62\xc4\x94\x52\x08\xdd\x8e\xc0\x7f\x36\x30\xba\x4f\x8a\x42\x30\xf9\x23\x9b\xf4\x59\x0a\x11\xa5\x14\xc2\x9d\xb5\xaf\x3b\xd0\x29\x11\x8e\xa5\xd5\xbd\xf2\xaa\x2a\xfc\x65\xa0\x72\xac\x0a\x21\x7b\xbb\xd9\xae\x70\x33\x28\xcd\x09\x7b\x75\x55\x2b\x47\x08\x61\x7c\xa8\x1e\x2f\x03\x4d\x01\xdf\x49\x71\xb5\x70\xb7\xc1\x0f\x33\xab\x66\xf0\xc7\x31\xe8\xea\xfd\xd1\xd4\x53\x4d\x41\xa7\xf2\xbf\x9f\x4c\x2f\x5d\x8b\x38\x5a\x22\xd3\xc4\xf4\x2f\xca\x10\xa8\x77\x94\xa6\xb3\x45\x6e\x41\xe6\xf7\x84\xcd\xc5\x46\xf7\x32\x4a\x79\x52\x8c\xed\x75\x70\x39\x2c\x6d\x0d\x36\x28\x5e\x44\x59\x18\xdd\x97\x2f\x23\x48\x7b\xf5\x99\xf6\xda\x79\xe2\x05\x3c\x67\xc6\xf9\x6d\x3a\x96\x34\x43\x97\xb6\x57\xf5\xfd\xbc\xc1\xf9\x48\xa8\xf9\xe5\x59\xa4\xed\x1b\xcb\xf7\xfa\x94\x6e\x63\x11\xf3\x24\xce\x97\xbc\x8b\x6f\x78\x29\x38\x43\x71\xfb\x2a\x43\x0a\xf7\x37\xd1\x8a\x99\xa2\x9a\x75\x8a\x10\x9e\x8f\xd6\xd3\x04\xce\x7a\xab\x57\xe2\x0f\xb1\x9c\x53\x88\x72\xf2\xaa\x8d\xf6\x45\x52\x15\x6f\xb9\x5e\x78\x75\xa5\x8c\x32\x04\x32\x4d\x8c\xf2\xfb\x00\x4a\x64\xd8\xcb\x82\x05\x00\x00"),
+          path: "projection.tml",
+          root: "projection.tml",
+        },
+      
+        "repository.tml": { // all .tml assets.
+          data: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x55\x5d\x8f\xe3\x34\x14\x7d\x8e\x7f\xc5\x41\x42\x4b\xbb\x0a\x19\xed\x6b\x51\x1f\x56\x33\x2b\x51\x69\x59\x89\x29\xda\x57\xe4\x89\x6f\x1b\x43\x62\x07\xdb\x6d\xb7\x0a\xf9\xef\xe8\x26\x71\x9a\x74\xda\x05\x24\xe8\xcb\xb4\xd7\xf7\xe3\x9c\xe3\x73\x3d\x4d\x83\x6f\x0b\x69\x54\x49\x58\xad\xb1\xf0\x87\x17\x8f\x6c\x1b\x5c\xf6\x49\x56\x84\x77\xf8\x13\xc1\x7e\xb4\x27\x72\x4b\xb4\xad\x78\x78\x78\xfb\xdf\x7e\xc4\xc3\x03\x9a\x66\x1c\xd8\xb6\x78\xa6\xda\x7a\x1d\xac\x3b\xff\x0f\xd3\x5e\x8d\xbb\x4c\x43\xed\xec\x51\x2b\xf2\x08\xe7\x9a\x14\x4a\x2b\x95\x36\x7b\x48\xa3\xe0\xe5\x91\xbf\xda\xdd\x15\x56\xb9\xdf\x3b\xda\xcb\x40\x9e\x1b\xdb\x23\x39\x48\xe4\x7f\x38\xff\xbb\x0e\xd9\x87\xed\xe3\xcf\xcf\xdb\x14\x07\xcf\xb5\xb3\xc2\xf7\xb1\x6e\xf3\x04\xe9\x11\x0a\xd2\xee\xd2\x0c\x5a\x65\x82\x41\xdc\x85\xea\x83\x3b\xe4\x01\x8d\x48\xc8\xa3\xff\xcc\xa7\x8a\xc4\xd1\x5e\xfb\xe0\xce\x78\x3b\x9e\x1c\xc9\x84\xe7\x21\x2c\x5a\xc1\x90\x3f\xd1\xe9\xde\x0c\x47\xe1\xe0\x8c\x87\x84\xa1\x13\xb4\xf1\x41\x9a\x9c\x5e\x69\x70\xa9\x48\x71\x2a\x74\x5e\x40\x51\x6e\x55\xaf\x08\xf1\x48\x8f\x93\x0e\x05\x93\x84\xa2\x9d\x3c\x94\xe1\x02\xb6\x3f\x8f\x58\x33\xb1\x3b\x98\xfc\x2b\xa0\x16\xe4\xaf\x88\x2e\xf1\xf6\x4e\x2e\xab\xd3\x73\xc0\x9b\x3b\x29\x0d\xf9\x15\xc8\xa7\x23\x80\xd5\x15\xb2\x76\x90\xe9\xa3\x95\x6a\x14\x84\x89\xcc\x1a\xb2\x26\x1c\xdc\xeb\xce\x25\x51\xaa\x14\x2f\x07\x5d\x06\xec\x9c\xad\xa0\xc3\x77\x1e\x25\x3b\x25\xc0\x1b\x59\xfb\xc2\x06\xe8\xdd\x6b\xfb\xeb\xaa\x2e\xa9\xea\x64\x89\x58\xb6\x43\xfe\x4f\xd2\xf9\x42\x96\xe4\xd2\xce\x94\xb2\x2c\x91\xdb\xaa\xd2\xc1\x43\xee\x02\x39\xe8\x30\x28\xb8\x70\x77\x65\x59\x76\x64\x16\x79\xf8\x82\xdc\x9a\x40\x5f\x42\xf6\xd8\xff\x4d\xc7\x4b\xde\x3c\xc1\x07\xa7\xcd\x7e\x89\xc5\xbc\x4f\x0a\x72\xce\xba\x25\x8b\x7b\x94\x0e\x4d\x33\x3c\x1e\x6d\x3b\xe7\x21\x92\xa6\x81\xde\x21\xfb\x4c\xce\x6b\x6b\x48\xa1\x6d\x8f\xfd\xf7\xa6\xa1\xd2\x53\xdb\xfe\xda\x34\x64\xd4\xd0\x94\x5f\x9f\x48\x98\x11\x8e\x2b\xc2\x50\x53\xb8\xac\xbb\xa7\x2c\xde\x54\x7a\x77\xa1\xa6\x34\x52\xbc\x99\x20\x5c\x8a\x44\xef\x98\x00\xbe\x59\xc3\xe8\x92\x49\x44\x8b\x18\x5d\x76\x30\x44\xd2\x0a\x91\x4c\x8a\xb2\xcd\x45\x94\xf5\xa4\xf5\x0d\x7a\xf3\xba\xe1\x04\x6b\x5c\x68\x33\xdb\xa9\x2b\xc7\xec\x94\xf1\x0c\x66\xdb\xca\x23\xe1\xe4\x74\xe0\xa7\xa8\xa0\xb8\x44\xb5\xb3\xea\x90\x93\xc2\xcb\x99\x1f\x19\x5b\x55\x6c\x02\x6b\x6e\xf8\x51\xf2\xd6\xf2\xab\x53\xd2\xe0\x90\x94\xb3\x0c\xbb\x4d\xd6\x75\xa9\xfb\xce\x15\x82\x65\xcf\xdc\xa0\x82\x5f\x8a\x58\x0a\xcd\x0b\xfa\x1b\xe5\x81\x54\xbf\xc9\xf1\x9a\x3e\x38\xb7\x0d\xb2\xa4\x51\xfc\xc1\xd0\xaf\x01\x69\x8f\x17\x2a\xb4\x51\x3c\x16\xa5\xf4\x21\x36\xb7\xbb\x7e\x35\xa2\xb0\x29\xd8\x1b\x7d\x2c\x2a\xa8\x3d\x2a\x7b\x24\x85\x60\x63\x7b\x7e\x92\xfa\x0e\x59\x94\xf5\xef\x8d\xcf\xc2\xde\x36\xfe\xe4\x26\xae\xea\xd3\x51\xe9\x7e\x23\xd2\x78\x1d\x59\x96\x8d\x3a\x70\x64\x89\x45\xfc\xfd\xd8\x01\xfb\x91\xa4\x22\x37\x5d\x98\xe2\x12\x99\xba\x9d\x61\xdd\x74\xfb\x0c\xc8\x98\xb1\x79\x9a\xe1\x9d\xf8\x33\xc5\x8d\x9b\x9c\xa6\x7e\x9e\xef\xdf\xf7\xef\x06\xed\x46\x92\x91\x5d\x96\x65\x5f\x5b\x96\x09\x91\x7e\x5f\x86\xcc\xd5\x7a\x86\xec\x7d\x5d\x97\xe7\x51\x95\x4e\xa5\x5e\x1a\xde\xbb\xee\xa7\x5f\xf1\xbf\x2e\x0c\x53\x53\x91\x24\x9c\x20\x8d\xea\xe3\x11\x95\x48\x92\x01\x7a\x1f\xef\xe7\x47\x3a\xb1\x4c\x87\xcd\xd3\x6a\x72\x1c\x63\x7c\x7e\x11\x69\x35\x9e\x4f\x84\x13\x49\x32\xd1\x77\x15\x33\xa6\x9a\x8b\xa4\x5d\xfe\xf0\xcf\xf4\xf8\x17\x4f\xc3\x30\x68\x08\x44\x2b\x5f\xf7\x35\xba\x14\xad\xf8\x6b\x00\x74\xc6\x81\x2c\xaa\x09\x00\x00"),
+          path: "repository.tml",
+          root: "repository.tml",
+        },
+      
+        "saga.tml": { // all .tml assets.
+          data: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x53\xcb\x6e\xdb\x3a\x14\x5c\x8b\x5f\x31\x17\xc8\x42\x0a\x0c\x1a\x77\x6b\xc0\x8b\x0b\xe4\x76\xd5\x14\x41\x93\xae\x03\x5a\x3a\x92\x89\x48\xa4\x42\x52\x31\x0c\x96\xff\x5e\x90\x7a\xf8\xd1\x34\xe8\xa2\xd5\x8a\x8f\xa3\x99\xe1\x99\x39\xde\xe3\x66\x2f\x54\xd5\x12\x36\x5b\xe4\x76\xd8\x59\xf0\x47\x67\xf8\x17\xd1\x11\xfe\xc5\x77\x38\xfd\x59\x1f\xc8\x14\x08\x81\xad\xd7\xb7\x7f\xf6\x63\xeb\x35\xbc\x5f\x08\x43\xc0\xa3\x68\xc4\x5f\xe0\x89\x44\x4f\x46\x28\x2b\x9d\xd4\x0a\xb2\xeb\x5b\xea\x48\x39\x0b\xb7\x27\x94\xaf\xc6\xbe\x48\xc7\x23\x39\xa4\x72\x64\x6a\x51\xd2\x0a\x6e\xfa\x43\x35\x10\x57\x3a\x77\x47\x94\xa2\x6d\xa5\x6a\x22\x42\x84\x17\x7d\x6f\x74\x6f\xa4\x70\x84\x8e\xdc\x5e\x57\xa8\xb5\x89\xb7\x70\xc7\x9e\xa0\xeb\xb4\xee\xc5\xb1\xd5\xa2\x9a\xb7\x8d\x7c\x8b\x18\xb3\x82\x7b\xb2\x56\x34\xc4\xf1\x74\x56\x2a\x6d\xc4\xaf\xa8\xd4\x15\x55\x38\x48\xb7\x5f\xea\xff\x7f\x4b\x8f\x98\x89\x9e\xce\x88\xba\x11\x0a\xb5\x34\xd6\xad\x20\x2c\x0e\x7b\x52\x30\x24\x2a\xec\x44\xf9\x82\xda\xe8\x0e\xd6\x69\x43\x09\x5f\x1b\xf4\xc3\xae\x95\x76\x4f\xc6\xc6\x72\x81\x4e\xf4\xfc\x44\x65\xcc\x37\xf5\xa2\xf4\x41\x4d\x22\x21\x2d\x0c\xb9\xc1\x28\x1a\x9f\x3a\x31\xda\x24\x51\x0f\x0e\x5a\x11\x67\xf5\xa0\x4a\xe4\xde\x4f\x39\x0b\x01\xb7\x17\xad\x2c\xce\x8c\xc9\x3b\xfb\x53\x2f\x56\x10\x65\x32\x6d\x3e\x8f\x2e\xfd\x97\x8e\x0a\x90\x31\xda\xc0\x33\xef\x21\x6b\xf0\x07\x21\x8d\x45\x08\xd9\xd4\xac\x55\x2c\x88\xc9\x9e\xff\x1d\xfb\xc5\xef\xd2\x75\x7e\x71\xea\x63\xf3\x36\xe8\x6c\xc3\xe3\x6a\x85\x3b\xe1\xc4\xb8\x7f\x18\x8d\x08\x05\xcb\x64\x9d\x20\xff\xd9\x42\xc9\x16\x9e\x65\xd9\xd8\x82\x78\xca\xb2\xc0\x58\x66\x0f\xd2\x95\xfb\xc5\xbc\xcd\x76\x76\x8e\x47\x40\x9e\xc7\x30\x14\xa3\x64\x23\x54\x43\xb8\x79\x5e\xe1\xa6\x17\x32\x09\x3d\x3d\xa1\x14\x96\xe0\x7d\xba\x49\x8a\xc6\xe4\x6d\x4e\x94\x67\x3d\xe5\x73\xe1\x7d\x4a\x1e\xff\x34\xa8\x72\xac\xcf\x27\x21\x73\x1b\x0b\xe6\x3d\xa9\x2a\x84\x2c\x2c\xab\x09\xef\x97\x4e\xb3\xc0\xd8\x9b\x30\x78\x5e\xfa\x18\x3d\xc0\x16\xf9\x95\x95\xb9\x92\x6d\x71\x6d\x46\x0c\xd7\x57\x6a\xa4\x75\x64\x2e\xca\x27\xf4\x98\xa2\xf1\xd6\x2e\xd3\x62\xe7\x14\x4f\xea\xd3\x5e\xb4\xed\x29\x63\xa7\xd9\x8c\xf8\xd7\xe3\x19\x13\xf8\xde\x7c\x25\xa7\x47\x31\xe6\x38\x65\xf3\x43\x6d\xb9\x99\x8a\x71\xfb\x2e\xc6\xef\x38\x39\x43\xf0\x99\x29\xf7\xfe\x75\xd0\x8e\xc6\xe2\x89\x71\xf5\x8e\xd9\x3e\x2c\x76\x05\x36\xaa\x95\x4a\xba\x3c\xb2\x66\x1f\xeb\xbe\x50\x6b\x0b\x16\x98\xf7\xa4\xaa\x10\xd8\x8f\x01\x00\x0e\x2f\xf7\x5a\xf4\x05\x00\x00"),
+          path: "saga.tml",
+          root: "saga.tml",
+        },
+      
     
   }
 )
@@ -0,0 +1,142 @@
+package cqrskit
+
+import (
+	"errors"
+	"sync"
+)
+
+// errors ...
+var (
+	ErrNoEventEncoder = errors.New("encoder does not implement cqrskit.EventEncoder")
+	ErrNoEventDecoder = errors.New("decoder does not implement cqrskit.EventDecoder")
+)
+
+//*******************************************************************************
+// Per-Event Publishing
+//*******************************************************************************
+
+// NamespaceFunc defines a function type which returns the namespace a single event of
+// the giving type should be published to, using the namespace of it's commit.
+type NamespaceFunc func(ns string, eventType string) string
+
+// EventNamespace implements the NamespaceFunc by returning the namespace of the commit
+// with the event type appended to it, eg users.events.UserCreated.
+func EventNamespace(ns string, eventType string) string {
+	return ns + "." + eventType
+}
+
+// SplitCommit returns a slice of EventMessages for each event within provided EventCommit,
+// where each carries the details of the commit with the index of said event.
+func SplitCommit(commit EventCommit) []EventMessage {
+	messages := make([]EventMessage, 0, len(commit.Events))
+	for index, event := range commit.Events {
+		messages = append(messages, EventMessage{
+			Event:       event,
+			Index:       index,
+			Total:       len(commit.Events),
+			Version:     commit.Version,
+			CommitID:    commit.CommitID,
+			InstanceID:  commit.InstanceID,
+			AggregateID: commit.AggregateID,
+		})
+	}
+	return messages
+}
+
+// PerEventPublisher returns a Publisher which splits every EventCommit into it's individual
+// events, publishing each as a EventMessage through the provided EventPublisher into the
+// namespace returned by the NamespaceFunc for said event's type. If no NamespaceFunc is provided
+// then EventNamespace is used.
+func PerEventPublisher(publisher EventPublisher, namer NamespaceFunc) Publisher {
+	if namer == nil {
+		namer = EventNamespace
+	}
+
+	return perEventPublisher{publisher: publisher, namer: namer}
+}
+
+// perEventPublisher implements the Publisher interface, delivering each event of a
+// EventCommit as it's own message.
+type perEventPublisher struct {
+	namer     NamespaceFunc
+	publisher EventPublisher
+}
+
+// Publish implements the Publisher interface, where the giving AckHandler is only called
+// once all events of the commit have being acknowledged, with the PubAck of each event
+// set as it's Response in order of the event index. If any event fails to be published
+// then the error is returned and the commit is never acknowledged.
+func (pe perEventPublisher) Publish(ns string, commit EventCommit, fn AckHandler) error {
+	messages := SplitCommit(commit)
+	ack := newCommitAck(ns, commit, len(messages), fn)
+
+	if len(messages) == 0 {
+		ack.done()
+		return nil
+	}
+
+	for _, message := range messages {
+		index := message.Index
+		if err := pe.publisher.PublishEvent(pe.namer(ns, message.Event.Type), message, func(part PubAck) {
+			ack.ack(index, part)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitAck collects the acknowledgement of the events of a EventCommit, calling
+// it's AckHandler once all have arrived.
+type commitAck struct {
+	ns       string
+	commit   EventCommit
+	fn       AckHandler
+	ml       sync.Mutex
+	received int
+	parts    []PubAck
+	acked    []bool
+}
+
+func newCommitAck(ns string, commit EventCommit, total int, fn AckHandler) *commitAck {
+	return &commitAck{
+		ns:     ns,
+		fn:     fn,
+		commit: commit,
+		parts:  make([]PubAck, total),
+		acked:  make([]bool, total),
+	}
+}
+
+// ack records the acknowledgement of the event at the giving index, ignoring
+// repeated acknowledgements of the same event.
+func (ca *commitAck) ack(index int, part PubAck) {
+	ca.ml.Lock()
+	if ca.acked[index] {
+		ca.ml.Unlock()
+		return
+	}
+
+	ca.acked[index] = true
+	ca.parts[index] = part
+	ca.received++
+	completed := ca.received == len(ca.parts)
+	ca.ml.Unlock()
+
+	if completed {
+		ca.done()
+	}
+}
+
+// done calls the AckHandler for the commit.
+func (ca *commitAck) done() {
+	ca.fn(PubAck{
+		Response:    ca.parts,
+		Namespace:   ca.ns,
+		Version:     ca.commit.Version,
+		CommitID:    ca.commit.CommitID,
+		InstanceID:  ca.commit.InstanceID,
+		AggregateID: ca.commit.AggregateID,
+	})
+}
@@ -0,0 +1,333 @@
+package inproc
+
+import (
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gokit/cqrskit"
+)
+
+// errors ...
+var (
+	ErrBusClosed      = errors.New("event bus is closed")
+	ErrBusFull        = errors.New("event bus queue is full")
+	ErrInvalidPattern = errors.New("subscription pattern is invalid")
+)
+
+// Handler defines a function type which is called with every EventCommit published
+// into a namespace matching it's subscription. A returned error stops the commit from
+// being acknowledged.
+type Handler func(ns string, commit cqrskit.EventCommit) error
+
+// ErrorHandler defines a function type which is called when a Handler fails for a commit
+// delivered asynchronously.
+type ErrorHandler func(ns string, commit cqrskit.EventCommit, err error)
+
+//*******************************************************************************
+// Subscriptions
+//*******************************************************************************
+
+// Subscription embodies a Handler registered for all namespaces matching a pattern.
+type Subscription struct {
+	bus     *Bus
+	fn      Handler
+	pattern []string
+}
+
+// Unsubscribe removes the subscription from it's bus, where it will no longer receive
+// published commits.
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
+
+// matches returns true/false if the namespace matches the subscription pattern, where
+// like NATS subjects, a '*' token matches any single token and a '>' token as the last
+// token matches one or more remaining tokens.
+func (s *Subscription) matches(ns []string) bool {
+	for index, token := range s.pattern {
+		if token == ">" {
+			return len(ns) > index
+		}
+
+		if index >= len(ns) {
+			return false
+		}
+
+		if token != "*" && token != ns[index] {
+			return false
+		}
+	}
+
+	return len(ns) == len(s.pattern)
+}
+
+// parsePattern returns the tokens of the pattern, validating that no token is empty
+// and '>' only appears as the last token.
+func parsePattern(pattern string) ([]string, error) {
+	tokens := strings.Split(pattern, ".")
+	for index, token := range tokens {
+		if token == "" {
+			return nil, ErrInvalidPattern
+		}
+
+		if token == ">" && index != len(tokens)-1 {
+			return nil, ErrInvalidPattern
+		}
+	}
+	return tokens, nil
+}
+
+//*******************************************************************************
+// Bus
+//*******************************************************************************
+
+// delivery embodies a commit waiting to be handed to subscribers.
+type delivery struct {
+	ns     string
+	commit cqrskit.EventCommit
+	fn     cqrskit.AckHandler
+}
+
+// Bus implements the cqrskit.Publisher by delivering commits to Go handlers subscribed
+// within the same process, with no broker in between.
+//
+// A synchronous Bus calls all matching handlers within Publish, acknowledging the commit once
+// all succeed, else returning the error of the first to fail. An asynchronous Bus queues commits
+// into a pool of workers, where commits of the same InstanceID always go to the same worker, which
+// keeps their order. Publish blocks once the queue of a worker is full, up to PublishTimeout if set,
+// providing back-pressure to publishers. Handlers publishing back into the bus, as sagas do, must use
+// TryPublish or the Publisher returned by NonBlocking, as their own worker could be the one to wait on:
+// they get ErrBusFull at once if the queue is full.
+type Bus struct {
+	PublishTimeout time.Duration
+	OnError        ErrorHandler
+
+	sl   sync.RWMutex
+	subs []*Subscription
+
+	cl      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	queues  []chan delivery
+	workers sync.WaitGroup
+	senders sync.WaitGroup
+}
+
+// NewBus returns a new instance of a synchronous Bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// NewAsyncBus returns a new instance of a asynchronous Bus, delivering commits using
+// the provided number of workers, each with a queue of buffer size.
+func NewAsyncBus(workers int, buffer int) *Bus {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	bus := &Bus{
+		done:   make(chan struct{}),
+		queues: make([]chan delivery, workers),
+	}
+
+	for index := range bus.queues {
+		bus.queues[index] = make(chan delivery, buffer)
+
+		bus.workers.Add(1)
+		go bus.work(index)
+	}
+
+	return bus
+}
+
+// Subscribe registers the Handler to receive all commits published into namespaces
+// matching the pattern, eg users.events, users.* or users.>.
+func (b *Bus) Subscribe(pattern string, fn Handler) (*Subscription, error) {
+	tokens, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &Subscription{bus: b, fn: fn, pattern: tokens}
+
+	b.sl.Lock()
+	b.subs = append(b.subs, sub)
+	b.sl.Unlock()
+
+	return sub, nil
+}
+
+// unsubscribe removes the subscription from the bus.
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.sl.Lock()
+	defer b.sl.Unlock()
+
+	for index, item := range b.subs {
+		if item != sub {
+			continue
+		}
+
+		subs := make([]*Subscription, 0, len(b.subs)-1)
+		subs = append(subs, b.subs[:index]...)
+		b.subs = append(subs, b.subs[index+1:]...)
+		return
+	}
+}
+
+// Publish implements the cqrskit.Publisher interface. The acknowledged function is called
+// with the number of handlers which received the commit as the Response.
+func (b *Bus) Publish(ns string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	return b.publish(delivery{ns: ns, commit: commit, fn: fn}, true)
+}
+
+// TryPublish publishes the commit like Publish, except it never waits on a full queue,
+// returning ErrBusFull at once instead. It's meant for handlers publishing back into the
+// bus, which would otherwise wait on their own worker.
+func (b *Bus) TryPublish(ns string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	return b.publish(delivery{ns: ns, commit: commit, fn: fn}, false)
+}
+
+// NonBlocking returns a cqrskit.Publisher publishing into the bus through TryPublish, giving
+// handlers, like sagas, a Publisher which can't block their worker.
+func (b *Bus) NonBlocking() cqrskit.Publisher {
+	return nonBlocking{bus: b}
+}
+
+// publish queues the delivery into the queue of it's worker, waiting on a full queue if
+// wait is true.
+func (b *Bus) publish(next delivery, wait bool) error {
+
+	b.cl.RLock()
+	if b.closed {
+		b.cl.RUnlock()
+		return ErrBusClosed
+	}
+
+	if len(b.queues) == 0 {
+		b.cl.RUnlock()
+		return b.deliver(next)
+	}
+
+	queue := b.queues[b.worker(next.commit.InstanceID)]
+
+	select {
+	case queue <- next:
+		b.cl.RUnlock()
+		return nil
+	default:
+	}
+
+	if !wait {
+		b.cl.RUnlock()
+		return ErrBusFull
+	}
+
+	// The queue is full, so the lock is released while waiting, letting Close end the
+	// wait through done before it closes the queues.
+	b.senders.Add(1)
+	defer b.senders.Done()
+	b.cl.RUnlock()
+
+	var timeout <-chan time.Time
+	if b.PublishTimeout > 0 {
+		timer := time.NewTimer(b.PublishTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case queue <- next:
+		return nil
+	case <-timeout:
+		return ErrBusFull
+	case <-b.done:
+		return ErrBusClosed
+	}
+}
+
+// Close stops the bus from accepting new commits, waiting till all queued commits are
+// delivered. Publishes waiting on a full queue fail with ErrBusClosed.
+func (b *Bus) Close() error {
+	b.cl.Lock()
+	if b.closed {
+		b.cl.Unlock()
+		return nil
+	}
+
+	b.closed = true
+	if b.done != nil {
+		close(b.done)
+	}
+	b.cl.Unlock()
+
+	b.senders.Wait()
+	for _, queue := range b.queues {
+		close(queue)
+	}
+
+	b.workers.Wait()
+	return nil
+}
+
+// work delivers all commits of the queue of the worker till it is closed.
+func (b *Bus) work(index int) {
+	defer b.workers.Done()
+
+	for next := range b.queues[index] {
+		if err := b.deliver(next); err != nil && b.OnError != nil {
+			b.OnError(next.ns, next.commit, err)
+		}
+	}
+}
+
+// worker returns the index of the worker queue for the instanceID.
+func (b *Bus) worker(instanceID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(instanceID))
+	return int(hash.Sum32() % uint32(len(b.queues)))
+}
+
+// deliver calls all handlers matching the namespace of the delivery, acknowledging
+// it once all succeed.
+func (b *Bus) deliver(next delivery) error {
+	ns := strings.Split(next.ns, ".")
+
+	b.sl.RLock()
+	handlers := make([]Handler, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if sub.matches(ns) {
+			handlers = append(handlers, sub.fn)
+		}
+	}
+	b.sl.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(next.ns, next.commit); err != nil {
+			return err
+		}
+	}
+
+	next.fn(cqrskit.PubAck{
+		Response:    len(handlers),
+		Namespace:   next.ns,
+		Version:     next.commit.Version,
+		CommitID:    next.commit.CommitID,
+		InstanceID:  next.commit.InstanceID,
+		AggregateID: next.commit.AggregateID,
+	})
+
+	return nil
+}
+
+// nonBlocking implements the cqrskit.Publisher through the TryPublish of it's bus.
+type nonBlocking struct {
+	bus *Bus
+}
+
+// Publish implements the cqrskit.Publisher interface.
+func (n nonBlocking) Publish(ns string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	return n.bus.TryPublish(ns, commit, fn)
+}
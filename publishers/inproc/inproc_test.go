@@ -0,0 +1,278 @@
+package inproc_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/gokit/cqrskit/publishers/inproc"
+)
+
+func TestBus(t *testing.T) {
+	bus := inproc.NewBus()
+
+	received := map[string][]string{}
+	for _, pattern := range []string{"users.events", "users.*", "users.>", "*.events.created", "orders.>"} {
+		pattern := pattern
+		if _, err := bus.Subscribe(pattern, func(ns string, commit cqrskit.EventCommit) error {
+			received[pattern] = append(received[pattern], ns)
+			return nil
+		}); err != nil {
+			tests.FailedWithError(err, "Should have successfully subscribed to pattern")
+		}
+	}
+	tests.Passed("Should have successfully subscribed to patterns")
+
+	if _, err := bus.Subscribe("users.>.created", func(string, cqrskit.EventCommit) error { return nil }); err != inproc.ErrInvalidPattern {
+		tests.Failed("Should have rejected pattern with '>' before last token")
+	}
+	tests.Passed("Should have rejected pattern with '>' before last token")
+
+	var acks []cqrskit.PubAck
+	for _, ns := range []string{"users.events", "users.events.created", "accounts.events.created"} {
+		if err := bus.Publish(ns, cqrskit.EventCommit{}, func(ack cqrskit.PubAck) {
+			acks = append(acks, ack)
+		}); err != nil {
+			tests.FailedWithError(err, "Should have successfully published event commit")
+		}
+	}
+	tests.Passed("Should have successfully published event commits")
+
+	if len(acks) != 3 || acks[0].Response != 3 || acks[1].Response != 2 || acks[2].Response != 1 {
+		tests.Info("Received: %+v", acks)
+		tests.Failed("Should have acknowledged commits with number of receiving handlers")
+	}
+	tests.Passed("Should have acknowledged commits with number of receiving handlers")
+
+	if len(received["users.>"]) != 2 || len(received["users.*"]) != 1 || len(received["orders.>"]) != 0 {
+		tests.Info("Received: %+v", received)
+		tests.Failed("Should have matched namespaces with wildcards")
+	}
+	tests.Passed("Should have matched namespaces with wildcards")
+
+	sub, err := bus.Subscribe("orders.events", func(string, cqrskit.EventCommit) error {
+		return errors.New("failed to handle commit")
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully subscribed to pattern")
+	}
+
+	if err := bus.Publish("orders.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {
+		tests.Failed("Should not have acknowledged failed commit")
+	}); err == nil {
+		tests.Failed("Should have returned error of failed handler")
+	}
+	tests.Passed("Should have returned error of failed handler")
+
+	sub.Unsubscribe()
+
+	if err := bus.Publish("orders.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published after unsubscribing")
+	}
+	tests.Passed("Should have successfully published after unsubscribing")
+}
+
+func TestAsyncBus(t *testing.T) {
+	bus := inproc.NewAsyncBus(4, 8)
+
+	var ml sync.Mutex
+	versions := map[string][]int{}
+
+	bus.Subscribe("users.>", func(ns string, commit cqrskit.EventCommit) error {
+		ml.Lock()
+		defer ml.Unlock()
+		versions[commit.InstanceID] = append(versions[commit.InstanceID], commit.Version)
+		return nil
+	})
+
+	var acks int
+	for version := 0; version < 50; version++ {
+		for _, instanceID := range []string{"instance-a", "instance-b", "instance-c"} {
+			if err := bus.Publish("users.events", cqrskit.EventCommit{InstanceID: instanceID, Version: version}, func(cqrskit.PubAck) {
+				ml.Lock()
+				acks++
+				ml.Unlock()
+			}); err != nil {
+				tests.FailedWithError(err, "Should have successfully published event commit")
+			}
+		}
+	}
+	tests.Passed("Should have successfully published event commits")
+
+	if err := bus.Close(); err != nil {
+		tests.FailedWithError(err, "Should have successfully closed bus")
+	}
+	tests.Passed("Should have successfully closed bus")
+
+	if acks != 150 {
+		tests.Info("Received: %d", acks)
+		tests.Failed("Should have delivered all queued commits before closing")
+	}
+	tests.Passed("Should have delivered all queued commits before closing")
+
+	for instanceID, received := range versions {
+		for index, version := range received {
+			if version != index {
+				tests.Info("Instance: %q", instanceID)
+				tests.Failed("Should have delivered commits of instance in order")
+			}
+		}
+	}
+	tests.Passed("Should have delivered commits of instance in order")
+
+	if err := bus.Publish("users.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {}); err != inproc.ErrBusClosed {
+		tests.Failed("Should have rejected commit after closing")
+	}
+	tests.Passed("Should have rejected commit after closing")
+}
+
+func TestAsyncBusBackPressure(t *testing.T) {
+	bus := inproc.NewAsyncBus(1, 1)
+	bus.PublishTimeout = 20 * time.Millisecond
+
+	failed := make(chan error, 2)
+	bus.OnError = func(ns string, commit cqrskit.EventCommit, err error) {
+		failed <- err
+	}
+
+	release := make(chan struct{})
+	bus.Subscribe("users.events", func(ns string, commit cqrskit.EventCommit) error {
+		<-release
+		return errors.New("failed to handle commit")
+	})
+
+	// first commit is held by the worker, second fills the queue.
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish("users.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {}); err != nil {
+			tests.FailedWithError(err, "Should have successfully queued event commit")
+		}
+	}
+	tests.Passed("Should have successfully queued event commits")
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := bus.Publish("users.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {}); err != inproc.ErrBusFull {
+		tests.Failed("Should have failed to publish into full queue")
+	}
+	tests.Passed("Should have failed to publish into full queue")
+
+	close(release)
+
+	if err := <-failed; err == nil {
+		tests.Failed("Should have reported error of failed handler")
+	}
+	tests.Passed("Should have reported error of failed handler")
+
+	bus.Close()
+}
+
+func TestAsyncBusRepublish(t *testing.T) {
+	bus := inproc.NewAsyncBus(1, 1)
+
+	release := make(chan struct{})
+	republished := make(chan error, 2)
+	sagas := make(chan string, 2)
+
+	publisher := bus.NonBlocking()
+	bus.Subscribe("users.events", func(ns string, commit cqrskit.EventCommit) error {
+		<-release
+		republished <- publisher.Publish("users.saga", cqrskit.EventCommit{CommitID: commit.CommitID}, func(cqrskit.PubAck) {})
+		return nil
+	})
+
+	bus.Subscribe("users.saga", func(ns string, commit cqrskit.EventCommit) error {
+		sagas <- commit.CommitID
+		return nil
+	})
+
+	// first commit is held by the worker, second fills the queue.
+	for _, id := range []string{"commit-1", "commit-2"} {
+		if err := bus.Publish("users.events", cqrskit.EventCommit{CommitID: id}, func(cqrskit.PubAck) {}); err != nil {
+			tests.FailedWithError(err, "Should have successfully queued event commit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tests.Passed("Should have successfully queued event commits")
+
+	close(release)
+
+	select {
+	case err := <-republished:
+		if err != inproc.ErrBusFull {
+			tests.Info("Received: %+v", err)
+			tests.Failed("Should have failed republish into full queue of own worker")
+		}
+	case <-time.After(time.Second):
+		tests.Failed("Should have failed republish into full queue of own worker without blocking")
+	}
+	tests.Passed("Should have failed republish into full queue of own worker without blocking")
+
+	if err := <-republished; err != nil {
+		tests.FailedWithError(err, "Should have successfully republished once queue had room")
+	}
+	tests.Passed("Should have successfully republished once queue had room")
+
+	if id := <-sagas; id != "commit-2" {
+		tests.Info("Received: %q", id)
+		tests.Failed("Should have delivered republished commit")
+	}
+	tests.Passed("Should have delivered republished commit")
+
+	bus.Close()
+}
+
+func TestAsyncBusCloseWithBlockedPublish(t *testing.T) {
+	bus := inproc.NewAsyncBus(1, 1)
+
+	release := make(chan struct{})
+	bus.Subscribe("users.events", func(ns string, commit cqrskit.EventCommit) error {
+		<-release
+		return nil
+	})
+
+	// first commit is held by the worker, second fills the queue.
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish("users.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {}); err != nil {
+			tests.FailedWithError(err, "Should have successfully queued event commit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tests.Passed("Should have successfully queued event commits")
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- bus.Publish("users.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {})
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		bus.Close()
+		close(closed)
+	}()
+
+	select {
+	case err := <-blocked:
+		if err != inproc.ErrBusClosed {
+			tests.Info("Received: %+v", err)
+			tests.Failed("Should have failed blocked publish once bus closed")
+		}
+	case <-time.After(time.Second):
+		tests.Failed("Should have failed blocked publish once bus closed")
+	}
+	tests.Passed("Should have failed blocked publish once bus closed")
+
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		tests.Failed("Should have closed bus once queued commits were delivered")
+	}
+	tests.Passed("Should have closed bus once queued commits were delivered")
+}
@@ -0,0 +1,334 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gokit/cqrskit"
+
+	"github.com/Shopify/sarama"
+)
+
+// errors ...
+var (
+	ErrClosed = errors.New("publisher is closed")
+)
+
+// consts of record headers set on every published record.
+const (
+	KindHeader        = "cqrskit-kind"
+	CommitIDHeader    = "cqrskit-commit-id"
+	VersionHeader     = "cqrskit-version"
+	InstanceIDHeader  = "cqrskit-instance-id"
+	AggregateIDHeader = "cqrskit-aggregate-id"
+	EventTypeHeader   = "cqrskit-event-type"
+	EventIndexHeader  = "cqrskit-event-index"
+)
+
+// consts of values for the KindHeader of a record.
+const (
+	CommitKind = "commit"
+	EventKind  = "event"
+)
+
+// NewConfig returns a new sarama.Config with the settings for an idempotent producer,
+// where records are written exactly once and in order into a partition. It is also
+// usable for consumer groups.
+func NewConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_11_0_0
+	config.Net.MaxOpenRequests = 1
+	config.Producer.Idempotent = true
+	config.Producer.Retry.Max = 10
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	return config
+}
+
+//*******************************************************************************
+// Kafka Publisher
+//*******************************************************************************
+
+// RecordPosition embodies the partition and offset a published record was written to,
+// it is set as the Response of every PubAck.
+type RecordPosition struct {
+	Partition int32 `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// KafkaPublisher implements the cqrskit.Publisher using kafka as the underline
+// transport, where each namespace is a kafka topic. Records are keyed by the
+// InstanceID of their commit, so all commits of an aggregate instance land on the
+// same partition in order.
+type KafkaPublisher struct {
+	encoder  cqrskit.Encoder
+	pl       sync.RWMutex
+	producer sarama.SyncProducer
+}
+
+// KafkaPublisherFrom returns a new instance of KafkaPublisher using the provided
+// sarama.SyncProducer instance.
+func KafkaPublisherFrom(producer sarama.SyncProducer, encoder cqrskit.Encoder) *KafkaPublisher {
+	return &KafkaPublisher{
+		encoder:  encoder,
+		producer: producer,
+	}
+}
+
+// NewKafkaPublisher returns a new instance of KafkaPublisher connected to the provided
+// brokers. If no config is provided then NewConfig is used.
+func NewKafkaPublisher(addrs []string, config *sarama.Config, encoder cqrskit.Encoder) (*KafkaPublisher, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+
+	producer, err := sarama.NewSyncProducer(addrs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return KafkaPublisherFrom(producer, encoder), nil
+}
+
+// Publish implements the cqrskit.Publisher and sends the commit into the kafka topic
+// and calls acknowledged function with the RecordPosition of the commit if successful
+// in both the encoding of the commit and it's delivery into kafka, else returning an
+// error of why.
+func (kp *KafkaPublisher) Publish(topic string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	commitBytes, err := kp.encoder.Encode(commit)
+	if err != nil {
+		return err
+	}
+
+	headers := recordHeaders(CommitKind, commit.CommitID, commit.AggregateID, commit.InstanceID, commit.Version)
+	position, err := kp.send(topic, commit.InstanceID, commitBytes, headers)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    position,
+		Namespace:   topic,
+		Version:     commit.Version,
+		CommitID:    commit.CommitID,
+		InstanceID:  commit.InstanceID,
+		AggregateID: commit.AggregateID,
+	})
+
+	return nil
+}
+
+// PublishEvent implements the cqrskit.EventPublisher and sends a single event split from
+// a commit into the kafka topic, it is used with cqrskit.PerEventPublisher to have each event
+// published to a topic for it's type.
+func (kp *KafkaPublisher) PublishEvent(topic string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	msgBytes, err := cqrskit.EncodeEvent(kp.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	headers := recordHeaders(EventKind, msg.CommitID, msg.AggregateID, msg.InstanceID, msg.Version)
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(EventTypeHeader), Value: []byte(msg.Event.Type)},
+		sarama.RecordHeader{Key: []byte(EventIndexHeader), Value: []byte(strconv.Itoa(msg.Index))},
+	)
+
+	position, err := kp.send(topic, msg.InstanceID, msgBytes, headers)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    position,
+		Namespace:   topic,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
+
+// send delivers the record keyed by the instanceID into the topic, returning ErrClosed
+// once the publisher is closed. The read lock is held while sending, as closing the
+// producer closes the channel records are sent into.
+func (kp *KafkaPublisher) send(topic string, instanceID string, data []byte, headers []sarama.RecordHeader) (RecordPosition, error) {
+	kp.pl.RLock()
+	defer kp.pl.RUnlock()
+
+	if kp.producer == nil {
+		return RecordPosition{}, ErrClosed
+	}
+
+	partition, offset, err := kp.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   topic,
+		Headers: headers,
+		Key:     sarama.StringEncoder(instanceID),
+		Value:   sarama.ByteEncoder(data),
+	})
+	if err != nil {
+		return RecordPosition{}, err
+	}
+
+	return RecordPosition{Partition: partition, Offset: offset}, nil
+}
+
+// Close ends the underline kafka producer once all records being sent are delivered, after
+// which all publishing fails with ErrClosed.
+func (kp *KafkaPublisher) Close() error {
+	kp.pl.Lock()
+	defer kp.pl.Unlock()
+
+	if kp.producer == nil {
+		return nil
+	}
+
+	err := kp.producer.Close()
+	kp.producer = nil
+	return err
+}
+
+// recordHeaders returns the headers carrying the commit metadata of a record.
+func recordHeaders(kind string, commitID string, aggregateID string, instanceID string, version int) []sarama.RecordHeader {
+	return []sarama.RecordHeader{
+		{Key: []byte(KindHeader), Value: []byte(kind)},
+		{Key: []byte(CommitIDHeader), Value: []byte(commitID)},
+		{Key: []byte(AggregateIDHeader), Value: []byte(aggregateID)},
+		{Key: []byte(InstanceIDHeader), Value: []byte(instanceID)},
+		{Key: []byte(VersionHeader), Value: []byte(strconv.Itoa(version))},
+	}
+}
+
+//*******************************************************************************
+// Kafka Consumer Group Subscriber
+//*******************************************************************************
+
+// Handler defines a function type which is called with every EventCommit received
+// from a kafka topic.
+type Handler func(cqrskit.EventCommit) error
+
+// GroupHandler implements the sarama.ConsumerGroupHandler, decoding every record of a
+// claimed partition into a EventCommit for a Handler. The offset of a record is only marked
+// for commit once the Handler succeeds, else the Handler is retried every RetryBackoff till
+// it does or the session ends, which keeps the order of commits within a partition.
+// Records which can not be decoded are marked, as no amount of retries will change their
+// outcome.
+type GroupHandler struct {
+	RetryBackoff time.Duration
+
+	fn      Handler
+	decoder cqrskit.Decoder
+}
+
+// NewGroupHandler returns a new instance of GroupHandler.
+func NewGroupHandler(decoder cqrskit.Decoder, fn Handler) *GroupHandler {
+	return &GroupHandler{
+		fn:           fn,
+		decoder:      decoder,
+		RetryBackoff: time.Second,
+	}
+}
+
+// Setup implements the sarama.ConsumerGroupHandler interface.
+func (gh *GroupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup implements the sarama.ConsumerGroupHandler interface.
+func (gh *GroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim implements the sarama.ConsumerGroupHandler interface.
+func (gh *GroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for record := range claim.Messages() {
+		commit, err := gh.decoder.Decode(record.Value)
+		if err != nil {
+			session.MarkMessage(record, "")
+			continue
+		}
+
+		if err := gh.handle(session.Context(), commit); err != nil {
+			return err
+		}
+
+		session.MarkMessage(record, "")
+	}
+	return nil
+}
+
+// handle calls the Handler with the commit till it succeeds or the context ends.
+func (gh *GroupHandler) handle(ctx context.Context, commit cqrskit.EventCommit) error {
+	for {
+		if err := gh.fn(commit); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(gh.RetryBackoff):
+		}
+	}
+}
+
+// KafkaSubscriber delivers commits from kafka topics to a Handler as a member of a
+// consumer group.
+type KafkaSubscriber struct {
+	*GroupHandler
+	topics []string
+	group  sarama.ConsumerGroup
+}
+
+// KafkaSubscriberFrom returns a new instance of KafkaSubscriber using the provided
+// sarama.ConsumerGroup instance.
+func KafkaSubscriberFrom(group sarama.ConsumerGroup, topics []string, decoder cqrskit.Decoder, fn Handler) *KafkaSubscriber {
+	return &KafkaSubscriber{
+		group:        group,
+		topics:       topics,
+		GroupHandler: NewGroupHandler(decoder, fn),
+	}
+}
+
+// NewKafkaSubscriber returns a new instance of KafkaSubscriber joining the consumer group
+// through the provided brokers. If no config is provided then NewConfig is used.
+func NewKafkaSubscriber(addrs []string, groupID string, topics []string, config *sarama.Config, decoder cqrskit.Decoder, fn Handler) (*KafkaSubscriber, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+
+	group, err := sarama.NewConsumerGroup(addrs, groupID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return KafkaSubscriberFrom(group, topics, decoder, fn), nil
+}
+
+// Subscribe consumes the topics as a member of the consumer group until the context
+// is cancelled or the group returns an error. Consumption restarts after every rebalance.
+func (ks *KafkaSubscriber) Subscribe(ctx context.Context) error {
+	for {
+		if err := ks.group.Consume(ctx, ks.topics, ks.GroupHandler); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+}
+
+// Close leaves the consumer group and ends the underline connections.
+func (ks *KafkaSubscriber) Close() error {
+	return ks.group.Close()
+}
@@ -0,0 +1,308 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/Shopify/sarama"
+
+	pubkafka "github.com/gokit/cqrskit/publishers/kafka"
+)
+
+func TestKafkaPublisher(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("users.events", 0, broker.BrokerID()).
+			SetLeader("users.events", 1, broker.BrokerID()).
+			SetLeader("users.events", 2, broker.BrokerID()),
+		"InitProducerIDRequest": sarama.NewMockWrapper(&sarama.InitProducerIDResponse{
+			ProducerID: 1000,
+		}),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	config := pubkafka.NewConfig()
+	config.Producer.Retry.Backoff = 0
+
+	publisher, err := pubkafka.NewKafkaPublisher([]string{broker.Addr()}, config, cqrskit.JSONEncoder{})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created idempotent publisher")
+	}
+	tests.Passed("Should have successfully created idempotent publisher")
+
+	defer publisher.Close()
+
+	partitions := map[string]map[int32]bool{}
+	for version, instanceID := range []string{"instance-a", "instance-b", "instance-a", "instance-c", "instance-a", "instance-b"} {
+		commit := cqrskit.EventCommit{InstanceID: instanceID, Version: version}
+		if err := publisher.Publish("users.events", commit, func(ack cqrskit.PubAck) {
+			position, ok := ack.Response.(pubkafka.RecordPosition)
+			if !ok {
+				tests.Failed("Should have received record position in acknowledgement")
+			}
+
+			if partitions[ack.InstanceID] == nil {
+				partitions[ack.InstanceID] = map[int32]bool{}
+			}
+			partitions[ack.InstanceID][position.Partition] = true
+		}); err != nil {
+			tests.FailedWithError(err, "Should have successfully published event commit")
+		}
+	}
+	tests.Passed("Should have successfully published event commits")
+
+	if len(partitions) != 3 {
+		tests.Failed("Should have acknowledged commits of all instances")
+	}
+	tests.Passed("Should have acknowledged commits of all instances")
+
+	for instanceID, used := range partitions {
+		if len(used) != 1 {
+			tests.Info("Instance: %q", instanceID)
+			tests.Info("Partitions: %+v", used)
+			tests.Failed("Should have published all commits of instance into same partition")
+		}
+	}
+	tests.Passed("Should have published all commits of instance into same partition")
+}
+
+func TestKafkaPublisherRecord(t *testing.T) {
+	producer := &mockProducer{}
+	publisher := pubkafka.KafkaPublisherFrom(producer, cqrskit.JSONEncoder{})
+
+	commit := cqrskit.EventCommit{
+		Version:     2,
+		InstanceID:  "233JNIosd232",
+		AggregateID: "43543b2323I",
+		CommitID:    "433436577674674574567575675",
+	}
+
+	if err := publisher.Publish("users.events", commit, func(ack cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	record := producer.messages[0]
+	if key, _ := record.Key.Encode(); string(key) != commit.InstanceID {
+		tests.Info("Received: %q", key)
+		tests.Failed("Should have keyed record by instance id")
+	}
+	tests.Passed("Should have keyed record by instance id")
+
+	headers := map[string]string{}
+	for _, header := range record.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	if headers[pubkafka.CommitIDHeader] != commit.CommitID ||
+		headers[pubkafka.AggregateIDHeader] != commit.AggregateID ||
+		headers[pubkafka.InstanceIDHeader] != commit.InstanceID ||
+		headers[pubkafka.VersionHeader] != "2" ||
+		headers[pubkafka.KindHeader] != pubkafka.CommitKind {
+		tests.Info("Received: %+v", headers)
+		tests.Failed("Should have set commit metadata in record headers")
+	}
+	tests.Passed("Should have set commit metadata in record headers")
+}
+
+func TestKafkaPublisherClosed(t *testing.T) {
+	producer := &mockProducer{}
+	publisher := pubkafka.KafkaPublisherFrom(producer, cqrskit.JSONEncoder{})
+
+	if err := publisher.Close(); err != nil {
+		tests.FailedWithError(err, "Should have successfully closed publisher")
+	}
+	tests.Passed("Should have successfully closed publisher")
+
+	if err := publisher.Publish("users.events", cqrskit.EventCommit{}, func(ack cqrskit.PubAck) {}); err != pubkafka.ErrClosed {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected publishing commit after close")
+	}
+	tests.Passed("Should have rejected publishing commit after close")
+
+	if err := publisher.PublishEvent("users.events", cqrskit.EventMessage{}, func(ack cqrskit.PubAck) {}); err != pubkafka.ErrClosed {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected publishing event after close")
+	}
+	tests.Passed("Should have rejected publishing event after close")
+
+	if len(producer.messages) != 0 {
+		tests.Failed("Should have sent no records after close")
+	}
+	tests.Passed("Should have sent no records after close")
+}
+
+func TestKafkaPublisherCloseWhilePublishing(t *testing.T) {
+	producer := &closingProducer{input: make(chan *sarama.ProducerMessage, 1000)}
+	publisher := pubkafka.KafkaPublisherFrom(producer, cqrskit.JSONEncoder{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := publisher.Publish("users.events", cqrskit.EventCommit{CommitID: "433436577674674574567575675"}, func(ack cqrskit.PubAck) {})
+				if err != nil && err != pubkafka.ErrClosed {
+					tests.FailedWithError(err, "Should have either published or been rejected as closed")
+				}
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := publisher.Close(); err != nil {
+		tests.FailedWithError(err, "Should have successfully closed publisher")
+	}
+
+	wg.Wait()
+	tests.Passed("Should have closed publisher without failing records being sent")
+}
+
+func TestGroupHandler(t *testing.T) {
+	var calls int
+	handler := pubkafka.NewGroupHandler(cqrskit.JSONDecoder{}, func(commit cqrskit.EventCommit) error {
+		calls++
+		if commit.CommitID == "commit-1" && calls == 1 {
+			return errors.New("failed to handle commit")
+		}
+		return nil
+	})
+
+	handler.RetryBackoff = 5 * time.Millisecond
+
+	session := &mockSession{ctx: context.Background()}
+	claim := &mockClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+
+	for offset, id := range []string{"commit-1", "commit-2"} {
+		value, _ := cqrskit.JSONEncoder{}.Encode(cqrskit.EventCommit{CommitID: id})
+		claim.messages <- &sarama.ConsumerMessage{Offset: int64(offset), Value: value}
+	}
+
+	claim.messages <- &sarama.ConsumerMessage{Offset: 2, Value: []byte("{")}
+	close(claim.messages)
+
+	if err := handler.ConsumeClaim(session, claim); err != nil {
+		tests.FailedWithError(err, "Should have successfully consumed claim")
+	}
+	tests.Passed("Should have successfully consumed claim")
+
+	if calls != 3 {
+		tests.Info("Expected: %d", 3)
+		tests.Info("Received: %d", calls)
+		tests.Failed("Should have retried failed commit")
+	}
+	tests.Passed("Should have retried failed commit")
+
+	if len(session.marked) != 3 || session.marked[0] != 0 || session.marked[1] != 1 {
+		tests.Info("Received: %+v", session.marked)
+		tests.Failed("Should have marked offsets in order after handling")
+	}
+	tests.Passed("Should have marked offsets in order after handling")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	failing := pubkafka.NewGroupHandler(cqrskit.JSONDecoder{}, func(commit cqrskit.EventCommit) error {
+		return errors.New("failed to handle commit")
+	})
+
+	session = &mockSession{ctx: ctx}
+	claim = &mockClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+
+	value, _ := cqrskit.JSONEncoder{}.Encode(cqrskit.EventCommit{CommitID: "commit-1"})
+	claim.messages <- &sarama.ConsumerMessage{Offset: 0, Value: value}
+	close(claim.messages)
+
+	if err := failing.ConsumeClaim(session, claim); err == nil {
+		tests.Failed("Should have stopped consuming claim after session ended")
+	}
+	tests.Passed("Should have stopped consuming claim after session ended")
+
+	if len(session.marked) != 0 {
+		tests.Failed("Should not have marked offset of failed commit")
+	}
+	tests.Passed("Should not have marked offset of failed commit")
+}
+
+type mockProducer struct {
+	messages []*sarama.ProducerMessage
+}
+
+func (m *mockProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	m.messages = append(m.messages, msg)
+	return 0, int64(len(m.messages) - 1), nil
+}
+
+func (m *mockProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	m.messages = append(m.messages, msgs...)
+	return nil
+}
+
+func (m *mockProducer) Close() error {
+	return nil
+}
+
+// closingProducer closes the channel records are sent into on Close, as the sarama producer
+// does, so sending after it is closed panics.
+type closingProducer struct {
+	input chan *sarama.ProducerMessage
+}
+
+func (c *closingProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	c.input <- msg
+	time.Sleep(10 * time.Microsecond)
+	return 0, 0, nil
+}
+
+func (c *closingProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	for _, msg := range msgs {
+		c.input <- msg
+	}
+	return nil
+}
+
+func (c *closingProducer) Close() error {
+	close(c.input)
+	return nil
+}
+
+type mockSession struct {
+	ml     sync.Mutex
+	ctx    context.Context
+	marked []int64
+}
+
+func (m *mockSession) Claims() map[string][]int32 { return nil }
+func (m *mockSession) MemberID() string           { return "member-1" }
+func (m *mockSession) GenerationID() int32        { return 1 }
+func (m *mockSession) Context() context.Context   { return m.ctx }
+
+func (m *mockSession) MarkOffset(topic string, partition int32, offset int64, metadata string)  {}
+func (m *mockSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (m *mockSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+	m.marked = append(m.marked, msg.Offset)
+}
+
+type mockClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (m *mockClaim) Topic() string                            { return "users.events" }
+func (m *mockClaim) Partition() int32                         { return 0 }
+func (m *mockClaim) InitialOffset() int64                     { return 0 }
+func (m *mockClaim) HighWaterMarkOffset() int64               { return 0 }
+func (m *mockClaim) Messages() <-chan *sarama.ConsumerMessage { return m.messages }
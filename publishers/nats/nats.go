@@ -84,6 +84,35 @@ func (np *NATSPublisher) Publish(ns string, commit cqrskit.EventCommit, fn cqrsk
 	return nil
 }
 
+// PublishEvent implements the cqrskit.EventPublisher and sends a single event split from a
+// commit into the nats pubsub, it is used with cqrskit.PerEventPublisher to have each event
+// published to it's own subject.
+func (np *NATSPublisher) PublishEvent(ns string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	conn, err := np.getConnection()
+	if err != nil {
+		return err
+	}
+
+	msgBytes, err := cqrskit.EncodeEvent(np.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Publish(ns, msgBytes); err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Namespace:   ns,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
+
 // Close ends the underline nats connection.
 func (np *NATSPublisher) Close() error {
 	np.cl.Lock()
@@ -180,6 +209,35 @@ func (np *NATStreamingPublisher) Publish(ns string, commit cqrskit.EventCommit,
 	return nil
 }
 
+// PublishEvent implements the cqrskit.EventPublisher and sends a single event split from a
+// commit into the nats pubsub, it is used with cqrskit.PerEventPublisher to have each event
+// published to it's own subject.
+func (np *NATStreamingPublisher) PublishEvent(ns string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	conn, err := np.getConnection()
+	if err != nil {
+		return err
+	}
+
+	msgBytes, err := cqrskit.EncodeEvent(np.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Publish(ns, msgBytes); err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Namespace:   ns,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
+
 // Close ends the underline nats connection.
 func (np *NATStreamingPublisher) Close() error {
 	np.cl.Lock()
@@ -32,3 +32,27 @@ func TestNATStreamingPublisher(t *testing.T) {
 	}
 	tests.Passed("Should have successfully published event commit")
 }
+
+func TestNATSPublisherPerEvent(t *testing.T) {
+	publisher := pubnats.NewNATSPublisher(defaultURL, cqrskit.JSONEncoder{})
+	defer publisher.Close()
+
+	commit := cqrskit.EventCommit{
+		Events: []cqrskit.Event{
+			{Type: "UserCreated"},
+			{Type: "UserEmailUpdated"},
+		},
+	}
+
+	var acked int
+	perEvent := cqrskit.PerEventPublisher(publisher, nil)
+	if err := perEvent.Publish("users.events", commit, func(ack cqrskit.PubAck) { acked++ }); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	if acked != 1 {
+		tests.Failed("Should have acknowledged commit once all events are published")
+	}
+	tests.Passed("Should have acknowledged commit once all events are published")
+}
@@ -0,0 +1,359 @@
+package nsq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gokit/cqrskit"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+// errors ...
+var (
+	ErrClosed = errors.New("publisher is closed")
+)
+
+//*******************************************************************************
+// NSQ Publisher
+//*******************************************************************************
+
+// Producer defines the methods of a nsq.Producer used by the NSQPublisher to
+// deliver commits into nsqd.
+type Producer interface {
+	Stop()
+	Publish(topic string, body []byte) error
+	MultiPublish(topic string, body [][]byte) error
+	DeferredPublish(topic string, delay time.Duration, body []byte) error
+}
+
+// NSQPublisher implements the cqrskit.Publisher using nsq as the underline
+// transport, where each namespace is a nsq topic.
+type NSQPublisher struct {
+	encoder  cqrskit.Encoder
+	pl       sync.Mutex
+	producer Producer
+}
+
+// NSQPublisherFrom returns a new instance of NSQPublisher using the provided
+// Producer instance.
+func NSQPublisherFrom(producer Producer, encoder cqrskit.Encoder) *NSQPublisher {
+	return &NSQPublisher{
+		encoder:  encoder,
+		producer: producer,
+	}
+}
+
+// NewNSQPublisher returns a new instance of NSQPublisher which publishes into the
+// nsqd instance at the giving address.
+func NewNSQPublisher(addr string, config *nsq.Config, encoder cqrskit.Encoder) (*NSQPublisher, error) {
+	producer, err := nsq.NewProducer(addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NSQPublisherFrom(producer, encoder), nil
+}
+
+// Publish implements the cqrskit.Publisher and sends the commit into the nsq topic
+// and calls acknowledged function if successful in both the encoding of the commit and
+// it's delivery into nsqd, else returning an error of why.
+func (np *NSQPublisher) Publish(topic string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	commitBytes, err := np.encoder.Encode(commit)
+	if err != nil {
+		return err
+	}
+
+	producer, err := np.getProducer()
+	if err != nil {
+		return err
+	}
+
+	if err := producer.Publish(topic, commitBytes); err != nil {
+		return err
+	}
+
+	fn(commitAck(topic, commit))
+	return nil
+}
+
+// PublishEvent implements the cqrskit.EventPublisher and sends a single event split from
+// a commit into the nsq topic, it is used with cqrskit.PerEventPublisher to have each event
+// published to a topic for it's type.
+func (np *NSQPublisher) PublishEvent(topic string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	msgBytes, err := cqrskit.EncodeEvent(np.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	producer, err := np.getProducer()
+	if err != nil {
+		return err
+	}
+
+	if err := producer.Publish(topic, msgBytes); err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Namespace:   topic,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
+
+// PublishBatch sends all provided commits into the nsq topic as a single MultiPublish
+// request, calling the acknowledged function for each commit once the batch is delivered.
+func (np *NSQPublisher) PublishBatch(topic string, commits []cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	batch := make([][]byte, 0, len(commits))
+	for _, commit := range commits {
+		commitBytes, err := np.encoder.Encode(commit)
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, commitBytes)
+	}
+
+	producer, err := np.getProducer()
+	if err != nil {
+		return err
+	}
+
+	if err := producer.MultiPublish(topic, batch); err != nil {
+		return err
+	}
+
+	for _, commit := range commits {
+		fn(commitAck(topic, commit))
+	}
+
+	return nil
+}
+
+// PublishAfter sends the commit into the nsq topic using DeferredPublish, where nsqd will
+// only deliver said commit to consumers after the giving delay. It is useful for the delayed
+// retry of commits.
+func (np *NSQPublisher) PublishAfter(topic string, delay time.Duration, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	commitBytes, err := np.encoder.Encode(commit)
+	if err != nil {
+		return err
+	}
+
+	producer, err := np.getProducer()
+	if err != nil {
+		return err
+	}
+
+	if err := producer.DeferredPublish(topic, delay, commitBytes); err != nil {
+		return err
+	}
+
+	fn(commitAck(topic, commit))
+	return nil
+}
+
+// Close stops the underline nsq producer, after which all publishing fails with ErrClosed.
+func (np *NSQPublisher) Close() error {
+	np.pl.Lock()
+	defer np.pl.Unlock()
+
+	if np.producer == nil {
+		return nil
+	}
+
+	np.producer.Stop()
+	np.producer = nil
+	return nil
+}
+
+// getProducer returns the producer of the publisher, or ErrClosed once it is closed.
+func (np *NSQPublisher) getProducer() (Producer, error) {
+	np.pl.Lock()
+	defer np.pl.Unlock()
+
+	if np.producer == nil {
+		return nil, ErrClosed
+	}
+	return np.producer, nil
+}
+
+// commitAck returns the PubAck for a commit published into the topic.
+func commitAck(topic string, commit cqrskit.EventCommit) cqrskit.PubAck {
+	return cqrskit.PubAck{
+		Namespace:   topic,
+		Version:     commit.Version,
+		CommitID:    commit.CommitID,
+		InstanceID:  commit.InstanceID,
+		AggregateID: commit.AggregateID,
+	}
+}
+
+//*******************************************************************************
+// NSQ Consumer
+//*******************************************************************************
+
+// Handler defines a function type which is called with every EventCommit received
+// from a nsq topic, where a returned error requeues said commit with a backoff.
+type Handler func(cqrskit.EventCommit) error
+
+// MessageHandler implements the nsq.Handler interface, decoding every received message
+// into a EventCommit for a Handler. Messages are touched every TouchInterval while the
+// Handler runs, finished when it succeeds and requeued with an exponential delay starting
+// at RequeueDelay, capped at MaxRequeueDelay, when it fails. Messages which can not be
+// decoded are finished, as no amount of retries will change their outcome.
+type MessageHandler struct {
+	TouchInterval   time.Duration
+	RequeueDelay    time.Duration
+	MaxRequeueDelay time.Duration
+
+	fn      Handler
+	decoder cqrskit.Decoder
+}
+
+// NewMessageHandler returns a new instance of MessageHandler.
+func NewMessageHandler(decoder cqrskit.Decoder, fn Handler) *MessageHandler {
+	return &MessageHandler{
+		fn:              fn,
+		decoder:         decoder,
+		TouchInterval:   30 * time.Second,
+		RequeueDelay:    time.Second,
+		MaxRequeueDelay: 15 * time.Minute,
+	}
+}
+
+// HandleMessage implements the nsq.Handler interface.
+func (mh *MessageHandler) HandleMessage(msg *nsq.Message) error {
+	msg.DisableAutoResponse()
+
+	commit, err := mh.decoder.Decode(msg.Body)
+	if err != nil {
+		msg.Finish()
+		return err
+	}
+
+	var waiter sync.WaitGroup
+	done := make(chan struct{})
+	if mh.TouchInterval > 0 {
+		waiter.Add(1)
+		go mh.touch(msg, done, &waiter)
+	}
+
+	err = mh.fn(commit)
+	close(done)
+	waiter.Wait()
+
+	if err != nil {
+		msg.Requeue(mh.backoff(msg.Attempts))
+		return err
+	}
+
+	msg.Finish()
+	return nil
+}
+
+// touch resets the timeout of the message on every TouchInterval until done is closed.
+func (mh *MessageHandler) touch(msg *nsq.Message, done chan struct{}, waiter *sync.WaitGroup) {
+	defer waiter.Done()
+
+	ticker := time.NewTicker(mh.TouchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			msg.Touch()
+		}
+	}
+}
+
+// backoff returns the delay for requeuing a message for the giving attempt.
+func (mh *MessageHandler) backoff(attempts uint16) time.Duration {
+	delay := mh.RequeueDelay
+	for i := uint16(1); i < attempts; i++ {
+		delay *= 2
+		if mh.MaxRequeueDelay > 0 && delay >= mh.MaxRequeueDelay {
+			return mh.MaxRequeueDelay
+		}
+	}
+	return delay
+}
+
+// NSQConsumer wraps a nsq.Consumer delivering commits from a topic and channel to a
+// Handler through a MessageHandler.
+type NSQConsumer struct {
+	*MessageHandler
+	consumer *nsq.Consumer
+	handlers bool
+}
+
+// NewNSQConsumer returns a new instance of NSQConsumer for the topic and channel, where
+// maxInFlight sets the total messages the consumer will have in flight at a time.
+func NewNSQConsumer(topic string, channel string, maxInFlight int, config *nsq.Config, decoder cqrskit.Decoder, fn Handler) (*NSQConsumer, error) {
+	if config == nil {
+		config = nsq.NewConfig()
+	}
+
+	config.MaxInFlight = maxInFlight
+
+	consumer, err := nsq.NewConsumer(topic, channel, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NSQConsumer{
+		consumer:       consumer,
+		MessageHandler: NewMessageHandler(decoder, fn),
+	}, nil
+}
+
+// ChangeMaxInFlight changes the total messages the consumer will have in flight at a time.
+func (nc *NSQConsumer) ChangeMaxInFlight(maxInFlight int) {
+	nc.consumer.ChangeMaxInFlight(maxInFlight)
+}
+
+// ConnectToNSQD adds the MessageHandler to the consumer with the giving concurrency and
+// connects it to the nsqd instances at the provided addresses.
+func (nc *NSQConsumer) ConnectToNSQD(concurrency int, addrs ...string) error {
+	nc.addHandlers(concurrency)
+	return nc.consumer.ConnectToNSQDs(addrs)
+}
+
+// ConnectToNSQLookupd adds the MessageHandler to the consumer with the giving concurrency
+// and connects it to the nsqlookupd instances at the provided addresses.
+func (nc *NSQConsumer) ConnectToNSQLookupd(concurrency int, addrs ...string) error {
+	nc.addHandlers(concurrency)
+	return nc.consumer.ConnectToNSQLookupds(addrs)
+}
+
+// Close stops the underline nsq consumer, waiting till all in flight messages are handled.
+func (nc *NSQConsumer) Close() error {
+	nc.consumer.Stop()
+
+	// StopChan is only closed once running handlers exit.
+	if nc.handlers {
+		<-nc.consumer.StopChan
+	}
+	return nil
+}
+
+// addHandlers adds the MessageHandler to the consumer once.
+func (nc *NSQConsumer) addHandlers(concurrency int) {
+	if nc.handlers {
+		return
+	}
+
+	nc.handlers = true
+	nc.consumer.AddConcurrentHandlers(nc.MessageHandler, concurrency)
+}
@@ -0,0 +1,221 @@
+package nsq_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	nsq "github.com/nsqio/go-nsq"
+
+	pubnsq "github.com/gokit/cqrskit/publishers/nsq"
+)
+
+func TestNSQPublisher(t *testing.T) {
+	producer := &mockProducer{topics: make(map[string][][]byte)}
+	publisher := pubnsq.NSQPublisherFrom(producer, cqrskit.JSONEncoder{})
+
+	if err := publisher.Publish("users.events", cqrskit.EventCommit{}, func(ack cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	var acked int
+	commits := []cqrskit.EventCommit{{Version: 1}, {Version: 2}, {Version: 3}}
+	if err := publisher.PublishBatch("users.events", commits, func(ack cqrskit.PubAck) { acked++ }); err != nil {
+		tests.FailedWithError(err, "Should have successfully published batch of event commits")
+	}
+	tests.Passed("Should have successfully published batch of event commits")
+
+	if acked != 3 || producer.batches != 1 {
+		tests.Failed("Should have acknowledged all commits of a single batch")
+	}
+	tests.Passed("Should have acknowledged all commits of a single batch")
+
+	if err := publisher.PublishAfter("users.events", time.Second, cqrskit.EventCommit{}, func(ack cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published deferred event commit")
+	}
+	tests.Passed("Should have successfully published deferred event commit")
+
+	if producer.delay != time.Second {
+		tests.Failed("Should have deferred delivery of event commit")
+	}
+	tests.Passed("Should have deferred delivery of event commit")
+
+	if total := len(producer.topics["users.events"]); total != 5 {
+		tests.Info("Expected: %d", 5)
+		tests.Info("Received: %d", total)
+		tests.Failed("Should have delivered all commits into topic")
+	}
+	tests.Passed("Should have delivered all commits into topic")
+
+	if err := publisher.Close(); err != nil || !producer.stopped {
+		tests.Failed("Should have stopped producer")
+	}
+	tests.Passed("Should have stopped producer")
+}
+
+func TestNSQPublisherClosed(t *testing.T) {
+	producer := &mockProducer{topics: make(map[string][][]byte)}
+	publisher := pubnsq.NSQPublisherFrom(producer, cqrskit.JSONEncoder{})
+
+	if err := publisher.Close(); err != nil {
+		tests.FailedWithError(err, "Should have successfully closed publisher")
+	}
+	tests.Passed("Should have successfully closed publisher")
+
+	noAck := func(ack cqrskit.PubAck) {}
+	for _, err := range []error{
+		publisher.Publish("users.events", cqrskit.EventCommit{}, noAck),
+		publisher.PublishEvent("users.events", cqrskit.EventMessage{}, noAck),
+		publisher.PublishBatch("users.events", []cqrskit.EventCommit{{Version: 1}}, noAck),
+		publisher.PublishAfter("users.events", time.Second, cqrskit.EventCommit{}, noAck),
+	} {
+		if err != pubnsq.ErrClosed {
+			tests.Info("Received: %+v", err)
+			tests.Failed("Should have rejected publishing after close")
+		}
+	}
+	tests.Passed("Should have rejected publishing after close")
+
+	if len(producer.topics) != 0 {
+		tests.Failed("Should have delivered no commits after close")
+	}
+	tests.Passed("Should have delivered no commits after close")
+}
+
+func TestMessageHandler(t *testing.T) {
+	var calls int
+	handler := pubnsq.NewMessageHandler(cqrskit.JSONDecoder{}, func(commit cqrskit.EventCommit) error {
+		calls++
+		if calls == 1 {
+			return errors.New("failed to handle commit")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	handler.RequeueDelay = time.Second
+	handler.MaxRequeueDelay = 3 * time.Second
+	handler.TouchInterval = 10 * time.Millisecond
+
+	commitBytes, err := cqrskit.JSONEncoder{}.Encode(cqrskit.EventCommit{CommitID: "433436577674674574567575675"})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully encoded event commit")
+	}
+	tests.Passed("Should have successfully encoded event commit")
+
+	delegate := &mockDelegate{}
+	msg := newMessage(commitBytes, delegate)
+	msg.Attempts = 3
+
+	if err := handler.HandleMessage(msg); err == nil {
+		tests.Failed("Should have failed to handle message")
+	}
+	tests.Passed("Should have failed to handle message")
+
+	if !delegate.requeued || !delegate.backoff {
+		tests.Failed("Should have requeued message with backoff")
+	}
+	tests.Passed("Should have requeued message with backoff")
+
+	if delegate.delay != 3*time.Second {
+		tests.Info("Expected: %s", 3*time.Second)
+		tests.Info("Received: %s", delegate.delay)
+		tests.Failed("Should have capped requeue delay")
+	}
+	tests.Passed("Should have capped requeue delay")
+
+	delegate = &mockDelegate{}
+	if err := handler.HandleMessage(newMessage(commitBytes, delegate)); err != nil {
+		tests.FailedWithError(err, "Should have successfully handled message")
+	}
+	tests.Passed("Should have successfully handled message")
+
+	if !delegate.finished || delegate.touched == 0 {
+		tests.Failed("Should have touched message and finished it")
+	}
+	tests.Passed("Should have touched message and finished it")
+
+	delegate = &mockDelegate{}
+	if err := handler.HandleMessage(newMessage([]byte("{"), delegate)); err == nil {
+		tests.Failed("Should have failed to decode message")
+	}
+	tests.Passed("Should have failed to decode message")
+
+	if !delegate.finished {
+		tests.Failed("Should have finished message which failed decoding")
+	}
+	tests.Passed("Should have finished message which failed decoding")
+}
+
+func newMessage(body []byte, delegate nsq.MessageDelegate) *nsq.Message {
+	var id nsq.MessageID
+	copy(id[:], "0123456789abcdef")
+
+	msg := nsq.NewMessage(id, body)
+	msg.Delegate = delegate
+	msg.Attempts = 1
+	return msg
+}
+
+type mockDelegate struct {
+	ml       sync.Mutex
+	touched  int
+	finished bool
+	requeued bool
+	backoff  bool
+	delay    time.Duration
+}
+
+func (m *mockDelegate) OnFinish(*nsq.Message) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+	m.finished = true
+}
+
+func (m *mockDelegate) OnRequeue(msg *nsq.Message, delay time.Duration, backoff bool) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+	m.requeued = true
+	m.backoff = backoff
+	m.delay = delay
+}
+
+func (m *mockDelegate) OnTouch(*nsq.Message) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+	m.touched++
+}
+
+type mockProducer struct {
+	stopped bool
+	batches int
+	delay   time.Duration
+	topics  map[string][][]byte
+}
+
+func (m *mockProducer) Stop() {
+	m.stopped = true
+}
+
+func (m *mockProducer) Publish(topic string, body []byte) error {
+	m.topics[topic] = append(m.topics[topic], body)
+	return nil
+}
+
+func (m *mockProducer) MultiPublish(topic string, body [][]byte) error {
+	m.batches++
+	m.topics[topic] = append(m.topics[topic], body...)
+	return nil
+}
+
+func (m *mockProducer) DeferredPublish(topic string, delay time.Duration, body []byte) error {
+	m.delay = delay
+	m.topics[topic] = append(m.topics[topic], body)
+	return nil
+}
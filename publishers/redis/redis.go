@@ -0,0 +1,473 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gokit/cqrskit"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// errors ...
+var (
+	ErrInvalidStreamReply = errors.New("invalid stream reply from redis")
+	ErrNoEntryData        = errors.New("entry has no commit or event field")
+)
+
+// consts of fields stored within each stream entry.
+const (
+	CommitField      = "commit"
+	EventField       = "event"
+	CommitIDField    = "commit_id"
+	VersionField     = "version"
+	InstanceIDField  = "instance_id"
+	AggregateIDField = "aggregate_id"
+
+	// ErrorField and EntryIDField hold the reason an entry was rejected and it's id
+	// in the source stream, within the entries of a dead-letter stream.
+	ErrorField   = "error"
+	EntryIDField = "entry_id"
+)
+
+//*******************************************************************************
+// Redis Streams Publisher
+//*******************************************************************************
+
+// RedisPublisher implements the cqrskit.Publisher using redis streams as the
+// underline transport, where each namespace is a stream into which commits are
+// added using XADD.
+type RedisPublisher struct {
+	// MaxLen sets the approximate length at which each stream is trimmed on
+	// every XADD, a value of zero or below disables trimming.
+	MaxLen int
+
+	addr    string
+	ops     []redis.DialOption
+	encoder cqrskit.Encoder
+	pl      sync.Mutex
+	pool    *redis.Pool
+}
+
+// RedisPublisherFrom returns a new instance of RedisPublisher using the provided
+// redis.Pool instance.
+func RedisPublisherFrom(pool *redis.Pool, encoder cqrskit.Encoder) *RedisPublisher {
+	return &RedisPublisher{
+		pool:    pool,
+		encoder: encoder,
+	}
+}
+
+// NewRedisPublisher returns a new instance of RedisPublisher.
+func NewRedisPublisher(addr string, encoder cqrskit.Encoder, ops ...redis.DialOption) *RedisPublisher {
+	return &RedisPublisher{
+		addr:    addr,
+		ops:     ops,
+		encoder: encoder,
+	}
+}
+
+// Publish implements the cqrskit.Publisher and adds the commit into the stream for
+// the namespace, calling acknowledged function with the id of the stream entry if
+// successful in both the encoding of the commit and it's addition into the stream,
+// else returning an error of why.
+func (rp *RedisPublisher) Publish(ns string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	commitBytes, err := rp.encoder.Encode(commit)
+	if err != nil {
+		return err
+	}
+
+	entryID, err := rp.add(ns, CommitField, commitBytes, commit.CommitID, commit.AggregateID, commit.InstanceID, commit.Version)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    entryID,
+		Namespace:   ns,
+		Version:     commit.Version,
+		CommitID:    commit.CommitID,
+		InstanceID:  commit.InstanceID,
+		AggregateID: commit.AggregateID,
+	})
+
+	return nil
+}
+
+// PublishEvent implements the cqrskit.EventPublisher and adds a single event split from
+// a commit into the stream for the namespace, it is used with cqrskit.PerEventPublisher to
+// have each event added to a stream for it's type.
+func (rp *RedisPublisher) PublishEvent(ns string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	msgBytes, err := cqrskit.EncodeEvent(rp.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	entryID, err := rp.add(ns, EventField, msgBytes, msg.CommitID, msg.AggregateID, msg.InstanceID, msg.Version)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    entryID,
+		Namespace:   ns,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
+
+// add runs XADD against the stream with the provided data, returning the id of the
+// new entry.
+func (rp *RedisPublisher) add(stream string, field string, data []byte, commitID string, aggregateID string, instanceID string, version int) (string, error) {
+	conn := rp.getPool().Get()
+	defer conn.Close()
+
+	args := redis.Args{stream}
+	if rp.MaxLen > 0 {
+		args = args.Add("MAXLEN", "~", rp.MaxLen)
+	}
+
+	args = args.Add("*", field, data)
+	args = args.Add(CommitIDField, commitID)
+	args = args.Add(AggregateIDField, aggregateID)
+	args = args.Add(InstanceIDField, instanceID)
+	args = args.Add(VersionField, version)
+
+	return redis.String(conn.Do("XADD", args...))
+}
+
+// Close ends the underline redis pool.
+func (rp *RedisPublisher) Close() error {
+	rp.pl.Lock()
+	defer rp.pl.Unlock()
+
+	if rp.pool == nil {
+		return nil
+	}
+
+	err := rp.pool.Close()
+	rp.pool = nil
+	return err
+}
+
+// getPool returns the current redis pool for delivery of commits.
+func (rp *RedisPublisher) getPool() *redis.Pool {
+	rp.pl.Lock()
+	defer rp.pl.Unlock()
+
+	if rp.pool != nil {
+		return rp.pool
+	}
+
+	addr, ops := rp.addr, rp.ops
+	rp.pool = &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr, ops...)
+		},
+	}
+
+	return rp.pool
+}
+
+//*******************************************************************************
+// Redis Streams Subscriber
+//*******************************************************************************
+
+// Handler defines a function type which is called with every EventCommit read from
+// a stream, where a returned error leaves said commit pending to be delivered again.
+// Entries added by PublishEvent are delivered as a EventCommit holding only their event.
+type Handler func(cqrskit.EventCommit) error
+
+// RedisSubscriber implements a consumer group reader over a redis stream, delivering
+// all added commits to a Handler and acknowledging each with XACK once handled.
+// Entries left pending by other consumers for longer than MinIdle are claimed using
+// XAUTOCLAIM and delivered again.
+type RedisSubscriber struct {
+	// Count sets the maximum number of entries read on each call.
+	Count int
+
+	// Block sets the duration each XREADGROUP call blocks for new entries.
+	Block time.Duration
+
+	// MinIdle sets the duration a pending entry must be idle before it is claimed.
+	MinIdle time.Duration
+
+	// DeadLetter sets the stream entries which can not be decoded are added to, along
+	// with the reason under ErrorField, before they are acknowledged. If empty, such
+	// entries are only acknowledged.
+	DeadLetter string
+
+	stream   string
+	group    string
+	consumer string
+	pool     *redis.Pool
+	decoder  cqrskit.Decoder
+}
+
+// NewRedisSubscriber returns a new instance of a RedisSubscriber reading the giving stream
+// as consumer within the consumer group.
+func NewRedisSubscriber(pool *redis.Pool, decoder cqrskit.Decoder, stream string, group string, consumer string) *RedisSubscriber {
+	return &RedisSubscriber{
+		Count:    10,
+		Block:    time.Second,
+		MinIdle:  30 * time.Second,
+		pool:     pool,
+		group:    group,
+		stream:   stream,
+		decoder:  decoder,
+		consumer: consumer,
+	}
+}
+
+// Subscribe creates the consumer group if it does not exist and delivers commits from
+// the stream to the provided Handler until the context is cancelled or a redis
+// error occurs.
+func (rs *RedisSubscriber) Subscribe(ctx context.Context, fn Handler) error {
+	if err := rs.createGroup(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err := rs.claim(ctx, fn); err != nil {
+			return err
+		}
+
+		if err := rs.read(ctx, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// createGroup creates the consumer group of the subscriber, ignoring the error if
+// said group already exists.
+func (rs *RedisSubscriber) createGroup() error {
+	conn := rs.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("XGROUP", "CREATE", rs.stream, rs.group, "$", "MKSTREAM"); err != nil {
+		if strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// claim takes ownership of entries pending longer than MinIdle and delivers them.
+func (rs *RedisSubscriber) claim(ctx context.Context, fn Handler) error {
+	conn := rs.pool.Get()
+	defer conn.Close()
+
+	start := "0-0"
+	for {
+		reply, err := redis.Values(conn.Do("XAUTOCLAIM", rs.stream, rs.group, rs.consumer, int64(rs.MinIdle/time.Millisecond), start, "COUNT", rs.Count))
+		if err != nil {
+			return err
+		}
+
+		if len(reply) < 2 {
+			return ErrInvalidStreamReply
+		}
+
+		if start, err = redis.String(reply[0], nil); err != nil {
+			return err
+		}
+
+		entries, err := parseEntries(reply[1])
+		if err != nil {
+			return err
+		}
+
+		if err := rs.deliver(ctx, conn, entries, fn); err != nil {
+			return err
+		}
+
+		if start == "0-0" {
+			return nil
+		}
+	}
+}
+
+// read retrieves new entries for the consumer and delivers them.
+func (rs *RedisSubscriber) read(ctx context.Context, fn Handler) error {
+	conn := rs.pool.Get()
+	defer conn.Close()
+
+	block := int64(rs.Block / time.Millisecond)
+	reply, err := redis.Values(redis.DoWithTimeout(conn, rs.Block+time.Second, "XREADGROUP", "GROUP", rs.group, rs.consumer, "COUNT", rs.Count, "BLOCK", block, "STREAMS", rs.stream, ">"))
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil
+		}
+		return err
+	}
+
+	for _, item := range reply {
+		stream, err := redis.Values(item, nil)
+		if err != nil {
+			return err
+		}
+
+		if len(stream) != 2 {
+			return ErrInvalidStreamReply
+		}
+
+		entries, err := parseEntries(stream[1])
+		if err != nil {
+			return err
+		}
+
+		if err := rs.deliver(ctx, conn, entries, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver decodes and calls the Handler with each entry, acknowledging those successfully
+// handled. Entries which fail to be handled are left pending, while those which can never
+// be decoded are rejected, so they are not claimed again forever.
+func (rs *RedisSubscriber) deliver(ctx context.Context, conn redis.Conn, entries []streamEntry, fn Handler) error {
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		commit, err := rs.decode(entry)
+		if err != nil {
+			if err := rs.reject(conn, entry, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(commit); err != nil {
+			continue
+		}
+
+		if _, err := conn.Do("XACK", rs.stream, rs.group, entry.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode returns the EventCommit of the entry, where entries added by PublishEvent are
+// delivered as a EventCommit holding only their single event.
+func (rs *RedisSubscriber) decode(entry streamEntry) (cqrskit.EventCommit, error) {
+	if data, ok := entry.Fields[CommitField]; ok {
+		return rs.decoder.Decode(data)
+	}
+
+	data, ok := entry.Fields[EventField]
+	if !ok {
+		return cqrskit.EventCommit{}, ErrNoEntryData
+	}
+
+	msg, err := cqrskit.DecodeEvent(rs.decoder, data)
+	if err != nil {
+		return cqrskit.EventCommit{}, err
+	}
+
+	return cqrskit.EventCommit{
+		Events:      []cqrskit.Event{msg.Event},
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	}, nil
+}
+
+// reject adds the entry to the DeadLetter stream, if any, and acknowledges it.
+func (rs *RedisSubscriber) reject(conn redis.Conn, entry streamEntry, reason string) error {
+	if rs.DeadLetter != "" {
+		fields := make([]string, 0, len(entry.Fields))
+		for field := range entry.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		args := []interface{}{rs.DeadLetter, "*"}
+		for _, field := range fields {
+			args = append(args, field, entry.Fields[field])
+		}
+		args = append(args, EntryIDField, entry.ID, ErrorField, reason)
+
+		if _, err := conn.Do("XADD", args...); err != nil {
+			return err
+		}
+	}
+
+	_, err := conn.Do("XACK", rs.stream, rs.group, entry.ID)
+	return err
+}
+
+// streamEntry embodies a single entry read from a redis stream.
+type streamEntry struct {
+	ID     string
+	Fields map[string][]byte
+}
+
+// parseEntries parses the reply of entries from XREADGROUP or XAUTOCLAIM.
+func parseEntries(reply interface{}) ([]streamEntry, error) {
+	items, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]streamEntry, 0, len(items))
+	for _, item := range items {
+		parts, err := redis.Values(item, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(parts) != 2 {
+			return nil, ErrInvalidStreamReply
+		}
+
+		id, err := redis.String(parts[0], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Entries deleted while pending are returned with no fields.
+		if parts[1] == nil {
+			continue
+		}
+
+		values, err := redis.ByteSlices(parts[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make(map[string][]byte, len(values)/2)
+		for i := 0; i+1 < len(values); i += 2 {
+			fields[string(values[i])] = values[i+1]
+		}
+
+		entries = append(entries, streamEntry{ID: id, Fields: fields})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,613 @@
+package redis_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/gomodule/redigo/redis"
+
+	pubredis "github.com/gokit/cqrskit/publishers/redis"
+)
+
+func TestRedisPublisher(t *testing.T) {
+	server := newRespServer(t)
+	defer server.Close()
+
+	publisher := pubredis.RedisPublisherFrom(server.Pool(), cqrskit.JSONEncoder{})
+	defer publisher.Close()
+
+	publisher.MaxLen = 2
+
+	for version := 1; version <= 3; version++ {
+		var entryID string
+		if err := publisher.Publish("audit.events", cqrskit.EventCommit{Version: version}, func(ack cqrskit.PubAck) {
+			entryID, _ = ack.Response.(string)
+		}); err != nil {
+			tests.FailedWithError(err, "Should have successfully published event commit")
+		}
+		tests.Passed("Should have successfully published event commit")
+
+		if entryID == "" {
+			tests.Failed("Should have received stream entry id in acknowledgement")
+		}
+		tests.Passed("Should have received stream entry id in acknowledgement")
+	}
+
+	if total := server.Len("audit.events"); total != 2 {
+		tests.Info("Expected: %d", 2)
+		tests.Info("Received: %d", total)
+		tests.Failed("Should have trimmed stream to max length")
+	}
+	tests.Passed("Should have trimmed stream to max length")
+
+	publisher.MaxLen = 0
+
+	commit := cqrskit.EventCommit{
+		Events: []cqrskit.Event{
+			{Type: "UserCreated"},
+			{Type: "UserEmailUpdated"},
+		},
+	}
+
+	if err := cqrskit.PerEventPublisher(publisher, nil).Publish("users.events", commit, func(ack cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published events of commit")
+	}
+	tests.Passed("Should have successfully published events of commit")
+
+	if total := server.Len("users.events.UserCreated"); total != 1 {
+		tests.Failed("Should have added event into stream for it's type")
+	}
+	tests.Passed("Should have added event into stream for it's type")
+}
+
+func TestRedisSubscriber(t *testing.T) {
+	server := newRespServer(t)
+	defer server.Close()
+
+	pool := server.Pool()
+
+	conn := pool.Get()
+	if _, err := conn.Do("XGROUP", "CREATE", "users.events", "workers", "$", "MKSTREAM"); err != nil {
+		tests.FailedWithError(err, "Should have successfully created consumer group")
+	}
+	tests.Passed("Should have successfully created consumer group")
+	conn.Close()
+
+	publisher := pubredis.RedisPublisherFrom(pool, cqrskit.JSONEncoder{})
+	for _, id := range []string{"commit-1", "commit-2", "commit-3"} {
+		if err := publisher.Publish("users.events", cqrskit.EventCommit{CommitID: id}, func(ack cqrskit.PubAck) {}); err != nil {
+			tests.FailedWithError(err, "Should have successfully published event commit")
+		}
+	}
+	tests.Passed("Should have successfully published event commits")
+
+	subscriber := pubredis.NewRedisSubscriber(pool, cqrskit.JSONDecoder{}, "users.events", "workers", "worker-1")
+	subscriber.Block = 20 * time.Millisecond
+	subscriber.MinIdle = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var failed bool
+	handled := map[string]int{}
+	err := subscriber.Subscribe(ctx, func(commit cqrskit.EventCommit) error {
+		handled[commit.CommitID]++
+
+		if commit.CommitID == "commit-2" && !failed {
+			failed = true
+			return errors.New("failed to handle commit")
+		}
+
+		if len(handled) == 3 && handled["commit-2"] == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	if err != context.Canceled {
+		tests.FailedWithError(err, "Should have stopped subscription due to cancellation")
+	}
+	tests.Passed("Should have stopped subscription due to cancellation")
+
+	if handled["commit-2"] != 2 {
+		tests.Info("Received: %d", handled["commit-2"])
+		tests.Failed("Should have claimed and redelivered failed commit")
+	}
+	tests.Passed("Should have claimed and redelivered failed commit")
+
+	if pending := server.Pending("users.events", "workers"); pending != 0 {
+		tests.Info("Received: %d", pending)
+		tests.Failed("Should have acknowledged all handled commits")
+	}
+	tests.Passed("Should have acknowledged all handled commits")
+}
+
+func TestRedisSubscriberEvents(t *testing.T) {
+	server := newRespServer(t)
+	defer server.Close()
+
+	pool := server.Pool()
+
+	conn := pool.Get()
+	if _, err := conn.Do("XGROUP", "CREATE", "users.events.UserCreated", "workers", "$", "MKSTREAM"); err != nil {
+		tests.FailedWithError(err, "Should have successfully created consumer group")
+	}
+	tests.Passed("Should have successfully created consumer group")
+	conn.Close()
+
+	commit := cqrskit.EventCommit{
+		Version:     2,
+		CommitID:    "commit-1",
+		InstanceID:  "user-1",
+		AggregateID: "User",
+		Events: []cqrskit.Event{
+			{Type: "UserCreated"},
+			{Type: "UserEmailUpdated"},
+		},
+	}
+
+	publisher := pubredis.RedisPublisherFrom(pool, cqrskit.JSONEncoder{})
+	if err := cqrskit.PerEventPublisher(publisher, nil).Publish("users.events", commit, func(ack cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published events of commit")
+	}
+	tests.Passed("Should have successfully published events of commit")
+
+	subscriber := pubredis.NewRedisSubscriber(pool, cqrskit.JSONDecoder{}, "users.events.UserCreated", "workers", "worker-1")
+	subscriber.Block = 20 * time.Millisecond
+	subscriber.DeadLetter = "users.events.UserCreated.dead"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var received cqrskit.EventCommit
+	err := subscriber.Subscribe(ctx, func(commit cqrskit.EventCommit) error {
+		received = commit
+		cancel()
+		return nil
+	})
+
+	if err != context.Canceled {
+		tests.FailedWithError(err, "Should have stopped subscription due to cancellation")
+	}
+	tests.Passed("Should have stopped subscription due to cancellation")
+
+	if received.CommitID != commit.CommitID || received.InstanceID != commit.InstanceID || received.Version != commit.Version {
+		tests.Info("Received: %+v", received)
+		tests.Failed("Should have delivered event with details of it's commit")
+	}
+	tests.Passed("Should have delivered event with details of it's commit")
+
+	if len(received.Events) != 1 || received.Events[0].Type != "UserCreated" {
+		tests.Info("Received: %+v", received.Events)
+		tests.Failed("Should have delivered only the event of the stream")
+	}
+	tests.Passed("Should have delivered only the event of the stream")
+
+	if total := server.Len("users.events.UserCreated.dead"); total != 0 {
+		tests.Info("Received: %d", total)
+		tests.Failed("Should have not rejected event entry")
+	}
+	tests.Passed("Should have not rejected event entry")
+}
+
+func TestRedisSubscriberMalformedEntries(t *testing.T) {
+	server := newRespServer(t)
+	defer server.Close()
+
+	pool := server.Pool()
+
+	conn := pool.Get()
+	if _, err := conn.Do("XGROUP", "CREATE", "users.events", "workers", "$", "MKSTREAM"); err != nil {
+		tests.FailedWithError(err, "Should have successfully created consumer group")
+	}
+	tests.Passed("Should have successfully created consumer group")
+
+	if _, err := conn.Do("XADD", "users.events", "*", pubredis.CommitField, "{not json"); err != nil {
+		tests.FailedWithError(err, "Should have successfully added undecodable entry")
+	}
+
+	if _, err := conn.Do("XADD", "users.events", "*", pubredis.CommitIDField, "commit-0"); err != nil {
+		tests.FailedWithError(err, "Should have successfully added entry without commit")
+	}
+	tests.Passed("Should have successfully added malformed entries")
+	conn.Close()
+
+	publisher := pubredis.RedisPublisherFrom(pool, cqrskit.JSONEncoder{})
+	if err := publisher.Publish("users.events", cqrskit.EventCommit{CommitID: "commit-1"}, func(ack cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	subscriber := pubredis.NewRedisSubscriber(pool, cqrskit.JSONDecoder{}, "users.events", "workers", "worker-1")
+	subscriber.Block = 20 * time.Millisecond
+	subscriber.MinIdle = 10 * time.Millisecond
+	subscriber.DeadLetter = "users.events.dead"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := subscriber.Subscribe(ctx, func(commit cqrskit.EventCommit) error {
+		cancel()
+		return nil
+	})
+
+	if err != context.Canceled {
+		tests.FailedWithError(err, "Should have stopped subscription due to cancellation")
+	}
+	tests.Passed("Should have stopped subscription due to cancellation")
+
+	if pending := server.Pending("users.events", "workers"); pending != 0 {
+		tests.Info("Received: %d", pending)
+		tests.Failed("Should have acknowledged malformed entries")
+	}
+	tests.Passed("Should have acknowledged malformed entries")
+
+	if total := server.Len("users.events.dead"); total != 2 {
+		tests.Info("Received: %d", total)
+		tests.Failed("Should have moved malformed entries into dead-letter stream")
+	}
+	tests.Passed("Should have moved malformed entries into dead-letter stream")
+}
+
+//*******************************************************************************
+// RESP Stand-in Server
+//*******************************************************************************
+
+// respServer implements a in-process stand-in for redis, supporting the stream
+// commands used by the publisher and subscriber.
+type respServer struct {
+	ln      net.Listener
+	ml      sync.Mutex
+	seq     int64
+	streams map[string]*fakeStream
+}
+
+type fakeStream struct {
+	entries []fakeEntry
+	groups  map[string]*fakeGroup
+}
+
+type fakeEntry struct {
+	seq    int64
+	fields []string
+}
+
+type fakeGroup struct {
+	last    int64
+	pending map[int64]*fakePending
+}
+
+type fakePending struct {
+	consumer  string
+	delivered time.Time
+}
+
+func newRespServer(t *testing.T) *respServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start resp server: %+q", err)
+	}
+
+	server := &respServer{ln: ln, streams: map[string]*fakeStream{}}
+	go server.serve()
+	return server
+}
+
+func (rs *respServer) Pool() *redis.Pool {
+	addr := rs.ln.Addr().String()
+	return &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr)
+		},
+	}
+}
+
+func (rs *respServer) Close() error {
+	return rs.ln.Close()
+}
+
+func (rs *respServer) Len(key string) int {
+	rs.ml.Lock()
+	defer rs.ml.Unlock()
+
+	if stream, ok := rs.streams[key]; ok {
+		return len(stream.entries)
+	}
+	return 0
+}
+
+func (rs *respServer) Pending(key string, group string) int {
+	rs.ml.Lock()
+	defer rs.ml.Unlock()
+
+	if stream, ok := rs.streams[key]; ok {
+		if grp, ok := stream.groups[group]; ok {
+			return len(grp.pending)
+		}
+	}
+	return 0
+}
+
+func (rs *respServer) serve() {
+	for {
+		conn, err := rs.ln.Accept()
+		if err != nil {
+			return
+		}
+		go rs.handle(conn)
+	}
+}
+
+func (rs *respServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		writeReply(writer, rs.exec(args))
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+type respError string
+
+func (rs *respServer) exec(args []string) interface{} {
+	switch strings.ToUpper(args[0]) {
+	case "XADD":
+		return rs.xadd(args[1:])
+	case "XGROUP":
+		return rs.xgroup(args[1:])
+	case "XREADGROUP":
+		return rs.xreadgroup(args[1:])
+	case "XAUTOCLAIM":
+		return rs.xautoclaim(args[1:])
+	case "XACK":
+		return rs.xack(args[1:])
+	}
+	return respError("ERR unknown command " + args[0])
+}
+
+func (rs *respServer) stream(key string) *fakeStream {
+	stream, ok := rs.streams[key]
+	if !ok {
+		stream = &fakeStream{groups: map[string]*fakeGroup{}}
+		rs.streams[key] = stream
+	}
+	return stream
+}
+
+func (rs *respServer) xadd(args []string) interface{} {
+	rs.ml.Lock()
+	defer rs.ml.Unlock()
+
+	stream := rs.stream(args[0])
+	args = args[1:]
+
+	maxLen := -1
+	if strings.ToUpper(args[0]) == "MAXLEN" {
+		args = args[1:]
+		if args[0] == "~" || args[0] == "=" {
+			args = args[1:]
+		}
+		maxLen, _ = strconv.Atoi(args[0])
+		args = args[1:]
+	}
+
+	rs.seq++
+	stream.entries = append(stream.entries, fakeEntry{seq: rs.seq, fields: args[1:]})
+	if maxLen >= 0 && len(stream.entries) > maxLen {
+		stream.entries = stream.entries[len(stream.entries)-maxLen:]
+	}
+
+	return []byte(entryID(rs.seq))
+}
+
+func (rs *respServer) xgroup(args []string) interface{} {
+	rs.ml.Lock()
+	defer rs.ml.Unlock()
+
+	stream := rs.stream(args[1])
+	if _, ok := stream.groups[args[2]]; ok {
+		return respError("BUSYGROUP Consumer Group name already exists")
+	}
+
+	stream.groups[args[2]] = &fakeGroup{last: rs.seq, pending: map[int64]*fakePending{}}
+	return "OK"
+}
+
+func (rs *respServer) xreadgroup(args []string) interface{} {
+	group, consumer := args[1], args[2]
+
+	var count int
+	var block time.Duration
+	var key string
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "COUNT":
+			count, _ = strconv.Atoi(args[i+1])
+			i++
+		case "BLOCK":
+			ms, _ := strconv.Atoi(args[i+1])
+			block = time.Duration(ms) * time.Millisecond
+			i++
+		case "STREAMS":
+			key = args[i+1]
+			i = len(args)
+		}
+	}
+
+	rs.ml.Lock()
+	stream := rs.stream(key)
+	grp, ok := stream.groups[group]
+	if !ok {
+		rs.ml.Unlock()
+		return respError("NOGROUP No such consumer group")
+	}
+
+	var entries []interface{}
+	for _, entry := range stream.entries {
+		if entry.seq <= grp.last {
+			continue
+		}
+		if count > 0 && len(entries) == count {
+			break
+		}
+
+		grp.last = entry.seq
+		grp.pending[entry.seq] = &fakePending{consumer: consumer, delivered: time.Now()}
+		entries = append(entries, entryReply(entry))
+	}
+	rs.ml.Unlock()
+
+	if len(entries) == 0 {
+		if block > 10*time.Millisecond {
+			block = 10 * time.Millisecond
+		}
+		time.Sleep(block)
+		return nil
+	}
+
+	return []interface{}{[]interface{}{[]byte(key), entries}}
+}
+
+func (rs *respServer) xautoclaim(args []string) interface{} {
+	rs.ml.Lock()
+	defer rs.ml.Unlock()
+
+	stream := rs.stream(args[0])
+	grp, ok := stream.groups[args[1]]
+	if !ok {
+		return respError("NOGROUP No such consumer group")
+	}
+
+	minIdle, _ := strconv.Atoi(args[3])
+	count, _ := strconv.Atoi(args[6])
+
+	now := time.Now()
+	var entries []interface{}
+	for _, entry := range stream.entries {
+		pending, ok := grp.pending[entry.seq]
+		if !ok || now.Sub(pending.delivered) < time.Duration(minIdle)*time.Millisecond {
+			continue
+		}
+		if len(entries) == count {
+			return []interface{}{[]byte(entryID(entry.seq)), entries, []interface{}{}}
+		}
+
+		pending.consumer = args[2]
+		pending.delivered = now
+		entries = append(entries, entryReply(entry))
+	}
+
+	return []interface{}{[]byte("0-0"), entries, []interface{}{}}
+}
+
+func (rs *respServer) xack(args []string) interface{} {
+	rs.ml.Lock()
+	defer rs.ml.Unlock()
+
+	stream := rs.stream(args[0])
+	grp, ok := stream.groups[args[1]]
+	if !ok {
+		return int64(0)
+	}
+
+	var acked int64
+	for _, id := range args[2:] {
+		seq, _ := strconv.ParseInt(strings.TrimPrefix(id, "1-"), 10, 64)
+		if _, ok := grp.pending[seq]; ok {
+			delete(grp.pending, seq)
+			acked++
+		}
+	}
+	return acked
+}
+
+func entryID(seq int64) string {
+	return fmt.Sprintf("1-%d", seq)
+}
+
+func entryReply(entry fakeEntry) interface{} {
+	fields := make([]interface{}, 0, len(entry.fields))
+	for _, field := range entry.fields {
+		fields = append(fields, []byte(field))
+	}
+	return []interface{}{[]byte(entryID(entry.seq)), fields}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("expected array of bulk strings")
+	}
+
+	total, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+
+		args = append(args, string(data[:size]))
+	}
+
+	return args, nil
+}
+
+func writeReply(writer *bufio.Writer, reply interface{}) {
+	switch value := reply.(type) {
+	case nil:
+		writer.WriteString("*-1\r\n")
+	case string:
+		fmt.Fprintf(writer, "+%s\r\n", value)
+	case respError:
+		fmt.Fprintf(writer, "-%s\r\n", value)
+	case int64:
+		fmt.Fprintf(writer, ":%d\r\n", value)
+	case []byte:
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(value), value)
+	case []interface{}:
+		fmt.Fprintf(writer, "*%d\r\n", len(value))
+		for _, item := range value {
+			writeReply(writer, item)
+		}
+	}
+}
@@ -159,3 +159,38 @@ func (np *SQSPublisher) Publish(targetName string, commit cqrskit.EventCommit, f
 
 	return nil
 }
+
+// PublishEvent implements the cqrskit.EventPublisher interface and sends a single event
+// split from a commit to the sqs queue registered with the targetName, it is used with
+// cqrskit.PerEventPublisher to have each event delivered to a queue for it's type.
+func (np *SQSPublisher) PublishEvent(targetName string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	region, err := np.getSQSRegion(targetName)
+	if err != nil {
+		return err
+	}
+
+	msgBytes, err := cqrskit.EncodeEvent(np.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	var message sqs.SendMessageInput
+	message.QueueUrl = aws.String(region.URL)
+	message.MessageBody = aws.String(string(msgBytes))
+
+	output, err := region.Service.SendMessage(&message)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    output,
+		Namespace:   region.URL,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
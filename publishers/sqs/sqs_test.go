@@ -52,6 +52,77 @@ func TestSQSPublisher(t *testing.T) {
 	<-mockSVC.actions
 }
 
+func TestSQSPublisherPerEvent(t *testing.T) {
+	mockSVC := mockSQSClient{
+		actions: make(chan struct{}),
+	}
+
+	publisher := pubsqs.NewSQSPublisher(func(region string) (sqsiface.SQSAPI, error) {
+		return mockSVC, nil
+	}, cqrskit.JSONEncoder{})
+
+	if err := publisher.AddSQSRegion(
+		"user.events.UserCreated",
+		"http://sqs.us-east-2.amazonaws.com/123456789012/UserCreated",
+	); err != nil {
+		tests.FailedWithError(err, "Should have successfully added new queue region")
+	}
+	tests.Passed("Should have successfully added new queue region")
+
+	if err := publisher.AddSQSRegion(
+		"user.events.UserEmailUpdated",
+		"http://sqs.us-east-2.amazonaws.com/123456789012/UserEmailUpdated",
+	); err != nil {
+		tests.FailedWithError(err, "Should have successfully added new queue region")
+	}
+	tests.Passed("Should have successfully added new queue region")
+
+	commit := cqrskit.EventCommit{
+		Version:  1,
+		CommitID: "433436577674674574567575675",
+		Events: []cqrskit.Event{
+			{Type: "UserCreated"},
+			{Type: "UserEmailUpdated"},
+		},
+	}
+
+	var acks []cqrskit.PubAck
+	perEvent := cqrskit.PerEventPublisher(publisher, nil)
+	if err := perEvent.Publish("user.events", commit, func(ack cqrskit.PubAck) {
+		acks = append(acks, ack)
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	<-mockSVC.actions
+	<-mockSVC.actions
+
+	if len(acks) != 1 {
+		tests.Info("Expected: %d", 1)
+		tests.Info("Received: %d", len(acks))
+		tests.Failed("Should have acknowledged commit once")
+	}
+	tests.Passed("Should have acknowledged commit once")
+
+	parts, ok := acks[0].Response.([]cqrskit.PubAck)
+	if !ok || len(parts) != 2 {
+		tests.Failed("Should have received acknowledgement for all events")
+	}
+	tests.Passed("Should have received acknowledgement for all events")
+
+	if parts[1].Namespace != "http://sqs.us-east-2.amazonaws.com/123456789012/UserEmailUpdated" {
+		tests.Info("Received: %q", parts[1].Namespace)
+		tests.Failed("Should have published event to queue for it's type")
+	}
+	tests.Passed("Should have published event to queue for it's type")
+
+	if err := perEvent.Publish("bomb.events", commit, func(ack cqrskit.PubAck) {}); err == nil {
+		tests.Failed("Should have failed to publish events with no registered queue")
+	}
+	tests.Passed("Should have failed to publish events with no registered queue")
+}
+
 type mockSQSClient struct {
 	sqsiface.SQSAPI
 	actions chan struct{}
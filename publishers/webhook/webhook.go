@@ -0,0 +1,336 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gokit/cqrskit"
+)
+
+// errors ...
+var (
+	ErrNoEndpoints = errors.New("target name has no associated webhook endpoints registered")
+)
+
+// consts of headers set on every webhook request.
+const (
+	SignatureHeader   = "X-Cqrskit-Signature"
+	TimestampHeader   = "X-Cqrskit-Timestamp"
+	NamespaceHeader   = "X-Cqrskit-Namespace"
+	IdempotencyHeader = "Idempotency-Key"
+)
+
+// maxResponseBody sets the maximum size of a response body kept for a PubAck.
+const maxResponseBody = 64 * 1024
+
+//*******************************************************************************
+// Signatures
+//*******************************************************************************
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the timestamp and body using the
+// provided secret, prefixed with the algorithm used, eg sha256=...
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true/false if the signature matches that of the timestamp and body
+// for the provided secret. It is used by receivers of webhook requests.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+//*******************************************************************************
+// Webhook Publisher
+//*******************************************************************************
+
+// Response embodies the final response received from a webhook endpoint, it is set
+// for each endpoint as the Response of every PubAck.
+type Response struct {
+	URL      string `json:"url"`
+	Status   int    `json:"status"`
+	Body     []byte `json:"body"`
+	Attempts int    `json:"attempts"`
+}
+
+// StatusError is returned when a webhook endpoint responds with a status which is
+// not successful after all retries.
+type StatusError struct {
+	Response
+}
+
+// Error implements the error interface.
+func (se StatusError) Error() string {
+	return fmt.Sprintf("webhook %q responded with status %d after %d attempts", se.URL, se.Status, se.Attempts)
+}
+
+// endpoint embodies a registered webhook url with the secret it's requests are signed with.
+type endpoint struct {
+	URL    string
+	Secret string
+}
+
+// WebhookPublisher implements the cqrskit.Publisher by POSTing encoded commits to all
+// webhook endpoints registered for a namespace. Requests are signed with the secret of
+// the endpoint and carry the CommitID as their idempotency key. Failed requests are retried
+// with an exponential backoff and jitter, respecting the Retry-After header of responses
+// upto MaxBackoff.
+type WebhookPublisher struct {
+	Client      *http.Client
+	ContentType string
+	MaxRetries  int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+
+	encoder   cqrskit.Encoder
+	el        sync.Mutex
+	endpoints map[string][]endpoint
+}
+
+// NewWebhookPublisher returns a new instance of WebhookPublisher.
+func NewWebhookPublisher(encoder cqrskit.Encoder) *WebhookPublisher {
+	return &WebhookPublisher{
+		encoder:     encoder,
+		MaxRetries:  5,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		ContentType: "application/json",
+		Client:      &http.Client{Timeout: 30 * time.Second},
+		endpoints:   make(map[string][]endpoint),
+	}
+}
+
+// AddEndpoint adds a webhook url to receive commits published to the targetName, where
+// each request is signed with the provided secret.
+func (wp *WebhookPublisher) AddEndpoint(targetName string, url string, secret string) {
+	wp.el.Lock()
+	defer wp.el.Unlock()
+
+	wp.endpoints[targetName] = append(wp.endpoints[targetName], endpoint{
+		URL:    url,
+		Secret: secret,
+	})
+}
+
+// getEndpoints returns all endpoints registered for targetName.
+func (wp *WebhookPublisher) getEndpoints(targetName string) ([]endpoint, error) {
+	wp.el.Lock()
+	defer wp.el.Unlock()
+
+	endpoints, ok := wp.endpoints[targetName]
+	if !ok || len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	return endpoints, nil
+}
+
+// Publish implements the cqrskit.Publisher interface and delivers the commit to all endpoints
+// of the targetName, calling the acknowledged function with the Response of each endpoint once
+// all have received it, else returning an error of why.
+func (wp *WebhookPublisher) Publish(targetName string, commit cqrskit.EventCommit, fn cqrskit.AckHandler) error {
+	commitBytes, err := wp.encoder.Encode(commit)
+	if err != nil {
+		return err
+	}
+
+	responses, err := wp.deliver(targetName, commit.CommitID, commitBytes)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    responses,
+		Namespace:   targetName,
+		Version:     commit.Version,
+		CommitID:    commit.CommitID,
+		InstanceID:  commit.InstanceID,
+		AggregateID: commit.AggregateID,
+	})
+
+	return nil
+}
+
+// PublishEvent implements the cqrskit.EventPublisher and delivers a single event split from
+// a commit to all endpoints of the targetName, it is used with cqrskit.PerEventPublisher to
+// have each event delivered to the endpoints for it's type.
+func (wp *WebhookPublisher) PublishEvent(targetName string, msg cqrskit.EventMessage, fn cqrskit.AckHandler) error {
+	msgBytes, err := cqrskit.EncodeEvent(wp.encoder, msg)
+	if err != nil {
+		return err
+	}
+
+	key := msg.CommitID + "-" + strconv.Itoa(msg.Index)
+	responses, err := wp.deliver(targetName, key, msgBytes)
+	if err != nil {
+		return err
+	}
+
+	fn(cqrskit.PubAck{
+		Response:    responses,
+		Namespace:   targetName,
+		Version:     msg.Version,
+		CommitID:    msg.CommitID,
+		InstanceID:  msg.InstanceID,
+		AggregateID: msg.AggregateID,
+	})
+
+	return nil
+}
+
+// deliver sends the body to all endpoints of the targetName, stopping at the first
+// endpoint which fails. As every request carries the idempotency key, re-publishing
+// is safe for endpoints which already received it.
+func (wp *WebhookPublisher) deliver(targetName string, key string, body []byte) ([]Response, error) {
+	endpoints, err := wp.getEndpoints(targetName)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]Response, 0, len(endpoints))
+	for _, end := range endpoints {
+		res, err := wp.post(targetName, end, key, body)
+		if err != nil {
+			return responses, err
+		}
+
+		responses = append(responses, res)
+	}
+
+	return responses, nil
+}
+
+// post sends the body to the endpoint, retrying on network errors, timeouts, rate limits
+// and server errors till MaxRetries is reached.
+func (wp *WebhookPublisher) post(targetName string, end endpoint, key string, body []byte) (Response, error) {
+	res := Response{URL: end.URL}
+
+	for {
+		res.Attempts++
+
+		var retryAfter time.Duration
+		status, resBody, header, err := wp.send(targetName, end, key, body)
+		if err == nil {
+			res.Status = status
+			res.Body = resBody
+
+			if status >= 200 && status < 300 {
+				return res, nil
+			}
+
+			if !retryable(status) {
+				return res, StatusError{Response: res}
+			}
+
+			retryAfter = parseRetryAfter(header.Get("Retry-After"))
+		}
+
+		if res.Attempts > wp.MaxRetries {
+			if err != nil {
+				return res, err
+			}
+			return res, StatusError{Response: res}
+		}
+
+		// Retry-After is capped by MaxBackoff, so no endpoint stalls a publish for
+		// as long as it asks.
+		delay := wp.backoff(res.Attempts)
+		if retryAfter > 0 {
+			delay = retryAfter
+			if wp.MaxBackoff > 0 && delay > wp.MaxBackoff {
+				delay = wp.MaxBackoff
+			}
+		}
+
+		time.Sleep(delay)
+	}
+}
+
+// send makes a single signed request to the endpoint.
+func (wp *WebhookPublisher) send(targetName string, end endpoint, key string, body []byte) (int, []byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodPost, end.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", wp.ContentType)
+	req.Header.Set(IdempotencyHeader, key)
+	req.Header.Set(NamespaceHeader, targetName)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(end.Secret, timestamp, body))
+
+	res, err := wp.Client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return res.StatusCode, resBody, res.Header, nil
+}
+
+// backoff returns the delay before the next attempt, which doubles on every attempt
+// from MinBackoff upto MaxBackoff, with a random jitter of upto half of said delay.
+func (wp *WebhookPublisher) backoff(attempt int) time.Duration {
+	delay := wp.MinBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if wp.MaxBackoff > 0 && delay >= wp.MaxBackoff {
+			delay = wp.MaxBackoff
+			break
+		}
+	}
+
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int63n(half+1))
+	}
+	return delay
+}
+
+// retryable returns true/false if a request with the giving response status should
+// be retried.
+func retryable(status int) bool {
+	return status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= http.StatusInternalServerError
+}
+
+// parseRetryAfter returns the duration of a Retry-After header in either it's
+// seconds or http date form.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
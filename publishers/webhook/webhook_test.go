@@ -0,0 +1,214 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	pubwebhook "github.com/gokit/cqrskit/publishers/webhook"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	var rl sync.Mutex
+	var keys []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !pubwebhook.Verify("secret", r.Header.Get(pubwebhook.TimestampHeader), body, r.Header.Get(pubwebhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		rl.Lock()
+		keys = append(keys, r.Header.Get(pubwebhook.IdempotencyHeader))
+		attempt := len(keys)
+		rl.Unlock()
+
+		switch attempt {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("received"))
+		}
+	}))
+	defer server.Close()
+
+	publisher := pubwebhook.NewWebhookPublisher(cqrskit.JSONEncoder{})
+	publisher.MinBackoff = 5 * time.Millisecond
+	publisher.AddEndpoint("users.events", server.URL, "secret")
+
+	commit := cqrskit.EventCommit{CommitID: "433436577674674574567575675", Version: 1}
+
+	var ack cqrskit.PubAck
+	start := time.Now()
+	if err := publisher.Publish("users.events", commit, func(a cqrskit.PubAck) { ack = a }); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	if time.Since(start) < time.Second {
+		tests.Failed("Should have respected Retry-After of response")
+	}
+	tests.Passed("Should have respected Retry-After of response")
+
+	if len(keys) != 3 || keys[0] != commit.CommitID || keys[2] != commit.CommitID {
+		tests.Info("Received: %+v", keys)
+		tests.Failed("Should have retried request with commit id as idempotency key")
+	}
+	tests.Passed("Should have retried request with commit id as idempotency key")
+
+	responses, ok := ack.Response.([]pubwebhook.Response)
+	if !ok || len(responses) != 1 {
+		tests.Failed("Should have received endpoint responses in acknowledgement")
+	}
+	tests.Passed("Should have received endpoint responses in acknowledgement")
+
+	if responses[0].Status != http.StatusAccepted || string(responses[0].Body) != "received" || responses[0].Attempts != 3 {
+		tests.Info("Received: %+v", responses[0])
+		tests.Failed("Should have received final status and body of endpoint")
+	}
+	tests.Passed("Should have received final status and body of endpoint")
+}
+
+func TestWebhookPublisherRetryAfterCap(t *testing.T) {
+	var rl sync.Mutex
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rl.Lock()
+		attempts++
+		attempt := attempts
+		rl.Unlock()
+
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher := pubwebhook.NewWebhookPublisher(cqrskit.JSONEncoder{})
+	publisher.MinBackoff = 5 * time.Millisecond
+	publisher.MaxBackoff = 50 * time.Millisecond
+	publisher.AddEndpoint("users.events", server.URL, "secret")
+
+	start := time.Now()
+	if err := publisher.Publish("users.events", cqrskit.EventCommit{CommitID: "433436577674674574567575675"}, func(cqrskit.PubAck) {}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published event commit")
+	}
+	tests.Passed("Should have successfully published event commit")
+
+	if elapsed := time.Since(start); elapsed < publisher.MaxBackoff || elapsed > 5*time.Second {
+		tests.Info("Received: %s", elapsed)
+		tests.Failed("Should have capped Retry-After of response to MaxBackoff")
+	}
+	tests.Passed("Should have capped Retry-After of response to MaxBackoff")
+}
+
+func TestWebhookPublisherFailures(t *testing.T) {
+	var rl sync.Mutex
+	var calls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rl.Lock()
+		calls++
+		rl.Unlock()
+
+		if r.URL.Path == "/rejects" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	publisher := pubwebhook.NewWebhookPublisher(cqrskit.JSONEncoder{})
+	publisher.MaxRetries = 2
+	publisher.MinBackoff = time.Millisecond
+	publisher.AddEndpoint("users.events", server.URL+"/rejects", "secret")
+	publisher.AddEndpoint("failing.events", server.URL+"/fails", "secret")
+
+	err := publisher.Publish("users.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {
+		tests.Failed("Should not have acknowledged rejected commit")
+	})
+	if serr, ok := err.(pubwebhook.StatusError); !ok || serr.Status != http.StatusBadRequest || calls != 1 {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have failed without retrying rejected request")
+	}
+	tests.Passed("Should have failed without retrying rejected request")
+
+	err = publisher.Publish("failing.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {
+		tests.Failed("Should not have acknowledged failed commit")
+	})
+	if serr, ok := err.(pubwebhook.StatusError); !ok || serr.Attempts != 3 || calls != 4 {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have failed after exhausting retries")
+	}
+	tests.Passed("Should have failed after exhausting retries")
+
+	if err := publisher.Publish("unknown.events", cqrskit.EventCommit{}, func(cqrskit.PubAck) {}); err != pubwebhook.ErrNoEndpoints {
+		tests.Failed("Should have failed for namespace without endpoints")
+	}
+	tests.Passed("Should have failed for namespace without endpoints")
+}
+
+func TestWebhookPublisherPerEvent(t *testing.T) {
+	var rl sync.Mutex
+	received := map[string]cqrskit.EventMessage{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg cqrskit.EventMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		rl.Lock()
+		received[r.Header.Get(pubwebhook.IdempotencyHeader)] = msg
+		rl.Unlock()
+	}))
+	defer server.Close()
+
+	publisher := pubwebhook.NewWebhookPublisher(cqrskit.JSONEncoder{})
+	publisher.AddEndpoint("users.events.UserCreated", server.URL, "secret")
+	publisher.AddEndpoint("users.events.UserUpdated", server.URL, "secret")
+
+	commit := cqrskit.EventCommit{
+		CommitID: "433436577674674574567575675",
+		Events: []cqrskit.Event{
+			{Type: "UserCreated"},
+			{Type: "UserUpdated"},
+		},
+	}
+
+	var acks int
+	if err := cqrskit.PerEventPublisher(publisher, cqrskit.EventNamespace).Publish("users.events", commit, func(cqrskit.PubAck) {
+		acks++
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully published events of commit")
+	}
+	tests.Passed("Should have successfully published events of commit")
+
+	if acks != 1 || len(received) != 2 {
+		tests.Failed("Should have delivered each event once and acknowledged commit")
+	}
+	tests.Passed("Should have delivered each event once and acknowledged commit")
+
+	if received[commit.CommitID+"-1"].Event.Type != "UserUpdated" {
+		tests.Failed("Should have keyed each event by commit id and index")
+	}
+	tests.Passed("Should have keyed each event by commit id and index")
+}
@@ -0,0 +1,103 @@
+package cqrskit
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// errors ...
+var (
+	ErrUnknownCommand   = errors.New("command has no handler on aggregate")
+	ErrUnknownMessage   = errors.New("message has no transition on saga")
+	ErrUnknownEventType = errors.New("event type is not registered")
+)
+
+//*******************************************************************************
+// Event Registry
+//*******************************************************************************
+
+// Events is the default EventRegistry, which generated aggregates register the types of
+// their events with.
+var Events = NewEventRegistry()
+
+// EventRegistry maps the stable type names of events to the Go types of their data, letting
+// event data read back from stores as maps or bytes be decoded into their original types.
+type EventRegistry struct {
+	ml    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewEventRegistry returns a new instance of EventRegistry.
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{types: map[string]reflect.Type{}}
+}
+
+// Register sets the type of the giving sample value as the type of events named eventType.
+func (er *EventRegistry) Register(eventType string, sample interface{}) {
+	rt := reflect.TypeOf(sample)
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	er.ml.Lock()
+	defer er.ml.Unlock()
+	er.types[eventType] = rt
+}
+
+// Type returns the type registered for events named eventType.
+func (er *EventRegistry) Type(eventType string) (reflect.Type, bool) {
+	er.ml.RLock()
+	defer er.ml.RUnlock()
+	rt, ok := er.types[eventType]
+	return rt, ok
+}
+
+// New returns a pointer to a new zero value of the type registered for events named eventType,
+// else ErrUnknownEventType is returned.
+func (er *EventRegistry) New(eventType string) (interface{}, error) {
+	rt, ok := er.Type(eventType)
+	if !ok {
+		return nil, ErrUnknownEventType
+	}
+	return reflect.New(rt).Interface(), nil
+}
+
+// Decode returns the event with it's Data converted to the type registered for it's Type, as
+// when read back from stores as a map. Events of unregistered types or whose Data already holds
+// the registered type are returned as is.
+func (er *EventRegistry) Decode(event Event) (Event, error) {
+	rt, ok := er.Type(event.Type)
+	if !ok || event.Data == nil || reflect.TypeOf(event.Data) == rt {
+		return event, nil
+	}
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return event, err
+	}
+
+	value := reflect.New(rt)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return event, err
+	}
+
+	event.Data = value.Elem().Interface()
+	return event, nil
+}
+
+// EventTypes returns the names of all registered event types, in ascending order.
+func (er *EventRegistry) EventTypes() []string {
+	er.ml.RLock()
+	defer er.ml.RUnlock()
+
+	names := make([]string, 0, len(er.types))
+	for name := range er.types {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
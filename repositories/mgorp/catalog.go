@@ -0,0 +1,115 @@
+package mgorp
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"gopkg.in/mgo.v2/bson"
+)
+
+//*******************************************************************************
+// Catalog Implementation
+//*******************************************************************************
+
+// MgoCatalog implements the cqrskit.Catalog interface, providing a read-only view over
+// the aggregates, instances and commits stored within mongo.
+type MgoCatalog struct {
+	db MongoDB
+}
+
+// NewCatalog returns a new instance of MgoCatalog.
+func NewCatalog(db MongoDB) MgoCatalog {
+	return MgoCatalog{db: db}
+}
+
+// Aggregates returns the ids of all aggregates within the store, in ascending order.
+func (mc MgoCatalog) Aggregates(ctx context.Context) ([]string, error) {
+	zdb, zes, zerr := session(ctx, mc.db, true)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	defer zes.Close()
+
+	var aggregates []string
+	if err := zdb.C(AggregateCollection).Find(nil).Distinct("aggregate_id", &aggregates); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	sort.Strings(aggregates)
+	return aggregates, nil
+}
+
+// Instances returns the commit statistics of all instances of the giving aggregate, in
+// ascending order of their instance id. Instances without commits and tombstoned instances
+// are included.
+func (mc MgoCatalog) Instances(ctx context.Context, aggregateID string) ([]cqrskit.InstanceStat, error) {
+	if aggregateID == "" {
+		return nil, ErrInvalidAggregateID
+	}
+
+	zdb, zes, zerr := session(ctx, mc.db, true)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	defer zes.Close()
+
+	var models []AggregateModel
+	if err := zdb.C(AggregateModelCollection).Find(bson.M{"aggregate_id": aggregateID}).Sort("instance_id").All(&models); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	var counts []struct {
+		InstanceID  string    `bson:"_id"`
+		Commits     int       `bson:"commits"`
+		LastVersion int       `bson:"last_version"`
+		LastCommit  time.Time `bson:"last_commit"`
+	}
+
+	if err := zdb.C(AggregateEventCommitCollection).Pipe([]bson.M{
+		{"$match": bson.M{"aggregate_id": aggregateID}},
+		{"$group": bson.M{
+			"_id":          "$instance_id",
+			"commits":      bson.M{"$sum": 1},
+			"last_version": bson.M{"$max": "$version"},
+			"last_commit":  bson.M{"$max": "$created"},
+		}},
+	}).All(&counts); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
+	stats := make([]cqrskit.InstanceStat, len(models))
+	indexes := make(map[string]int, len(models))
+	for index, model := range models {
+		indexes[model.InstanceID] = index
+		stats[index] = cqrskit.InstanceStat{
+			AggregateID: aggregateID,
+			InstanceID:  model.InstanceID,
+			Deleted:     model.Deleted,
+		}
+	}
+
+	for _, count := range counts {
+		index, ok := indexes[count.InstanceID]
+		if !ok {
+			continue
+		}
+
+		stats[index].Commits = count.Commits
+		stats[index].LastVersion = count.LastVersion
+		stats[index].LastCommit = count.LastCommit
+	}
+
+	return stats, nil
+}
+
+// FindByEventType returns a page of upto limit commits of all aggregates and instances which
+// contain an event of the giving type and were created within the window, in ascending order of
+// creation. The cursor of a returned page is passed to read the next page, where an empty cursor
+// reads the first. A limit of zero or below returns all matching commits in a single page.
+func (mc MgoCatalog) FindByEventType(ctx context.Context, eventType string, window cqrskit.TimeRange, token string, limit int) (cqrskit.CommitPage, error) {
+	return readCreatedRange(ctx, mc.db, bson.M{"events.type": eventType}, window, token, limit)
+}
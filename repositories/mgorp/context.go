@@ -0,0 +1,78 @@
+package mgorp
+
+import (
+	"context"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+)
+
+// ContextError is returned by repository operations when their context is cancelled
+// or it's deadline is exceeded, before or while talking to mongo. It's Cause holds
+// the error of the context, letting callers tell it apart from errors of the db.
+type ContextError struct {
+	Cause error
+}
+
+// Error implements the error interface.
+func (ce ContextError) Error() string {
+	return "mgorp: operation aborted: " + ce.Cause.Error()
+}
+
+// IsContextError returns true/false if the error is a ContextError.
+func IsContextError(err error) bool {
+	_, ok := err.(ContextError)
+	return ok
+}
+
+// session returns a new mongo.Database and mongo.Session for an operation with the
+// giving context. If the context has a deadline then the session is copied to get it's
+// own socket, whose timeouts are set to the time left till said deadline. The session
+// must always be closed by the caller.
+func session(ctx context.Context, db MongoDB, isread bool) (*mgo.Database, *mgo.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, ContextError{Cause: err}
+	}
+
+	zdb, zes, err := db.New(isread)
+	if err != nil {
+		return nil, nil, contextErr(ctx, err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return zdb, zes, nil
+	}
+
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		zes.Close()
+		return nil, nil, ContextError{Cause: context.DeadlineExceeded}
+	}
+
+	copied := zes.Copy()
+	zes.Close()
+
+	copied.SetSyncTimeout(timeout)
+	copied.SetSocketTimeout(timeout)
+	return copied.DB(zdb.Name), copied, nil
+}
+
+// contextErr returns a ContextError if the context has ended, else the error as is. It
+// is used on errors returned by mongo, where a socket timeout from the deadline of the
+// context will come back as a ContextError.
+func contextErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(ContextError); ok {
+		return err
+	}
+
+	if cerr := ctx.Err(); cerr != nil {
+		return ContextError{Cause: cerr}
+	}
+
+	return err
+}
@@ -0,0 +1,62 @@
+package mgorp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/tests"
+	mgo "gopkg.in/mgo.v2"
+
+	"github.com/gokit/cqrskit/repositories/mgorp"
+)
+
+func TestContextExpiry(t *testing.T) {
+	db := &mockDB{}
+	readers := mgorp.NewReadMaster(db)
+
+	repo, err := readers.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := repo.ReadAll(cancelled); !mgorp.IsContextError(err) {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have returned context error for cancelled context")
+	}
+	tests.Passed("Should have returned context error for cancelled context")
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	if _, err := repo.Count(expired); !mgorp.IsContextError(err) {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have returned context error for expired deadline")
+	}
+	tests.Passed("Should have returned context error for expired deadline")
+
+	if db.calls != 0 {
+		tests.Failed("Should have aborted before creating any session")
+	}
+	tests.Passed("Should have aborted before creating any session")
+
+	if _, err := repo.Count(context.Background()); err == nil || mgorp.IsContextError(err) {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have returned db error as is")
+	}
+	tests.Passed("Should have returned db error as is")
+}
+
+type mockDB struct {
+	calls int
+}
+
+func (m *mockDB) New(isread bool) (*mgo.Database, *mgo.Session, error) {
+	m.calls++
+	return nil, nil, errors.New("no reachable servers")
+}
@@ -0,0 +1,203 @@
+package mgorp
+
+import (
+	"context"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+//*******************************************************************************
+// Commit Import Implementation
+//*******************************************************************************
+
+// ImportsCommits implements the archive.CommitImporters interface, as all repositories
+// of the MgoWriteMaster implement the cqrskit.CommitImporter interface.
+func (mw MgoWriteMaster) ImportsCommits() bool {
+	return true
+}
+
+// Import implements the cqrskit.CommitImporter interface, storing the commit with it's
+// CommitID, Version and Created time into the stream of the aggregate instance, along with
+// it's CommitHeader, whose timestamp is the time the commit was created. No dispatch record
+// is written, as imported commits were dispatched by the store they came from.
+//
+// An ErrDuplicateCommitRequest is returned if the commit id is already stored, and an
+// ErrConcurrentWrites if it's version is not above the last commit version of the stream
+// or is taken by a concurrent write, in which case nothing is written.
+func (mwr *MgoWriteRepository) Import(ctx context.Context, commit cqrskit.EventCommit) (cqrskit.CommitHeader, error) {
+	var header cqrskit.CommitHeader
+
+	zdb, zes, err := session(ctx, mwr.db, false)
+	if err != nil {
+		return header, err
+	}
+
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mwr.aggregateID, mwr.instanceID); err != nil {
+		return header, contextErr(ctx, err)
+	}
+
+	probeQuery := bson.M{
+		"commit_id":    commit.CommitID,
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+	}
+
+	totalFound, err := zdb.C(AggregateEventCommitCollection).Find(probeQuery).Count()
+	if err != nil && err != mgo.ErrNotFound {
+		return header, contextErr(ctx, err)
+	}
+
+	if err == nil && totalFound != 0 {
+		return header, ErrDuplicateCommitRequest
+	}
+
+	lastHeader, err := mwr.LastCommitVersion(ctx)
+	if err != nil && err != ErrNoCommitsYet {
+		return header, contextErr(ctx, err)
+	}
+
+	if commit.Version <= lastHeader.Version {
+		return header, ErrConcurrentWrites
+	}
+
+	commited := commit.Created
+	if commited.IsZero() {
+		commited = time.Now()
+	}
+
+	commit.InstanceID = mwr.instanceID
+	commit.AggregateID = mwr.aggregateID
+
+	commitHeader := bson.M{
+		"timestamp":    commited,
+		"version":      commit.Version,
+		"commit_id":    commit.CommitID,
+		"instance_id":  mwr.instanceID,
+		"aggregate_id": mwr.aggregateID,
+	}
+
+	if mwr.twoPhase {
+		if err := mwr.importTxn(ctx, zdb, commit, commitHeader); err != nil {
+			return header, err
+		}
+	} else if err := mwr.importDirect(ctx, zdb, commit, commitHeader); err != nil {
+		return header, err
+	}
+
+	header.Version = commit.Version
+	header.Timestamp = commited
+	header.CommitID = commit.CommitID
+	header.InstanceID = mwr.instanceID
+	header.AggregateID = mwr.aggregateID
+
+	return header, nil
+}
+
+// importDirect writes the imported commit and it's header without a two-phase commit. Like the
+// lease of Write, the header is inserted first, so a conflicting version or commit id is
+// caught by the unique indexes of the header collection before the commit is written, and
+// the header is removed again if the commit fails to be written.
+func (mwr *MgoWriteRepository) importDirect(ctx context.Context, zdb *mgo.Database, commit cqrskit.EventCommit, commitHeader bson.M) error {
+	headerID := bson.NewObjectId()
+	commitHeader["_id"] = headerID
+
+	headerCollection := zdb.C(AggregateCommitHeaderCollection)
+	if err := headerCollection.Insert(commitHeader); err != nil {
+		if mgo.IsDup(err) {
+			return mwr.importConflict(ctx, zdb, commit)
+		}
+
+		return contextErr(ctx, err)
+	}
+
+	if err := zdb.C(AggregateEventCommitCollection).Insert(commit); err != nil {
+		if rmErr := headerCollection.RemoveId(headerID); rmErr != nil && rmErr != mgo.ErrNotFound {
+			return contextErr(ctx, rmErr)
+		}
+
+		if mgo.IsDup(err) {
+			return mwr.importConflict(ctx, zdb, commit)
+		}
+
+		return contextErr(ctx, err)
+	}
+
+	return nil
+}
+
+// importConflict returns the error of an import rejected by a unique index, being
+// ErrDuplicateCommitRequest if the commit id is already stored and ErrConcurrentWrites
+// if it's version was taken or leased by another write.
+func (mwr *MgoWriteRepository) importConflict(ctx context.Context, zdb *mgo.Database, commit cqrskit.EventCommit) error {
+	total, err := zdb.C(AggregateCommitHeaderCollection).Find(bson.M{
+		"commit_id":    commit.CommitID,
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+	}).Count()
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+
+	if total != 0 {
+		return ErrDuplicateCommitRequest
+	}
+
+	return ErrConcurrentWrites
+}
+
+// importTxn writes the imported commit and it's header within a single two-phase commit, moving
+// the streamRecord of the aggregate instance forward to the commit's version.
+func (mwr *MgoWriteRepository) importTxn(ctx context.Context, zdb *mgo.Database, commit cqrskit.EventCommit, commitHeader bson.M) error {
+	streamID := mwr.streamID()
+	streamOp := txn.Op{C: AggregateStreamCollection, Id: streamID}
+
+	var stream streamRecord
+	if err := zdb.C(AggregateStreamCollection).FindId(streamID).One(&stream); err != nil {
+		if err != mgo.ErrNotFound {
+			return contextErr(ctx, err)
+		}
+
+		streamOp.Assert = txn.DocMissing
+		streamOp.Insert = streamRecord{Version: commit.Version}
+	} else {
+		if commit.Version <= stream.Version {
+			return ErrConcurrentWrites
+		}
+
+		streamOp.Assert = bson.M{"version": stream.Version}
+		streamOp.Update = bson.M{"$set": bson.M{"version": commit.Version}}
+	}
+
+	ops := []txn.Op{
+		streamOp,
+		{
+			C:      AggregateEventCommitCollection,
+			Id:     bson.NewObjectId(),
+			Assert: txn.DocMissing,
+			Insert: commit,
+		},
+		{
+			C:      AggregateCommitHeaderCollection,
+			Id:     bson.NewObjectId(),
+			Assert: txn.DocMissing,
+			Insert: commitHeader,
+		},
+	}
+
+	runner := txn.NewRunner(zdb.C(AggregateTxnCollection))
+	if err := runner.Run(ops, "", nil); err != nil {
+		if err == txn.ErrAborted {
+			return ErrConcurrentWrites
+		}
+
+		return contextErr(ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package mgorp
+
+import (
+	mgo "gopkg.in/mgo.v2"
+)
+
+// collectionIndexes holds the indexes of each collection used by the repositories. All
+// unique keys of records owned by an aggregate instance are scoped by it's aggregate_id
+// and instance_id, so every instance has it's own versions, revisions and commit ids.
+var collectionIndexes = map[string][]mgo.Index{
+	AggregateCollection: {
+		{
+			Key:    []string{"aggregate_id"},
+			Unique: true,
+			Name:   "aggregate_index",
+		},
+	},
+	AggregateModelCollection: {
+		{
+			Key:    []string{"aggregate_id", "instance_id"},
+			Unique: true,
+			Name:   "aggregate_instance_index",
+		},
+	},
+	AggregateEventCommitCollection: {
+		{
+			Key:    []string{"aggregate_id", "instance_id", "version"},
+			Unique: true,
+			Name:   "aggregate_instance_version",
+		},
+		{
+			Key:    []string{"aggregate_id", "instance_id", "commit_id"},
+			Unique: true,
+			Name:   "aggregate_instance_commit_id",
+		},
+		{
+			Key:  []string{"created"},
+			Name: "created",
+		},
+		{
+			Key:  []string{"events.type", "created"},
+			Name: "event_type_created",
+		},
+	},
+	AggregateCommitHeaderCollection: {
+		{
+			Key:    []string{"aggregate_id", "instance_id", "version"},
+			Unique: true,
+			Name:   "aggregate_instance_version",
+		},
+		{
+			Key:    []string{"aggregate_id", "instance_id", "commit_id"},
+			Unique: true,
+			Name:   "aggregate_instance_commit_id",
+		},
+		{
+			Key:  []string{"timestamp"},
+			Name: "timestamp",
+		},
+	},
+	AggregateDispatchCollection: {
+		{
+			Key:    []string{"aggregate_id", "instance_id", "commit_id"},
+			Unique: true,
+			Name:   "aggregate_instance_commit_id",
+		},
+	},
+	SnapshotCollection: {
+		{
+			Key:    []string{"aggregate_id", "instance_id", "revision"},
+			Unique: true,
+			Name:   "aggregate_instance_revision",
+		},
+		{
+			Key:    []string{"snap_id"},
+			Unique: true,
+			Name:   "snap_id",
+		},
+	},
+}
+
+// legacyIndexes holds the names of the indexes created by older versions of the repositories
+// which are replaced by collectionIndexes, as their unique keys were not scoped to an aggregate
+// instance.
+var legacyIndexes = map[string][]string{
+	AggregateModelCollection:        {"instance_index", "aggregate_id_index"},
+	AggregateEventCommitCollection:  {"commit_id", "version", "instance_aggregate_index"},
+	AggregateCommitHeaderCollection: {"commit_id", "version", "instance_aggregate_index"},
+	AggregateDispatchCollection:     {"commit_id", "instance_aggregate_index"},
+	SnapshotCollection:              {"instance_id", "aggregate_id", "revision"},
+}
+
+// ensureIndexes creates the indexes of the provided collections if missing.
+func ensureIndexes(zdb *mgo.Database, collections ...string) error {
+	for _, collection := range collections {
+		col := zdb.C(collection)
+		for _, index := range collectionIndexes[collection] {
+			if err := col.EnsureIndex(index); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EnsureIndexes creates the indexes of all collections used by the repositories if
+// missing.
+func EnsureIndexes(db MongoDB) error {
+	zdb, zes, err := db.New(false)
+	if err != nil {
+		return err
+	}
+
+	defer zes.Close()
+
+	for collection := range collectionIndexes {
+		if err := ensureIndexes(zdb, collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DroppedIndex embodies a index removed from a collection by MigrateIndexes.
+type DroppedIndex struct {
+	Collection string
+	Name       string
+}
+
+// MigrateIndexes rebuilds the indexes of databases created with older versions of the
+// repositories, where unique keys like version, revision and commit_id were not scoped
+// to an aggregate instance. The current indexes are created first, so uniqueness is never
+// left unguarded, after which the legacy indexes they replace are dropped. Any other index,
+// like those created by operators, is left alone. The dropped indexes are returned.
+func MigrateIndexes(db MongoDB) ([]DroppedIndex, error) {
+	zdb, zes, err := db.New(false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zes.Close()
+
+	var dropped []DroppedIndex
+	for collection := range collectionIndexes {
+		if err := ensureIndexes(zdb, collection); err != nil {
+			return dropped, err
+		}
+
+		legacy := map[string]bool{}
+		for _, name := range legacyIndexes[collection] {
+			legacy[name] = true
+		}
+
+		col := zdb.C(collection)
+		existing, err := col.Indexes()
+		if err != nil {
+			return dropped, err
+		}
+
+		for _, index := range existing {
+			if !legacy[index.Name] {
+				continue
+			}
+
+			if err := col.DropIndexName(index.Name); err != nil {
+				return dropped, err
+			}
+
+			dropped = append(dropped, DroppedIndex{
+				Collection: collection,
+				Name:       index.Name,
+			})
+		}
+	}
+
+	return dropped, nil
+}
@@ -0,0 +1,178 @@
+package mgorp
+
+import (
+	"context"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+//*******************************************************************************
+// Stream Lifecycle Implementation
+//*******************************************************************************
+
+// checkTombstone returns a cqrskit.StreamDeletedError if the stream of the aggregate instance
+// has being tombstoned.
+func checkTombstone(zdb *mgo.Database, aggregateID string, instanceID string) error {
+	var model AggregateModel
+
+	instQuery := bson.M{
+		"aggregate_id": aggregateID,
+		"instance_id":  instanceID,
+		"deleted":      bson.M{"$exists": true},
+	}
+
+	if err := zdb.C(AggregateModelCollection).Find(instQuery).One(&model); err != nil {
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return cqrskit.StreamDeletedError{
+		AggregateID: aggregateID,
+		InstanceID:  instanceID,
+		Deleted:     model.Deleted,
+	}
+}
+
+// Tombstone soft deletes the stream of the aggregate instance. The commits of the stream are
+// kept but marked as deleted, so queries across aggregates skip them, and all further writes
+// and reads of the stream return a cqrskit.StreamDeletedError.
+func (mwr *MgoWriteRepository) Tombstone(ctx context.Context) error {
+	zdb, zes, err := session(ctx, mwr.db, false)
+	if err != nil {
+		return err
+	}
+
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mwr.aggregateID, mwr.instanceID); err != nil {
+		return contextErr(ctx, err)
+	}
+
+	lvQuery := bson.M{
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+	}
+
+	deleted := bson.M{"$set": bson.M{"deleted": time.Now()}}
+
+	// mark the commits first, so a failure leaves the stream open to another attempt.
+	if _, err := zdb.C(AggregateEventCommitCollection).UpdateAll(lvQuery, deleted); err != nil {
+		return contextErr(ctx, err)
+	}
+
+	if _, err := zdb.C(AggregateModelCollection).UpdateAll(lvQuery, deleted); err != nil {
+		return contextErr(ctx, err)
+	}
+
+	return nil
+}
+
+// Delete hard deletes the stream of the aggregate instance, removing it's commits, headers,
+// dispatch records, snapshots and instance record. The total commits removed is returned.
+// Writing to the instance afterwards starts a new stream from the first version.
+func (mwr *MgoWriteRepository) Delete(ctx context.Context) (int, error) {
+	removed, err := mwr.DeleteAll(ctx)
+	if err != nil {
+		return removed, err
+	}
+
+	zdb, zes, err := session(ctx, mwr.db, false)
+	if err != nil {
+		return removed, err
+	}
+
+	defer zes.Close()
+
+	lvQuery := bson.M{
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+	}
+
+	if _, err := zdb.C(SnapshotCollection).RemoveAll(lvQuery); err != nil {
+		return removed, contextErr(ctx, err)
+	}
+
+	if err := zdb.C(AggregateStreamCollection).RemoveId(mwr.streamID()); err != nil && err != mgo.ErrNotFound {
+		return removed, contextErr(ctx, err)
+	}
+
+	if _, err := zdb.C(AggregateModelCollection).RemoveAll(lvQuery); err != nil {
+		return removed, contextErr(ctx, err)
+	}
+
+	return removed, nil
+}
+
+// TruncateBefore removes all commits of the aggregate instance below the giving version, along
+// with their headers and dispatch records, returning total commits removed. The version must not
+// be above the last commit version, so the last commit is always kept and versions continue from
+// it, else ErrTruncateBeyondLastCommit is returned.
+func (mwr *MgoWriteRepository) TruncateBefore(ctx context.Context, version int64) (int, error) {
+	zdb, zes, err := session(ctx, mwr.db, false)
+	if err != nil {
+		return -1, err
+	}
+
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mwr.aggregateID, mwr.instanceID); err != nil {
+		return -1, contextErr(ctx, err)
+	}
+
+	lastHeader, err := mwr.LastCommitVersion(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	if version > int64(lastHeader.Version) {
+		return -1, ErrTruncateBeyondLastCommit
+	}
+
+	lvQuery := bson.M{
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+		"version":      bson.M{"$lt": version},
+	}
+
+	var truncated []struct {
+		CommitID string `bson:"commit_id"`
+	}
+
+	if err := zdb.C(AggregateEventCommitCollection).Find(lvQuery).Select(bson.M{"commit_id": 1}).All(&truncated); err != nil {
+		return -1, contextErr(ctx, err)
+	}
+
+	if len(truncated) == 0 {
+		return 0, nil
+	}
+
+	commitIDs := make([]string, 0, len(truncated))
+	for _, commit := range truncated {
+		commitIDs = append(commitIDs, commit.CommitID)
+	}
+
+	// remove dispatch records first, so a failure never leaves dispatches of missing commits.
+	if _, err := zdb.C(AggregateDispatchCollection).RemoveAll(bson.M{
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+		"commit_id":    bson.M{"$in": commitIDs},
+	}); err != nil {
+		return -1, contextErr(ctx, err)
+	}
+
+	info, err := zdb.C(AggregateEventCommitCollection).RemoveAll(lvQuery)
+	if err != nil {
+		return -1, contextErr(ctx, err)
+	}
+
+	if _, err := zdb.C(AggregateCommitHeaderCollection).RemoveAll(lvQuery); err != nil {
+		return info.Removed, contextErr(ctx, err)
+	}
+
+	return info.Removed, nil
+}
@@ -120,19 +120,24 @@ func (m *MongoDBImpl) New(isread bool) (*mgo.Database, *mgo.Session, error) {
 	m.ml.Lock()
 	defer m.ml.Unlock()
 
-	// if m.master is alive then continue else, reset as empty.
+	// if m.master is alive then continue else, close and reset as empty.
 	if m.master != nil {
 		if err := m.master.Ping(); err != nil {
+			m.master.Close()
 			m.master = nil
 		}
 	}
 
-	ses, err := getSession(m.Config)
-	if err != nil {
-		return nil, nil, err
-	}
+	// dial only when no live master exists, as every dialed session holds it's own
+	// pool of sockets.
+	if m.master == nil {
+		ses, err := getSession(m.Config)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	m.master = ses
+		m.master = ses
+	}
 
 	if isread {
 		copy := m.master.Copy()
@@ -7,21 +7,26 @@ package mgorp
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/gokit/cqrskit"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
 )
 
 // errors ....
 var (
-	ErrInvalidAggregateID     = errors.New("invalid aggregate id")
-	ErrInvalidInstanceID      = errors.New("invalid instance id")
-	ErrNoCommitsYet           = errors.New("no commits has being made")
-	ErrInvalidDispatchID      = errors.New("invalid dispatch id, expected ObjectID hex")
-	ErrConcurrentWrites       = errors.New("concurrent write occured; version used")
-	ErrDuplicateCommitRequest = errors.New("request commit id handld, duplicate request")
+	ErrInvalidAggregateID       = errors.New("invalid aggregate id")
+	ErrInvalidInstanceID        = errors.New("invalid instance id")
+	ErrNoCommitsYet             = errors.New("no commits has being made")
+	ErrInvalidDispatchID        = errors.New("invalid dispatch id, expected ObjectID hex")
+	ErrConcurrentWrites         = cqrskit.ErrConcurrentWrites
+	ErrDuplicateCommitRequest   = errors.New("request commit id handld, duplicate request")
+	ErrInvalidCursor            = errors.New("invalid page cursor")
+	ErrTruncateBeyondLastCommit = errors.New("truncate version beyond last commit version")
+	ErrWriteModeMismatch        = errors.New("store is written in another write mode, leases and two-phase commits can not be mixed")
 )
 
 // consts values of aggregate collection names.
@@ -32,6 +37,15 @@ const (
 	AggregateDispatchCollection     = "aggregates_model_event_dispatch"
 	AggregateEventCommitCollection  = "aggregates_model_event_commits"
 	AggregateCommitHeaderCollection = "aggregates_model_event_commit_header"
+	AggregateStreamCollection       = "aggregates_model_event_streams"
+	AggregateTxnCollection          = "aggregates_model_event_txns"
+	AggregateWriteModeCollection    = "aggregates_write_mode"
+)
+
+// consts values of the write modes of a store.
+const (
+	LeaseWriteMode    = "leases"
+	TwoPhaseWriteMode = "two-phase"
 )
 
 // MongoDB defines a interface which exposes a method for retrieving a
@@ -79,6 +93,7 @@ type AggregateModel struct {
 	Id           bson.ObjectId `bson:"_id"`
 	InstanceID   string        `bson:"instance_id"`
 	AggregatedID string        `bson:"aggregate_id"`
+	Deleted      time.Time     `bson:"deleted,omitempty"`
 }
 
 //*******************************************************************************
@@ -98,11 +113,13 @@ func NewSnapshotWriters(db MongoDB) MgoSnapshotWriters {
 // Writer attempts to retrieve aggregate WriteRepo instance for writing snapshots for a giving
 // instance of an aggregate model. If aggregate record does not exists, it will be created.
 func (mw MgoSnapshotWriters) Writer(aggregateID string, instanceID string) (cqrskit.SnapshotWriter, error) {
-	zdb, _, err := mw.db.New(true)
+	zdb, zes, err := mw.db.New(true)
 	if err != nil {
 		return nil, err
 	}
 
+	defer zes.Close()
+
 	snapshots := zdb.C(SnapshotCollection)
 	total, err := snapshots.Count()
 	if err != nil && err != mgo.ErrNotFound {
@@ -110,33 +127,7 @@ func (mw MgoSnapshotWriters) Writer(aggregateID string, instanceID string) (cqrs
 	}
 
 	if total == 0 {
-		if err := snapshots.EnsureIndex(mgo.Index{
-			Key:  []string{"instance_id"},
-			Name: "instance_id",
-		}); err != nil {
-			return nil, err
-		}
-
-		if err := snapshots.EnsureIndex(mgo.Index{
-			Key:  []string{"aggregate_id"},
-			Name: "aggregate_id",
-		}); err != nil {
-			return nil, err
-		}
-
-		if err := snapshots.EnsureIndex(mgo.Index{
-			Key:    []string{"revision"},
-			Name:   "revision",
-			Unique: true,
-		}); err != nil {
-			return nil, err
-		}
-
-		if err := snapshots.EnsureIndex(mgo.Index{
-			Key:    []string{"snap_id"},
-			Name:   "snap_id",
-			Unique: true,
-		}); err != nil {
+		if err := ensureIndexes(zdb, SnapshotCollection); err != nil {
 			return nil, err
 		}
 	}
@@ -159,7 +150,7 @@ type MgoSnapshotWriter struct {
 
 // Write attempts to add new snapshot into store.
 func (msw MgoSnapshotWriter) Write(ctx context.Context, snap cqrskit.Snapshot) error {
-	zdb, zes, err := msw.db.New(false)
+	zdb, zes, err := session(ctx, msw.db, false)
 	if err != nil {
 		return err
 	}
@@ -167,28 +158,31 @@ func (msw MgoSnapshotWriter) Write(ctx context.Context, snap cqrskit.Snapshot) e
 	defer zes.Close()
 
 	snapshots := zdb.C(msw.collection)
-	return snapshots.Insert(snap)
+	return contextErr(ctx, snapshots.Insert(snap))
 }
 
 // Count returns total snapshots available for aggregate type and instance model.
 func (msw MgoSnapshotWriter) Count(ctx context.Context) (int, error) {
-	zdb, _, err := msw.db.New(true)
+	zdb, zes, err := session(ctx, msw.db, true)
 	if err != nil {
 		return -1, err
 	}
 
+	defer zes.Close()
+
 	query := bson.M{
 		"aggregate_id": msw.aggregateID,
 		"instance_id":  msw.instanceID,
 	}
 
 	snapshots := zdb.C(msw.collection)
-	return snapshots.Find(query).Count()
+	total, err := snapshots.Find(query).Count()
+	return total, contextErr(ctx, err)
 }
 
 // Rewrite attempts to rewrite existing snapshot with using provided revision value and replacement snapshot.
 func (msw MgoSnapshotWriter) Rewrite(ctx context.Context, revision int, snap cqrskit.Snapshot) error {
-	zdb, zes, err := msw.db.New(false)
+	zdb, zes, err := session(ctx, msw.db, false)
 	if err != nil {
 		return err
 	}
@@ -212,7 +206,7 @@ func (msw MgoSnapshotWriter) Rewrite(ctx context.Context, revision int, snap cqr
 		"from_version": snap.FromVersion,
 	}
 
-	return snapshots.Update(query, bson.M{"$set": value})
+	return contextErr(ctx, snapshots.Update(query, bson.M{"$set": value}))
 }
 
 //*******************************************************************************
@@ -251,11 +245,13 @@ type MgoSnapshotReader struct {
 func (msw MgoSnapshotReader) ReadRevision(ctx context.Context, revision int) (cqrskit.Snapshot, error) {
 	var snap cqrskit.Snapshot
 
-	zdb, _, err := msw.db.New(true)
+	zdb, zes, err := session(ctx, msw.db, true)
 	if err != nil {
 		return snap, err
 	}
 
+	defer zes.Close()
+
 	snapshots := zdb.C(msw.collection)
 
 	query := bson.M{
@@ -265,16 +261,18 @@ func (msw MgoSnapshotReader) ReadRevision(ctx context.Context, revision int) (cq
 	}
 
 	err = snapshots.Find(query).One(&snap)
-	return snap, err
+	return snap, contextErr(ctx, err)
 }
 
 // Count returns total snapshots available for aggregate type and instance model.
 func (msw MgoSnapshotReader) Count(ctx context.Context) (int, error) {
-	zdb, _, err := msw.db.New(true)
+	zdb, zes, err := session(ctx, msw.db, true)
 	if err != nil {
 		return -1, err
 	}
 
+	defer zes.Close()
+
 	snapshots := zdb.C(msw.collection)
 
 	query := bson.M{
@@ -283,18 +281,20 @@ func (msw MgoSnapshotReader) Count(ctx context.Context) (int, error) {
 	}
 
 	total, err := snapshots.Find(query).Count()
-	return total, err
+	return total, contextErr(ctx, err)
 }
 
 // ReadID returns snapshot data referenced by the provided snapshot id.
 func (msw MgoSnapshotReader) ReadID(ctx context.Context, id string) (cqrskit.Snapshot, error) {
 	var snap cqrskit.Snapshot
 
-	zdb, _, err := msw.db.New(true)
+	zdb, zes, err := session(ctx, msw.db, true)
 	if err != nil {
 		return snap, err
 	}
 
+	defer zes.Close()
+
 	snapshots := zdb.C(msw.collection)
 
 	query := bson.M{
@@ -304,16 +304,18 @@ func (msw MgoSnapshotReader) ReadID(ctx context.Context, id string) (cqrskit.Sna
 	}
 
 	err = snapshots.Find(query).One(&snap)
-	return snap, err
+	return snap, contextErr(ctx, err)
 }
 
 // ReadAll returns a slice of all available sanpshot data in the db.
 func (msw MgoSnapshotReader) ReadAll(ctx context.Context) ([]cqrskit.Snapshot, error) {
-	zdb, _, err := msw.db.New(true)
+	zdb, zes, err := session(ctx, msw.db, true)
 	if err != nil {
 		return nil, err
 	}
 
+	defer zes.Close()
+
 	snapshots := zdb.C(msw.collection)
 
 	query := bson.M{
@@ -323,16 +325,18 @@ func (msw MgoSnapshotReader) ReadAll(ctx context.Context) ([]cqrskit.Snapshot, e
 
 	var snaps []cqrskit.Snapshot
 	err = snapshots.Find(query).All(&snaps)
-	return snaps, err
+	return snaps, contextErr(ctx, err)
 }
 
 // ReadVersion returns all snapshot whoes version spans withinthe from - to range.
 func (msw MgoSnapshotReader) ReadVersion(ctx context.Context, from int, to int) ([]cqrskit.Snapshot, error) {
-	zdb, _, err := msw.db.New(true)
+	zdb, zes, err := session(ctx, msw.db, true)
 	if err != nil {
 		return nil, err
 	}
 
+	defer zes.Close()
+
 	snapshots := zdb.C(msw.collection)
 
 	query := bson.M{
@@ -344,7 +348,7 @@ func (msw MgoSnapshotReader) ReadVersion(ctx context.Context, from int, to int)
 
 	var snaps []cqrskit.Snapshot
 	err = snapshots.Find(query).All(&snaps)
-	return snaps, err
+	return snaps, contextErr(ctx, err)
 }
 
 //*******************************************************************************
@@ -354,22 +358,54 @@ func (msw MgoSnapshotReader) ReadVersion(ctx context.Context, from int, to int)
 // MgoWriteMaser implements the cqrskit.WriteRepository interface exposing
 // methods to have a direct writer for a giving aggregate and and instance.
 type MgoWriteMaster struct {
-	db MongoDB
+	db       MongoDB
+	twoPhase bool
+	claim    *writeModeClaim
+}
+
+// writeModeClaim records whether the write mode of a MgoWriteMaster was claimed for the
+// store, so it is only claimed once.
+type writeModeClaim struct {
+	ml      sync.Mutex
+	claimed bool
 }
 
 // NewWriteMaster returns a new instance of MgoWriteMaster.
 func NewWriteMaster(db MongoDB) MgoWriteMaster {
-	return MgoWriteMaster{db: db}
+	return MgoWriteMaster{db: db, claim: &writeModeClaim{}}
+}
+
+// NewTwoPhaseWriteMaster returns a new instance of MgoWriteMaster whose repositories write a
+// EventCommit, it's CommitHeader and dispatch record through a two-phase commit run by the
+// client with the mgo txn package, instead of through version leases, where either all
+// records are written or, once the commit is resumed, none. See MgoWriteRepository.Write.
+//
+// Two-phase commits are not transactions of the server and are not isolated: readers may
+// see some of the records of a commit before all are written, till the commit completes or
+// is resumed by the next commit of the aggregate instance or by RepairLeases. Only other
+// two-phase commits see their pending writes, so leases and two-phase commits can not be
+// mixed within a store. The first write master to get a writer records it's write mode into
+// the AggregateWriteModeCollection, and masters of the other mode get an ErrWriteModeMismatch
+// from then on. A store is switched to the other mode by removing the record once all
+// writers of the old mode are stopped.
+func NewTwoPhaseWriteMaster(db MongoDB) MgoWriteMaster {
+	return MgoWriteMaster{db: db, twoPhase: true, claim: &writeModeClaim{}}
 }
 
 // Writer attempts to retrieve aggregate WriteRepo instance for writing events for a giving
 // instance of an aggregate model. If aggregate record does not exists, it will be created.
 func (mw MgoWriteMaster) Writer(aggregateID string, instanceID string) (cqrskit.WriteRepo, error) {
-	zdb, _, err := mw.db.New(true)
+	if err := mw.claimWriteMode(); err != nil {
+		return nil, err
+	}
+
+	zdb, zes, err := mw.db.New(true)
 	if err != nil {
 		return nil, err
 	}
 
+	defer zes.Close()
+
 	zcol := zdb.C(AggregateCollection)
 	icol := zdb.C(AggregateModelCollection)
 
@@ -397,9 +433,75 @@ func (mw MgoWriteMaster) Writer(aggregateID string, instanceID string) (cqrskit.
 		db:          mw.db,
 		instanceID:  instanceID,
 		aggregateID: aggregateID,
+		twoPhase:    mw.twoPhase,
 	}, nil
 }
 
+// writeMode returns the write mode of the master.
+func (mw MgoWriteMaster) writeMode() string {
+	if mw.twoPhase {
+		return TwoPhaseWriteMode
+	}
+	return LeaseWriteMode
+}
+
+// claimWriteMode records the write mode of the master as that of the store, if none is
+// recorded yet, returning an ErrWriteModeMismatch if the store has another write mode.
+// The store is only asked till it's mode is claimed, as the record is never changed
+// while writers of the mode run.
+func (mw MgoWriteMaster) claimWriteMode() error {
+	if mw.claim != nil {
+		mw.claim.ml.Lock()
+		defer mw.claim.ml.Unlock()
+
+		if mw.claim.claimed {
+			return nil
+		}
+	}
+
+	zdb, zes, err := mw.db.New(false)
+	if err != nil {
+		return err
+	}
+
+	defer zes.Close()
+
+	var record struct {
+		Mode string `bson:"mode"`
+	}
+
+	modes := zdb.C(AggregateWriteModeCollection)
+	if err := modes.FindId(AggregateWriteModeCollection).One(&record); err != nil {
+		if err != mgo.ErrNotFound {
+			return err
+		}
+
+		record.Mode = mw.writeMode()
+
+		// A master of the other mode claiming the store at the same time is caught by
+		// the unique _id of the record, so the record is read again.
+		if err := modes.Insert(bson.M{"_id": AggregateWriteModeCollection, "mode": record.Mode}); err != nil {
+			if !mgo.IsDup(err) {
+				return err
+			}
+
+			if err := modes.FindId(AggregateWriteModeCollection).One(&record); err != nil {
+				return err
+			}
+		}
+	}
+
+	if record.Mode != mw.writeMode() {
+		return ErrWriteModeMismatch
+	}
+
+	if mw.claim != nil {
+		mw.claim.claimed = true
+	}
+
+	return nil
+}
+
 // New returns a new cqrskit.WriteRepo for a giving aggregateID and instanceID, if giving
 // aggregate is not found then a record is created and same logic applies for the instance.
 func (mw *MgoWriteMaster) new(aggregateID string, instanceID string) (cqrskit.WriteRepo, error) {
@@ -443,23 +545,18 @@ func (mw *MgoWriteMaster) new(aggregateID string, instanceID string) (cqrskit.Wr
 		db:          mw.db,
 		instanceID:  instanceID,
 		aggregateID: aggregateID,
+		twoPhase:    mw.twoPhase,
 	}, nil
 }
 
 func (mw *MgoWriteMaster) createAggregateModel(aggregateID string, instanceID string, zdb *mgo.Database) error {
-	icol := zdb.C(AggregateModelCollection)
-	if err := icol.EnsureIndex(mgo.Index{
-		Key:    []string{"instance_id"},
-		Unique: true,
-		Name:   "instance_index",
-	}); err != nil {
-		return err
-	}
-
-	if err := icol.EnsureIndex(mgo.Index{
-		Key:  []string{"aggregate_id"},
-		Name: "aggregate_id_index",
-	}); err != nil {
+	if err := ensureIndexes(
+		zdb,
+		AggregateModelCollection,
+		AggregateEventCommitCollection,
+		AggregateCommitHeaderCollection,
+		AggregateDispatchCollection,
+	); err != nil {
 		return err
 	}
 
@@ -468,99 +565,11 @@ func (mw *MgoWriteMaster) createAggregateModel(aggregateID string, instanceID st
 	model.InstanceID = instanceID
 	model.AggregatedID = aggregateID
 
-	if err := icol.Insert(model); err != nil {
-		return err
-	}
-
-	// Add index to event collection for aggregate model.
-	cmCol := zdb.C(AggregateEventCommitCollection)
-	if err := cmCol.EnsureIndex(mgo.Index{
-		Key:    []string{"commit_id"},
-		Unique: true,
-		Name:   "commit_id",
-	}); err != nil {
-		return err
-	}
-
-	if err := cmCol.EnsureIndex(mgo.Index{
-		Key:    []string{"version"},
-		Unique: true,
-		Name:   "version",
-	}); err != nil {
-		return err
-	}
-
-	if err := cmCol.EnsureIndex(mgo.Index{
-		Key:  []string{"created"},
-		Name: "created",
-	}); err != nil {
-		return err
-	}
-
-	if err := cmCol.EnsureIndex(mgo.Index{
-		Key:  []string{"instance_id", "aggregate_id"},
-		Name: "instance_aggregate_index",
-	}); err != nil {
-		return err
-	}
-
-	cmhCol := zdb.C(AggregateCommitHeaderCollection)
-	if err := cmhCol.EnsureIndex(mgo.Index{
-		Key:    []string{"commit_id"},
-		Unique: true,
-		Name:   "commit_id",
-	}); err != nil {
-		return err
-	}
-
-	if err := cmhCol.EnsureIndex(mgo.Index{
-		Key:    []string{"version"},
-		Unique: true,
-		Name:   "version",
-	}); err != nil {
-		return err
-	}
-
-	if err := cmhCol.EnsureIndex(mgo.Index{
-		Key:  []string{"timestamp"},
-		Name: "timestamp",
-	}); err != nil {
-		return err
-	}
-
-	if err := cmhCol.EnsureIndex(mgo.Index{
-		Key:  []string{"instance_id", "aggregate_id"},
-		Name: "instance_aggregate_index",
-	}); err != nil {
-		return err
-	}
-
-	dhCol := zdb.C(AggregateDispatchCollection)
-	if err := dhCol.EnsureIndex(mgo.Index{
-		Key:    []string{"commit_id"},
-		Unique: true,
-		Name:   "commit_id",
-	}); err != nil {
-		return err
-	}
-
-	if err := dhCol.EnsureIndex(mgo.Index{
-		Key:  []string{"instance_id", "aggregate_id"},
-		Name: "instance_aggregate_index",
-	}); err != nil {
-		return err
-	}
-
-	return nil
+	return zdb.C(AggregateModelCollection).Insert(model)
 }
 
 func (mw *MgoWriteMaster) createAggregate(aggregateID string, zdb *mgo.Database) error {
-	zcol := zdb.C(AggregateCollection)
-	if err := zcol.EnsureIndex(mgo.Index{
-		Key:    []string{"aggregate_id"},
-		Unique: true,
-		Name:   "aggregate_index",
-	}); err != nil {
+	if err := ensureIndexes(zdb, AggregateCollection); err != nil {
 		return err
 	}
 
@@ -568,7 +577,7 @@ func (mw *MgoWriteMaster) createAggregate(aggregateID string, zdb *mgo.Database)
 	aggr.Id = bson.NewObjectId()
 	aggr.AggregateID = aggregateID
 
-	return zcol.Insert(aggr)
+	return zdb.C(AggregateCollection).Insert(aggr)
 }
 
 // MgoWriteRepository implements the cqrskit.WriteRepo
@@ -577,12 +586,13 @@ type MgoWriteRepository struct {
 	db          MongoDB
 	aggregateID string
 	instanceID  string
+	twoPhase    bool
 }
 
 // DeleteAll removes all record associated with giving event and returns total
 // records of all event records removed.
 func (mwr *MgoWriteRepository) DeleteAll(ctx context.Context) (int, error) {
-	zdb, zes, err := mwr.db.New(false)
+	zdb, zes, err := session(ctx, mwr.db, false)
 	if err != nil {
 		return -1, err
 	}
@@ -597,15 +607,15 @@ func (mwr *MgoWriteRepository) DeleteAll(ctx context.Context) (int, error) {
 	mc := zdb.C(AggregateEventCommitCollection)
 	info, err := mc.RemoveAll(lvQuery)
 	if err != nil {
-		return -1, err
+		return -1, contextErr(ctx, err)
 	}
 
 	if _, err = zdb.C(AggregateCommitHeaderCollection).RemoveAll(lvQuery); err != nil {
-		return info.Removed, err
+		return info.Removed, contextErr(ctx, err)
 	}
 
 	if _, err = zdb.C(AggregateDispatchCollection).RemoveAll(lvQuery); err != nil {
-		return info.Removed, err
+		return info.Removed, contextErr(ctx, err)
 	}
 
 	return info.Removed, nil
@@ -613,11 +623,13 @@ func (mwr *MgoWriteRepository) DeleteAll(ctx context.Context) (int, error) {
 
 // Count returns total count of all commited events for giving aggregate and instance.
 func (mwr *MgoWriteRepository) Count(ctx context.Context) (int, error) {
-	zdb, _, err := mwr.db.New(true)
+	zdb, zes, err := session(ctx, mwr.db, true)
 	if err != nil {
 		return -1, err
 	}
 
+	defer zes.Close()
+
 	mc := zdb.C(AggregateEventCommitCollection)
 
 	lvQuery := bson.M{
@@ -627,7 +639,7 @@ func (mwr *MgoWriteRepository) Count(ctx context.Context) (int, error) {
 
 	total, err := mc.Find(lvQuery).Count()
 	if err != nil {
-		return -1, err
+		return -1, contextErr(ctx, err)
 	}
 
 	return total, nil
@@ -638,7 +650,7 @@ func (mwr *MgoWriteRepository) Count(ctx context.Context) (int, error) {
 func (mwr *MgoWriteRepository) LastCommitVersion(ctx context.Context) (cqrskit.CommitHeader, error) {
 	var header cqrskit.CommitHeader
 
-	zdb, zes, err := mwr.db.New(true)
+	zdb, zes, err := session(ctx, mwr.db, true)
 	if err != nil {
 		return header, err
 	}
@@ -654,7 +666,7 @@ func (mwr *MgoWriteRepository) LastCommitVersion(ctx context.Context) (cqrskit.C
 
 	if err := commitCollection.Find(lvQuery).Sort("-version").One(&header); err != nil {
 		if err != mgo.ErrNotFound {
-			return header, err
+			return header, contextErr(ctx, err)
 		}
 
 		return header, ErrNoCommitsYet
@@ -667,15 +679,27 @@ func (mwr *MgoWriteRepository) LastCommitVersion(ctx context.Context) (cqrskit.C
 // the ff follows true:
 // 1. Request CommitID has not being seen or handled before.
 // 2. Request does not attempt to conflict with version that has already being taking.
+// 3. Request Version, if above zero, is the version the commit gets.
 // In each case an appropriate error is returned to indicate status of request.
+//
+// Repositories from a two-phase MgoWriteMaster write the commit, it's header and dispatch
+// record through a two-phase commit, else version leases are used.
 func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommitRequest) (cqrskit.CommitHeader, error) {
-	zdb, zes, err := mwr.db.New(false)
+	if mwr.twoPhase {
+		return mwr.writeTxn(ctx, req)
+	}
+
+	zdb, zes, err := session(ctx, mwr.db, false)
 	if err != nil {
 		return cqrskit.CommitHeader{}, err
 	}
 
 	defer zes.Close()
 
+	if err := checkTombstone(zdb, mwr.aggregateID, mwr.instanceID); err != nil {
+		return cqrskit.CommitHeader{}, contextErr(ctx, err)
+	}
+
 	dispatchCollection := zdb.C(AggregateDispatchCollection)
 	commitCollection := zdb.C(AggregateEventCommitCollection)
 	commitHeaderCollection := zdb.C(AggregateCommitHeaderCollection)
@@ -690,13 +714,19 @@ func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommi
 
 	totalFound, err := commitCollection.Find(probeQuery).Count()
 	if err != nil && err != mgo.ErrNotFound {
-		return header.CommitHeader, err
+		return header.CommitHeader, contextErr(ctx, err)
 	}
 
 	if err == nil && totalFound == 1 {
 		return header.CommitHeader, ErrDuplicateCommitRequest
 	}
 
+	// Get last version number, which any lease we use must be ahead of.
+	lastHeader, err := mwr.LastCommitVersion(ctx)
+	if err != nil && err != ErrNoCommitsYet {
+		return header.CommitHeader, contextErr(ctx, err)
+	}
+
 	// Attempt to get current leased header.
 	leaseQuery := bson.M{
 		"commit_id":    "",
@@ -704,63 +734,69 @@ func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommi
 		"instance_id":  mwr.instanceID,
 	}
 
-	var dispatchHeader CommitDispatchHeader
+	leaseErr := commitHeaderCollection.Find(leaseQuery).One(&header)
+	if leaseErr != nil && leaseErr != mgo.ErrNotFound {
+		return header.CommitHeader, contextErr(ctx, leaseErr)
+	}
 
-	if err := commitHeaderCollection.Find(leaseQuery).One(&header); err != nil {
-		if err != mgo.ErrNotFound {
-			return header.CommitHeader, err
+	// A lease whose version was taken by another commit is stale and will never
+	// succeed, so remove it and lease out a new version.
+	if leaseErr == nil && header.Version <= lastHeader.Version {
+		if err := commitHeaderCollection.RemoveId(header.ID); err != nil && err != mgo.ErrNotFound {
+			return header.CommitHeader, contextErr(ctx, err)
 		}
 
-		// Get last version number and lease out next version number for self.
-		lastHeader, err := mwr.LastCommitVersion(ctx)
-		if err != nil && err != ErrNoCommitsYet {
-			return header.CommitHeader, err
-		}
+		leaseErr = mgo.ErrNotFound
+	}
 
+	if leaseErr == mgo.ErrNotFound {
 		header.ID = bson.NewObjectId()
 		header.CommitID = ""
 		header.Version = lastHeader.Version + 1
 		header.InstanceID = mwr.instanceID
 		header.AggregateID = mwr.aggregateID
 
-		dispatchHeader.ID = bson.NewObjectId()
-		dispatchHeader.CommitID = ""
-		dispatchHeader.InstanceID = mwr.instanceID
-		dispatchHeader.AggregateID = mwr.aggregateID
-		dispatchHeader.DispatchID = dispatchHeader.ID.Hex()
-
 		// Register new lease into commit header, requesting weak lock on version.
 		if err := commitHeaderCollection.Insert(bson.M{
 			"_id":          header.ID,
 			"commit_id":    header.CommitID,
+			"leased":       time.Now(),
 			"version":      header.Version,
 			"instance_id":  header.InstanceID,
 			"aggregate_id": header.AggregateID,
 		}); err != nil {
-			return header.CommitHeader, err
-		}
+			// Another write leasing the same version, or holding a lease of the instance,
+			// is caught by the unique indexes of the commit header.
+			if mgo.IsDup(err) {
+				return header.CommitHeader, ErrConcurrentWrites
+			}
 
-		if err := dispatchCollection.Insert(bson.M{
-			"_id":          dispatchHeader.ID,
-			"commit_id":    dispatchHeader.CommitID,
-			"dispatch_id":  dispatchHeader.DispatchID,
-			"instance_id":  dispatchHeader.InstanceID,
-			"aggregate_id": dispatchHeader.AggregateID,
-		}); err != nil {
-			return header.CommitHeader, err
+			return header.CommitHeader, contextErr(ctx, err)
 		}
 	}
 
-	total, err := dispatchCollection.Find(leaseQuery).Count()
-	if err != nil && err != mgo.ErrNotFound {
-		return header.CommitHeader, err
+	// A commit requested at a version other than the one leased was made from an older
+	// state of the stream. Writes racing for the same version are caught by the unique
+	// version index instead.
+	if req.Version > 0 && header.Version != req.Version {
+		return header.CommitHeader, ErrConcurrentWrites
 	}
 
-	if total == 0 {
+	var dispatchHeader CommitDispatchHeader
+	var dispatchLease struct {
+		ID bson.ObjectId `bson:"_id"`
+	}
+
+	// Reuse the leased dispatch record if one exists, else lease out a new one.
+	if err := dispatchCollection.Find(leaseQuery).One(&dispatchLease); err != nil {
+		if err != mgo.ErrNotFound {
+			return header.CommitHeader, contextErr(ctx, err)
+		}
+
 		dispatchHeader.ID = bson.NewObjectId()
 		dispatchHeader.InstanceID = mwr.instanceID
 		dispatchHeader.AggregateID = mwr.aggregateID
-		dispatchHeader.DispatchID = dispatchHeader.ID.String()
+		dispatchHeader.DispatchID = dispatchHeader.ID.Hex()
 
 		if err := dispatchCollection.Insert(bson.M{
 			"_id":          dispatchHeader.ID,
@@ -769,30 +805,30 @@ func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommi
 			"instance_id":  dispatchHeader.InstanceID,
 			"aggregate_id": dispatchHeader.AggregateID,
 		}); err != nil {
-			return header.CommitHeader, err
+			return header.CommitHeader, contextErr(ctx, err)
 		}
+	} else {
+		dispatchHeader.ID = dispatchLease.ID
 	}
 
-	var eventCommit cqrskit.EventCommit
-	eventCommit.CommitID = req.ID
-	eventCommit.Events = req.Events
-	eventCommit.Header = req.Header
-	eventCommit.Command = req.Command
-	eventCommit.Created = req.Created
-	eventCommit.Version = header.Version
-	eventCommit.InstanceID = mwr.instanceID
-	eventCommit.AggregateID = mwr.aggregateID
+	// Abort before the commit is written if the context ended, as the lease is reused
+	// by the next write.
+	if err := ctx.Err(); err != nil {
+		return header.CommitHeader, ContextError{Cause: err}
+	}
 
+	eventCommit := mwr.eventCommit(req, header.Version)
 	if err := commitCollection.Insert(eventCommit); err != nil {
-		if lastErr, ok := err.(*mgo.LastError); ok {
-			return header.CommitHeader, lastErr
-		}
-
+		// Duplicate key errors are themselves a *mgo.LastError, so they are checked first.
 		if mgo.IsDup(err) {
 			return header.CommitHeader, ErrConcurrentWrites
 		}
 
-		return header.CommitHeader, err
+		if lastErr, ok := err.(*mgo.LastError); ok {
+			return header.CommitHeader, contextErr(ctx, lastErr)
+		}
+
+		return header.CommitHeader, contextErr(ctx, err)
 	}
 
 	commited := time.Now()
@@ -803,10 +839,10 @@ func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommi
 		},
 	}); err != nil {
 		if lastErr, ok := err.(*mgo.LastError); ok {
-			return header.CommitHeader, lastErr
+			return header.CommitHeader, contextErr(ctx, lastErr)
 		}
 
-		return header.CommitHeader, err
+		return header.CommitHeader, contextErr(ctx, err)
 	}
 
 	if err := dispatchCollection.UpdateId(dispatchHeader.ID, bson.M{
@@ -815,10 +851,10 @@ func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommi
 		},
 	}); err != nil {
 		if lastErr, ok := err.(*mgo.LastError); ok {
-			return header.CommitHeader, lastErr
+			return header.CommitHeader, contextErr(ctx, lastErr)
 		}
 
-		return header.CommitHeader, err
+		return header.CommitHeader, contextErr(ctx, err)
 	}
 
 	header.Timestamp = commited
@@ -827,6 +863,316 @@ func (mwr *MgoWriteRepository) Write(ctx context.Context, req cqrskit.EventCommi
 	return header.CommitHeader, nil
 }
 
+// streamRecord embodies the record kept for each aggregate instance by two-phase writes,
+// holding the last version committed. Every two-phase commit asserts it's version, so only
+// one of any concurrent writes for a version is ever applied.
+type streamRecord struct {
+	Version int `bson:"version"`
+}
+
+// writeTxn writes the EventCommit, it's CommitHeader and dispatch record through a two-phase
+// commit run by the mgo txn package, where either all records are written or none. A commit
+// interrupted midway is completed by the next commit touching the same aggregate instance, or
+// by RepairLeases.
+//
+// The two-phase commit is run from the client, not as a transaction of the server, so readers
+// may see the records of a commit being applied before all are written. Only the streamRecord,
+// which lease writes never update, guards the version of a commit, which is why stores are
+// kept to a single write mode.
+func (mwr *MgoWriteRepository) writeTxn(ctx context.Context, req cqrskit.EventCommitRequest) (cqrskit.CommitHeader, error) {
+	var header cqrskit.CommitHeader
+
+	zdb, zes, err := session(ctx, mwr.db, false)
+	if err != nil {
+		return header, err
+	}
+
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mwr.aggregateID, mwr.instanceID); err != nil {
+		return header, contextErr(ctx, err)
+	}
+
+	probeQuery := bson.M{
+		"commit_id":    req.ID,
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+	}
+
+	totalFound, err := zdb.C(AggregateEventCommitCollection).Find(probeQuery).Count()
+	if err != nil && err != mgo.ErrNotFound {
+		return header, contextErr(ctx, err)
+	}
+
+	if err == nil && totalFound != 0 {
+		return header, ErrDuplicateCommitRequest
+	}
+
+	lastHeader, err := mwr.LastCommitVersion(ctx)
+	if err != nil && err != ErrNoCommitsYet {
+		return header, contextErr(ctx, err)
+	}
+
+	streamID := mwr.streamID()
+	streamOp := txn.Op{C: AggregateStreamCollection, Id: streamID}
+
+	var stream streamRecord
+	if err := zdb.C(AggregateStreamCollection).FindId(streamID).One(&stream); err != nil {
+		if err != mgo.ErrNotFound {
+			return header, contextErr(ctx, err)
+		}
+
+		streamOp.Assert = txn.DocMissing
+	} else {
+		streamOp.Assert = bson.M{"version": stream.Version}
+	}
+
+	// Instances previously written with leases have no stream record yet, so the
+	// version is taken from whichever is ahead.
+	version := lastHeader.Version
+	if stream.Version > version {
+		version = stream.Version
+	}
+	version++
+
+	if req.Version > 0 && version != req.Version {
+		return header, ErrConcurrentWrites
+	}
+
+	if streamOp.Assert == txn.DocMissing {
+		streamOp.Insert = streamRecord{Version: version}
+	} else {
+		streamOp.Update = bson.M{"$set": bson.M{"version": version}}
+	}
+
+	commited := time.Now()
+	eventCommit := mwr.eventCommit(req, version)
+	dispatchID := bson.NewObjectId()
+
+	ops := []txn.Op{
+		streamOp,
+		{
+			C:      AggregateEventCommitCollection,
+			Id:     bson.NewObjectId(),
+			Assert: txn.DocMissing,
+			Insert: eventCommit,
+		},
+		{
+			C:      AggregateCommitHeaderCollection,
+			Id:     bson.NewObjectId(),
+			Assert: txn.DocMissing,
+			Insert: bson.M{
+				"timestamp":    commited,
+				"version":      version,
+				"commit_id":    eventCommit.CommitID,
+				"instance_id":  mwr.instanceID,
+				"aggregate_id": mwr.aggregateID,
+			},
+		},
+		{
+			C:      AggregateDispatchCollection,
+			Id:     dispatchID,
+			Assert: txn.DocMissing,
+			Insert: bson.M{
+				"commit_id":    eventCommit.CommitID,
+				"dispatch_id":  dispatchID.Hex(),
+				"instance_id":  mwr.instanceID,
+				"aggregate_id": mwr.aggregateID,
+			},
+		},
+	}
+
+	runner := txn.NewRunner(zdb.C(AggregateTxnCollection))
+	if err := runner.Run(ops, "", nil); err != nil {
+		if err == txn.ErrAborted {
+			return header, ErrConcurrentWrites
+		}
+
+		return header, contextErr(ctx, err)
+	}
+
+	header.Version = version
+	header.Timestamp = commited
+	header.CommitID = eventCommit.CommitID
+	header.InstanceID = mwr.instanceID
+	header.AggregateID = mwr.aggregateID
+
+	return header, nil
+}
+
+// streamID returns the id of the streamRecord of the aggregate instance.
+func (mwr *MgoWriteRepository) streamID() bson.D {
+	return bson.D{
+		{Name: "aggregate_id", Value: mwr.aggregateID},
+		{Name: "instance_id", Value: mwr.instanceID},
+	}
+}
+
+// eventCommit returns the EventCommit for the request at the giving version.
+func (mwr *MgoWriteRepository) eventCommit(req cqrskit.EventCommitRequest, version int) cqrskit.EventCommit {
+	var eventCommit cqrskit.EventCommit
+	eventCommit.CommitID = req.ID
+	eventCommit.Events = req.Events
+	eventCommit.Header = req.Header
+	eventCommit.Command = req.Command
+	eventCommit.Created = req.Created
+	eventCommit.Version = version
+	eventCommit.InstanceID = mwr.instanceID
+	eventCommit.AggregateID = mwr.aggregateID
+	return eventCommit
+}
+
+//*******************************************************************************
+// Lease Repair
+//*******************************************************************************
+
+// LeaseRepair embodies the total of stale lease records removed by RepairLeases.
+type LeaseRepair struct {
+	Headers    int
+	Dispatches int
+}
+
+// RepairLeases removes the stale leases left by writes of the aggregate instance which
+// failed midway. A leased CommitHeader is stale once it's version is taken by another
+// commit or it was leased longer than maxAge ago, where leases without a lease time are
+// always considered old. Leased dispatch records are removed once no leased CommitHeader
+// remains. For two-phase repositories, interrupted two-phase commits are completed first.
+func (mwr *MgoWriteRepository) RepairLeases(ctx context.Context, maxAge time.Duration) (LeaseRepair, error) {
+	var repair LeaseRepair
+
+	zdb, zes, err := session(ctx, mwr.db, false)
+	if err != nil {
+		return repair, err
+	}
+
+	defer zes.Close()
+
+	if mwr.twoPhase {
+		if err := txn.NewRunner(zdb.C(AggregateTxnCollection)).ResumeAll(); err != nil {
+			return repair, contextErr(ctx, err)
+		}
+	}
+
+	return mwr.repairLeases(ctx, zdb, maxAge)
+}
+
+// repairLeases removes the stale leases of the aggregate instance.
+func (mwr *MgoWriteRepository) repairLeases(ctx context.Context, zdb *mgo.Database, maxAge time.Duration) (LeaseRepair, error) {
+	var repair LeaseRepair
+
+	lastHeader, err := mwr.LastCommitVersion(ctx)
+	if err != nil && err != ErrNoCommitsYet {
+		return repair, contextErr(ctx, err)
+	}
+
+	leaseQuery := bson.M{
+		"commit_id":    "",
+		"aggregate_id": mwr.aggregateID,
+		"instance_id":  mwr.instanceID,
+	}
+
+	var leases []struct {
+		ID      bson.ObjectId `bson:"_id"`
+		Version int           `bson:"version"`
+		Leased  time.Time     `bson:"leased"`
+	}
+
+	commitHeaderCollection := zdb.C(AggregateCommitHeaderCollection)
+	if err := commitHeaderCollection.Find(leaseQuery).All(&leases); err != nil {
+		return repair, contextErr(ctx, err)
+	}
+
+	deadline := time.Now().Add(-maxAge)
+
+	var active int
+	for _, lease := range leases {
+		if lease.Version > lastHeader.Version && lease.Leased.After(deadline) {
+			active++
+			continue
+		}
+
+		if err := commitHeaderCollection.RemoveId(lease.ID); err != nil && err != mgo.ErrNotFound {
+			return repair, contextErr(ctx, err)
+		}
+
+		repair.Headers++
+	}
+
+	if active != 0 {
+		return repair, nil
+	}
+
+	info, err := zdb.C(AggregateDispatchCollection).RemoveAll(leaseQuery)
+	if err != nil {
+		return repair, contextErr(ctx, err)
+	}
+
+	repair.Dispatches = info.Removed
+	return repair, nil
+}
+
+// RepairLeases repairs the stale leases of every aggregate instance with leased records
+// as done by MgoWriteRepository.RepairLeases, returning the total of stale records removed.
+func (mw MgoWriteMaster) RepairLeases(ctx context.Context, maxAge time.Duration) (LeaseRepair, error) {
+	var repair LeaseRepair
+
+	zdb, zes, err := session(ctx, mw.db, false)
+	if err != nil {
+		return repair, err
+	}
+
+	defer zes.Close()
+
+	if mw.twoPhase {
+		if err := txn.NewRunner(zdb.C(AggregateTxnCollection)).ResumeAll(); err != nil {
+			return repair, contextErr(ctx, err)
+		}
+	}
+
+	type instance struct {
+		InstanceID  string `bson:"instance_id"`
+		AggregateID string `bson:"aggregate_id"`
+	}
+
+	seen := map[instance]bool{}
+	for _, col := range []string{AggregateCommitHeaderCollection, AggregateDispatchCollection} {
+		var leased []instance
+		if err := zdb.C(col).Find(bson.M{"commit_id": ""}).Select(bson.M{
+			"instance_id":  1,
+			"aggregate_id": 1,
+		}).All(&leased); err != nil {
+			return repair, contextErr(ctx, err)
+		}
+
+		for _, item := range leased {
+			seen[item] = true
+		}
+	}
+
+	for item := range seen {
+		if err := ctx.Err(); err != nil {
+			return repair, ContextError{Cause: err}
+		}
+
+		writer := &MgoWriteRepository{
+			db:          mw.db,
+			instanceID:  item.InstanceID,
+			aggregateID: item.AggregateID,
+			twoPhase:    mw.twoPhase,
+		}
+
+		fixed, err := writer.repairLeases(ctx, zdb, maxAge)
+		if err != nil {
+			return repair, contextErr(ctx, err)
+		}
+
+		repair.Headers += fixed.Headers
+		repair.Dispatches += fixed.Dispatches
+	}
+
+	return repair, nil
+}
+
 //*******************************************************************************
 // Read Repository Implementation
 //*******************************************************************************
@@ -863,28 +1209,41 @@ type MgoReadRepository struct {
 // CountBatches returns total number of event batches saved, with total events across all batches
 // available within db.
 func (mrr *MgoReadRepository) Count(ctx context.Context) (int, error) {
-	zdb, _, zerr := mrr.db.New(true)
+	zdb, zes, zerr := session(ctx, mrr.db, true)
 	if zerr != nil {
 		return -1, zerr
 	}
 
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		return -1, contextErr(ctx, err)
+	}
+
 	fnQuery := bson.M{
 		"aggregate_id": mrr.aggregateID,
 		"instance_id":  mrr.instanceID,
 	}
 
 	zcol := zdb.C(AggregateEventCommitCollection)
-	return zcol.Find(fnQuery).Count()
+	total, err := zcol.Find(fnQuery).Count()
+	return total, contextErr(ctx, err)
 }
 
 // ReadAll returns all events for giving aggregate and events for aggregate model.
 func (mrr *MgoReadRepository) ReadAll(ctx context.Context) ([]cqrskit.EventCommit, error) {
 	var events []cqrskit.EventCommit
-	zdb, _, zerr := mrr.db.New(true)
+	zdb, zes, zerr := session(ctx, mrr.db, true)
 	if zerr != nil {
 		return nil, zerr
 	}
 
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
 	zcol := zdb.C(AggregateEventCommitCollection)
 	rmQuery := bson.M{
 		"aggregate_id": mrr.aggregateID,
@@ -892,7 +1251,7 @@ func (mrr *MgoReadRepository) ReadAll(ctx context.Context) ([]cqrskit.EventCommi
 	}
 
 	if err := zcol.Find(rmQuery).Sort("version").All(&events); err != nil {
-		return nil, err
+		return nil, contextErr(ctx, err)
 	}
 
 	return events, nil
@@ -901,11 +1260,17 @@ func (mrr *MgoReadRepository) ReadAll(ctx context.Context) ([]cqrskit.EventCommi
 // ReadVersion returns all events for giving aggregate and instance model for requested version
 // if found.
 func (mrr *MgoReadRepository) ReadVersion(ctx context.Context, version int64) (cqrskit.EventCommit, error) {
-	zdb, _, zerr := mrr.db.New(true)
+	zdb, zes, zerr := session(ctx, mrr.db, true)
 	if zerr != nil {
 		return cqrskit.EventCommit{}, zerr
 	}
 
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		return cqrskit.EventCommit{}, contextErr(ctx, err)
+	}
+
 	zcol := zdb.C(AggregateEventCommitCollection)
 	rmQuery := bson.M{
 		"aggregate_id": mrr.aggregateID,
@@ -915,36 +1280,76 @@ func (mrr *MgoReadRepository) ReadVersion(ctx context.Context, version int64) (c
 
 	var commit cqrskit.EventCommit
 	if err := zcol.Find(rmQuery).One(&commit); err != nil {
-		return cqrskit.EventCommit{}, err
+		return cqrskit.EventCommit{}, contextErr(ctx, err)
 	}
 
 	return commit, nil
 }
 
-// ReadSinceCount returns all available events stored within mongdb, returning desired count from last saved
-// upward. If count is below zero that is -1, then all records are returned in reverse, that is in descending
-// order of version number.
+// ReadSinceCount returns the last count of events stored within mongodb, in ascending order of version.
+// If count is below zero that is -1, then all records are returned in reverse, that is in descending
+// order of version number, and a count of zero returns all records in ascending order.
 func (mrr *MgoReadRepository) ReadSinceCount(ctx context.Context, count int) ([]cqrskit.EventCommit, error) {
+	if count < 0 {
+		return mrr.Read(ctx, cqrskit.ReadBackward, 0, -1)
+	}
+
+	if count == 0 {
+		return mrr.Read(ctx, cqrskit.ReadForward, 0, -1)
+	}
+
+	events, err := mrr.Read(ctx, cqrskit.ReadBackward, 0, count)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+
+	return events, nil
+}
+
+// Read returns upto limit events from the giving version in the direction provided, where a limit
+// of zero or below returns all. Reading backward with a version of zero or below starts from the
+// latest commit, so pages of history are read by passing the version before the last commit
+// of the previous page.
+func (mrr *MgoReadRepository) Read(ctx context.Context, direction cqrskit.ReadDirection, fromVersion int64, limit int) ([]cqrskit.EventCommit, error) {
 	var events []cqrskit.EventCommit
-	zdb, _, zerr := mrr.db.New(true)
+	zdb, zes, zerr := session(ctx, mrr.db, true)
 	if zerr != nil {
 		return nil, zerr
 	}
 
-	zcol := zdb.C(AggregateEventCommitCollection)
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
 	rmQuery := bson.M{
 		"aggregate_id": mrr.aggregateID,
 		"instance_id":  mrr.instanceID,
 	}
 
-	if count > 0 {
-		if err := zcol.Find(rmQuery).Limit(count).Sort("version").All(&events); err != nil {
-			return nil, err
-		}
-	} else {
-		if err := zcol.Find(rmQuery).Sort("version").All(&events); err != nil {
-			return nil, err
+	sort := "version"
+	switch direction {
+	case cqrskit.ReadBackward:
+		sort = "-version"
+		if fromVersion > 0 {
+			rmQuery["version"] = bson.M{"$lte": fromVersion}
 		}
+	default:
+		rmQuery["version"] = bson.M{"$gte": fromVersion}
+	}
+
+	query := zdb.C(AggregateEventCommitCollection).Find(rmQuery).Sort(sort)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.All(&events); err != nil {
+		return nil, contextErr(ctx, err)
 	}
 
 	return events, nil
@@ -953,11 +1358,17 @@ func (mrr *MgoReadRepository) ReadSinceCount(ctx context.Context, count int) ([]
 // ReadSinceVersion returns all events that have occured around giving version and upwards.
 func (mrr *MgoReadRepository) ReadSinceVersion(ctx context.Context, version int64, limit int) ([]cqrskit.EventCommit, error) {
 	var events []cqrskit.EventCommit
-	zdb, _, zerr := mrr.db.New(true)
+	zdb, zes, zerr := session(ctx, mrr.db, true)
 	if zerr != nil {
 		return nil, zerr
 	}
 
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		return nil, contextErr(ctx, err)
+	}
+
 	zcol := zdb.C(AggregateEventCommitCollection)
 	rmQuery := bson.M{
 		"aggregate_id": mrr.aggregateID,
@@ -967,11 +1378,11 @@ func (mrr *MgoReadRepository) ReadSinceVersion(ctx context.Context, version int6
 
 	if limit > 0 {
 		if err := zcol.Find(rmQuery).Limit(limit).Sort("version").All(&events); err != nil {
-			return nil, err
+			return nil, contextErr(ctx, err)
 		}
 	} else {
 		if err := zcol.Find(rmQuery).Sort("version").All(&events); err != nil {
-			return nil, err
+			return nil, contextErr(ctx, err)
 		}
 	}
 
@@ -979,32 +1390,106 @@ func (mrr *MgoReadRepository) ReadSinceVersion(ctx context.Context, version int6
 }
 
 // ReadSinceTime returns all events for giving aggregate and events for aggregate model for the time of creation
-// of event upwards till required limit. Limit of -1 returns all events from said time.
+// of event upwards till required limit, in ascending order of version. Limit of -1 returns all events from said time.
 func (mrr *MgoReadRepository) ReadSinceTime(ctx context.Context, ts time.Time, limit int) ([]cqrskit.EventCommit, error) {
-	var events []cqrskit.EventCommit
-	zdb, _, zerr := mrr.db.New(true)
+	page, err := mrr.ReadRange(ctx, cqrskit.Since(ts), "", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Commits, nil
+}
+
+// streamBatchSize sets the total commits retrieved at a time by a stream.
+const streamBatchSize = 100
+
+// Stream returns a cqrskit.CommitIterator over all commits from the giving version upwards in
+// order of version. Commits are retrieved from a mongo cursor in batches as the iterator advances,
+// which keeps memory use flat for large streams. The iterator holds a session till closed, and
+// stops with a ContextError once the context ends.
+func (mrr *MgoReadRepository) Stream(ctx context.Context, fromVersion int64) (cqrskit.CommitIterator, error) {
+	zdb, zes, zerr := session(ctx, mrr.db, true)
 	if zerr != nil {
 		return nil, zerr
 	}
 
-	zcol := zdb.C(AggregateEventCommitCollection)
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		zes.Close()
+		return nil, contextErr(ctx, err)
+	}
+
 	rmQuery := bson.M{
 		"aggregate_id": mrr.aggregateID,
 		"instance_id":  mrr.instanceID,
-		"created":      ts,
+		"version":      bson.M{"$gte": fromVersion},
 	}
 
-	if limit > 0 {
-		if err := zcol.Find(rmQuery).Limit(limit).All(&events); err != nil {
-			return nil, err
-		}
-	} else {
-		if err := zcol.Find(rmQuery).All(&events); err != nil {
-			return nil, err
-		}
+	iter := zdb.C(AggregateEventCommitCollection).Find(rmQuery).Sort("version").Batch(streamBatchSize).Iter()
+
+	return &commitIterator{
+		ctx:  ctx,
+		iter: iter,
+		ses:  zes,
+	}, nil
+}
+
+// commitIterator implements the cqrskit.CommitIterator over a mgo.Iter.
+type commitIterator struct {
+	ctx    context.Context
+	iter   *mgo.Iter
+	ses    *mgo.Session
+	err    error
+	done   bool
+	commit cqrskit.EventCommit
+}
+
+// Next implements the cqrskit.CommitIterator interface.
+func (ci *commitIterator) Next() bool {
+	if ci.done {
+		return false
 	}
 
-	return events, nil
+	if err := ci.ctx.Err(); err != nil {
+		ci.err = ContextError{Cause: err}
+		ci.release()
+		return false
+	}
+
+	var commit cqrskit.EventCommit
+	if !ci.iter.Next(&commit) {
+		ci.err = ci.release()
+		return false
+	}
+
+	ci.commit = commit
+	return true
+}
+
+// Commit implements the cqrskit.CommitIterator interface.
+func (ci *commitIterator) Commit() cqrskit.EventCommit {
+	return ci.commit
+}
+
+// Err implements the cqrskit.CommitIterator interface.
+func (ci *commitIterator) Err() error {
+	return ci.err
+}
+
+// Close implements the cqrskit.CommitIterator interface.
+func (ci *commitIterator) Close() error {
+	if ci.done {
+		return nil
+	}
+	return ci.release()
+}
+
+// release closes the cursor and session of the iterator, returning the error of the cursor.
+func (ci *commitIterator) release() error {
+	ci.done = true
+
+	err := ci.iter.Close()
+	ci.ses.Close()
+	return contextErr(ci.ctx, err)
 }
 
 //*******************************************************************************
@@ -1048,7 +1533,7 @@ func (mdr MgoDispatchReader) Dispatch(ctx context.Context, idHex string) error {
 		return ErrInvalidDispatchID
 	}
 
-	zdb, zess, zerr := mdr.db.New(false)
+	zdb, zess, zerr := session(ctx, mdr.db, false)
 	if zerr != nil {
 		return zerr
 	}
@@ -1057,16 +1542,18 @@ func (mdr MgoDispatchReader) Dispatch(ctx context.Context, idHex string) error {
 
 	id := bson.ObjectIdHex(idHex)
 	dispatchCollection := zdb.C(AggregateDispatchCollection)
-	return dispatchCollection.RemoveId(id)
+	return contextErr(ctx, dispatchCollection.RemoveId(id))
 }
 
 // Undispatched returns all list of pending undispatched messages in the event store.
 func (mdr MgoDispatchReader) Undispatched(ctx context.Context) ([]cqrskit.PendingDispatch, error) {
-	zdb, _, zerr := mdr.db.New(true)
+	zdb, zes, zerr := session(ctx, mdr.db, true)
 	if zerr != nil {
 		return nil, zerr
 	}
 
+	defer zes.Close()
+
 	disQuery := bson.M{
 		"aggregate_id": mdr.aggregateID,
 		"instance_id":  mdr.instanceID,
@@ -1074,5 +1561,5 @@ func (mdr MgoDispatchReader) Undispatched(ctx context.Context) ([]cqrskit.Pendin
 
 	var pending []cqrskit.PendingDispatch
 	err := zdb.C(AggregateDispatchCollection).Find(disQuery).All(&pending)
-	return pending, err
+	return pending, contextErr(ctx, err)
 }
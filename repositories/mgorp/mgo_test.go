@@ -9,6 +9,8 @@ import (
 	"github.com/gokit/cqrskit"
 
 	"github.com/influx6/faux/tests"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 
 	"github.com/gokit/cqrskit/repositories/mgorp"
 	"github.com/gokit/cqrskit/repositories/mgorp/mdb"
@@ -248,11 +250,444 @@ func TestMongoRepository(t *testing.T) {
 	testReadRepository_ReadSinceCount(t, hostdb, readRepo)
 	testReadRepository_ReadSinceCountWithLimit(t, hostdb, readRepo)
 	testReadRepository_ReadVersion(t, hostdb, readRepo)
+	testReadRepository_Stream(t, hostdb, readRepo)
+	testReadRepository_ReadBackward(t, hostdb, readRepo)
+	testReadRepository_ReadRange(t, hostdb, readRepo)
+	testCatalog(t, hostdb, mgorp.NewCatalog(hostdb))
 	testDispatchRepository_Undispatch(t, hostdb, dispatchRepo)
 	testDispatchRepository_Dispatch(t, hostdb, dispatchRepo)
 	dropCollection(t, hostdb)
 }
 
+func TestMongoRepositoryTwoPhase(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+	writeRepo := mgorp.NewTwoPhaseWriteMaster(hostdb)
+	readRepo := mgorp.NewReadMaster(hostdb)
+	dispatchRepo := mgorp.NewDispatchMaster(hostdb)
+
+	testWriteMaster_New(t, hostdb, writeRepo)
+	testWriteRepository_SaveEvents(t, hostdb, writeRepo)
+	testWriteRepository_DuplicateCommit(t, hostdb, writeRepo)
+	testReadRepository_ReadAll(t, hostdb, readRepo)
+	testReadRepository_ReadVersion(t, hostdb, readRepo)
+	testDispatchRepository_Undispatch(t, hostdb, dispatchRepo)
+	testDispatchRepository_Dispatch(t, hostdb, dispatchRepo)
+	dropCollection(t, hostdb)
+}
+
+func TestMongoRepositoryWriteMode(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+	defer dropCollection(t, hostdb)
+
+	writers := mgorp.NewWriteMaster(hostdb)
+	if _, err := writers.Writer(aggregateId, modelId); err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	zdb, zses, err := hostdb.New(false)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten db session")
+	}
+	tests.Passed("Should have successfully gotten db session")
+
+	defer zses.Close()
+
+	if err := zdb.C(mgorp.AggregateWriteModeCollection).DropCollection(); err != nil {
+		tests.FailedWithError(err, "Should have successfully dropped write mode record")
+	}
+	tests.Passed("Should have successfully dropped write mode record")
+
+	// The write mode is claimed once per master, so the dropped record is not written again.
+	if _, err := writers.Writer(aggregateId, modelId); err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten writer of claimed write mode")
+	}
+	tests.Passed("Should have successfully gotten writer of claimed write mode")
+
+	if total, err := zdb.C(mgorp.AggregateWriteModeCollection).Count(); err != nil || total != 0 {
+		tests.Info("Received: %d records, %+v", total, err)
+		tests.Failed("Should have claimed write mode only once per master")
+	}
+	tests.Passed("Should have claimed write mode only once per master")
+
+	if _, err := mgorp.NewWriteMaster(hostdb).Writer(aggregateId, modelId); err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten writer of same write mode")
+	}
+	tests.Passed("Should have successfully gotten writer of same write mode")
+
+	if _, err := mgorp.NewTwoPhaseWriteMaster(hostdb).Writer(aggregateId, modelId); err != mgorp.ErrWriteModeMismatch {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected writer of other write mode")
+	}
+	tests.Passed("Should have rejected writer of other write mode")
+}
+
+func TestMongoRepositoryLeaseRepair(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+	writeRepo := mgorp.NewWriteMaster(hostdb)
+
+	testWriteMaster_New(t, hostdb, writeRepo)
+	testWriteRepository_SaveEvents(t, hostdb, writeRepo)
+	testWriteRepository_RepairLeases(t, hostdb, writeRepo)
+	dropCollection(t, hostdb)
+}
+
+func TestMongoRepositoryLifecycle(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+	writeRepo := mgorp.NewWriteMaster(hostdb)
+	readRepo := mgorp.NewReadMaster(hostdb)
+
+	testWriteMaster_New(t, hostdb, writeRepo)
+	testWriteRepository_SaveEvents(t, hostdb, writeRepo)
+	testWriteRepository_Lifecycle(t, hostdb, writeRepo, readRepo)
+	dropCollection(t, hostdb)
+}
+
+func TestMongoRepositoryConcurrentSaves(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+
+	for _, writeRepo := range []mgorp.MgoWriteMaster{mgorp.NewWriteMaster(hostdb), mgorp.NewTwoPhaseWriteMaster(hostdb)} {
+		es := cqrskit.ESCQRS{Events: struct {
+			mgorp.MgoWriteMaster
+			mgorp.MgoReadMaster
+		}{writeRepo, mgorp.NewReadMaster(hostdb)}}
+
+		errs := make(chan error, 5)
+		for i := 0; i < 5; i++ {
+			go func() {
+				_, err := cqrskit.SaveAggregate(context.Background(), es, aggregateId, modelId, 0, "CreateUser")
+				errs <- err
+			}()
+		}
+
+		var saved int
+		for i := 0; i < 5; i++ {
+			switch err := <-errs; err {
+			case nil:
+				saved++
+			case cqrskit.ErrStaleAggregate:
+			default:
+				tests.FailedWithError(err, "Should have either saved or rejected aggregate as stale")
+			}
+		}
+
+		if saved != 1 {
+			tests.Info("Received: %d saved", saved)
+			tests.Failed("Should have saved only one of concurrent saves of the same version")
+		}
+		tests.Passed("Should have saved only one of concurrent saves of the same version")
+
+		dropCollection(t, hostdb)
+	}
+}
+
+func TestMongoRepositoryImport(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+
+	for _, writeRepo := range []mgorp.MgoWriteMaster{mgorp.NewWriteMaster(hostdb), mgorp.NewTwoPhaseWriteMaster(hostdb)} {
+		testWriteMaster_New(t, hostdb, writeRepo)
+		testWriteRepository_Import(t, hostdb, writeRepo, mgorp.NewReadMaster(hostdb))
+		dropCollection(t, hostdb)
+	}
+}
+
+func TestMongoRepositoryImportLeasedVersion(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+	writeRepo := mgorp.NewWriteMaster(hostdb)
+	defer dropCollection(t, hostdb)
+
+	testWriteMaster_New(t, hostdb, writeRepo)
+
+	zdb, zses, err := hostdb.New(false)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten db session")
+	}
+	tests.Passed("Should have successfully gotten db session")
+
+	defer zses.Close()
+
+	// lease the first version, as done by a write in progress.
+	if err := zdb.C(mgorp.AggregateCommitHeaderCollection).Insert(bson.M{
+		"_id":          bson.NewObjectId(),
+		"commit_id":    "",
+		"version":      1,
+		"leased":       time.Now(),
+		"instance_id":  modelId,
+		"aggregate_id": aggregateId,
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully inserted lease")
+	}
+	tests.Passed("Should have successfully inserted lease")
+
+	repo, err := writeRepo.Writer(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	if _, err := repo.(cqrskit.CommitImporter).Import(context.Background(), cqrskit.EventCommit{
+		CommitID: "433436577674674574567575675",
+		Version:  1,
+		Command:  "CreateUser",
+		Created:  created,
+	}); err != mgorp.ErrConcurrentWrites {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected import of leased version")
+	}
+	tests.Passed("Should have rejected import of leased version")
+
+	total, err := zdb.C(mgorp.AggregateEventCommitCollection).Find(bson.M{
+		"aggregate_id": aggregateId,
+		"instance_id":  modelId,
+	}).Count()
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully counted commits")
+	}
+	tests.Passed("Should have successfully counted commits")
+
+	if total != 0 {
+		tests.Info("Received: %d", total)
+		tests.Failed("Should have written no commit for rejected import")
+	}
+	tests.Passed("Should have written no commit for rejected import")
+}
+
+func TestMongoRepositoryInstances(t *testing.T) {
+	hostdb := mdb.NewMongoDB(config)
+	writeRepo := mgorp.NewWriteMaster(hostdb)
+
+	for _, instanceID := range []string{modelId, "233JNIosd233"} {
+		repo, err := writeRepo.Writer(aggregateId, instanceID)
+		if err != nil {
+			tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+		}
+		tests.Passed("Should have successfully created new aggregate repository")
+
+		header, err := repo.Write(context.Background(), cqrskit.EventCommitRequest{
+			ID:      "433436577674674574567575675",
+			Command: "CreateUser",
+			Created: created,
+		})
+		if err != nil {
+			tests.FailedWithError(err, "Should have successfully written first commit of instance")
+		}
+		tests.Passed("Should have successfully written first commit of instance")
+
+		if header.Version != 1 {
+			tests.Info("Received: %d", header.Version)
+			tests.Failed("Should have written commit at version 1")
+		}
+		tests.Passed("Should have written commit at version 1")
+	}
+
+	zdb, zses, err := hostdb.New(false)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully connected to database")
+	}
+	defer zses.Close()
+
+	operatorIndex := mgo.Index{Key: []string{"command"}, Name: "operator_command"}
+	if err := zdb.C(mgorp.AggregateEventCommitCollection).EnsureIndex(operatorIndex); err != nil {
+		tests.FailedWithError(err, "Should have successfully created operator index")
+	}
+	tests.Passed("Should have successfully created operator index")
+
+	if _, err := mgorp.MigrateIndexes(hostdb); err != nil {
+		tests.FailedWithError(err, "Should have successfully migrated indexes")
+	}
+	tests.Passed("Should have successfully migrated indexes")
+
+	indexes, err := zdb.C(mgorp.AggregateEventCommitCollection).Indexes()
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully listed indexes")
+	}
+
+	var kept bool
+	for _, index := range indexes {
+		kept = kept || index.Name == operatorIndex.Name
+	}
+
+	if !kept {
+		tests.Failed("Should have kept index created by operator")
+	}
+	tests.Passed("Should have kept index created by operator")
+
+	dropped, err := mgorp.MigrateIndexes(hostdb)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully migrated indexes")
+	}
+	tests.Passed("Should have successfully migrated indexes")
+
+	if len(dropped) != 0 {
+		tests.Info("Received: %+v", dropped)
+		tests.Failed("Should have no legacy indexes left to drop")
+	}
+	tests.Passed("Should have no legacy indexes left to drop")
+
+	dropCollection(t, hostdb)
+}
+
+func testWriteRepository_DuplicateCommit(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoWriteMaster) {
+	repo, err := hostRepo.Writer(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	if _, err := repo.Write(context.Background(), cqrskit.EventCommitRequest{
+		ID:      "433436577674674574567575675",
+		Command: "CreateUser",
+		Created: created,
+	}); err != mgorp.ErrDuplicateCommitRequest {
+		tests.Failed("Should have rejected duplicate commit request")
+	}
+	tests.Passed("Should have rejected duplicate commit request")
+}
+
+func testWriteRepository_Import(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoWriteMaster, readers mgorp.MgoReadMaster) {
+	repo, err := hostRepo.Writer(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	importer, ok := repo.(cqrskit.CommitImporter)
+	if !ok {
+		tests.Failed("Should have implemented cqrskit.CommitImporter")
+	}
+	tests.Passed("Should have implemented cqrskit.CommitImporter")
+
+	imported := created.Add(-time.Hour).Truncate(time.Millisecond)
+	header, err := importer.Import(context.Background(), cqrskit.EventCommit{
+		CommitID: "433436577674674574567575675",
+		Version:  3,
+		Command:  "CreateUser",
+		Created:  imported,
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully imported commit")
+	}
+	tests.Passed("Should have successfully imported commit")
+
+	if header.Version != 3 {
+		tests.Info("Received: %d", header.Version)
+		tests.Failed("Should have kept version of imported commit")
+	}
+	tests.Passed("Should have kept version of imported commit")
+
+	if _, err := importer.Import(context.Background(), cqrskit.EventCommit{
+		CommitID: "433436577674674574567575676",
+		Version:  2,
+		Command:  "UpdateUserEmail",
+		Created:  imported,
+	}); err != mgorp.ErrConcurrentWrites {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected import below last version")
+	}
+	tests.Passed("Should have rejected import below last version")
+
+	reader, err := readers.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	commit, err := reader.ReadVersion(context.Background(), 3)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully read imported commit")
+	}
+	tests.Passed("Should have successfully read imported commit")
+
+	if commit.CommitID != "433436577674674574567575675" || !commit.Created.Equal(imported) {
+		tests.Info("Received: %+v", commit)
+		tests.Failed("Should have kept commit id and creation time of imported commit")
+	}
+	tests.Passed("Should have kept commit id and creation time of imported commit")
+
+	header, err = repo.Write(context.Background(), cqrskit.EventCommitRequest{
+		ID:      "433436577674674574567575677",
+		Command: "UpdateUserEmail",
+		Created: created,
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully written commit after import")
+	}
+	tests.Passed("Should have successfully written commit after import")
+
+	if header.Version != 4 {
+		tests.Info("Received: %d", header.Version)
+		tests.Failed("Should have written commit after imported version")
+	}
+	tests.Passed("Should have written commit after imported version")
+}
+
+func testWriteRepository_RepairLeases(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoWriteMaster) {
+	zdb, zses, err := db.New(false)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten db session")
+	}
+	tests.Passed("Should have successfully gotten db session")
+
+	defer zses.Close()
+
+	// lease a version already taken by a commit, as left by a crashed write.
+	if err := zdb.C(mgorp.AggregateCommitHeaderCollection).Insert(bson.M{
+		"_id":          bson.NewObjectId(),
+		"commit_id":    "",
+		"version":      2,
+		"leased":       time.Now(),
+		"instance_id":  modelId,
+		"aggregate_id": aggregateId,
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully inserted stale lease")
+	}
+	tests.Passed("Should have successfully inserted stale lease")
+
+	if err := zdb.C(mgorp.AggregateDispatchCollection).Insert(bson.M{
+		"_id":          bson.NewObjectId(),
+		"commit_id":    "",
+		"instance_id":  modelId,
+		"aggregate_id": aggregateId,
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully inserted stale dispatch lease")
+	}
+	tests.Passed("Should have successfully inserted stale dispatch lease")
+
+	repair, err := hostRepo.RepairLeases(context.Background(), time.Hour)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully repaired leases")
+	}
+	tests.Passed("Should have successfully repaired leases")
+
+	if repair.Headers != 1 || repair.Dispatches != 1 {
+		tests.Info("Received: %+v", repair)
+		tests.Failed("Should have removed stale leases")
+	}
+	tests.Passed("Should have removed stale leases")
+
+	repo, err := hostRepo.Writer(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	header, err := repo.Write(context.Background(), cqrskit.EventCommitRequest{
+		ID:      "436895577674674574567575699",
+		Command: "UpdateUserEmail",
+		Created: created,
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully written commit after repair")
+	}
+	tests.Passed("Should have successfully written commit after repair")
+
+	if header.Version != 3 {
+		tests.Info("Received: %d", header.Version)
+		tests.Failed("Should have written commit at next version")
+	}
+	tests.Passed("Should have written commit at next version")
+}
+
 func dropCollection(t *testing.T, db mdb.MongoDB) {
 	zdb, zses, err := db.New(false)
 	if err != nil {
@@ -281,6 +716,20 @@ func dropCollection(t *testing.T, db mdb.MongoDB) {
 		tests.FailedWithError(err, "Should have successfully dropped 'aggregate_events_model' collection")
 	}
 	tests.Passed("Should have successfully dropped 'aggregate_events_model' collection")
+
+	for _, col := range []string{
+		mgorp.AggregateDispatchCollection,
+		mgorp.AggregateCommitHeaderCollection,
+		mgorp.AggregateStreamCollection,
+		mgorp.AggregateTxnCollection,
+		mgorp.AggregateTxnCollection + ".stash",
+		mgorp.AggregateWriteModeCollection,
+	} {
+		if err := zdb.C(col).DropCollection(); err != nil && err.Error() != "ns not found" {
+			tests.FailedWithError(err, "Should have successfully dropped collection")
+		}
+	}
+	tests.Passed("Should have successfully dropped remaining collections")
 }
 
 func testDispatchRepository_Dispatch(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoDispatchMaster) {
@@ -616,3 +1065,308 @@ func testReadRepository_ReadVersion(t *testing.T, db mdb.MongoDB, hostRepo mgorp
 	}
 	tests.Passed("Should have retrieved expected records in count")
 }
+
+func testReadRepository_Stream(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoReadMaster) {
+	repo, err := hostRepo.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	iter, err := repo.Stream(context.Background(), 1)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully opened stream")
+	}
+	tests.Passed("Should have successfully opened stream")
+
+	var versions []int
+	for iter.Next() {
+		versions = append(versions, iter.Commit().Version)
+	}
+
+	if err := iter.Err(); err != nil {
+		tests.FailedWithError(err, "Should have successfully streamed all records")
+	}
+	tests.Passed("Should have successfully streamed all records")
+
+	if err := iter.Close(); err != nil {
+		tests.FailedWithError(err, "Should have successfully closed stream")
+	}
+	tests.Passed("Should have successfully closed stream")
+
+	if len(versions) != 2 || versions[0] != 1 || versions[1] != 2 {
+		tests.Info("Received: %+v", versions)
+		tests.Failed("Should have streamed records in order of version")
+	}
+	tests.Passed("Should have streamed records in order of version")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	iter, err = repo.Stream(ctx, 1)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully opened stream")
+	}
+	tests.Passed("Should have successfully opened stream")
+
+	defer iter.Close()
+
+	if !iter.Next() {
+		tests.Failed("Should have streamed first record")
+	}
+	tests.Passed("Should have streamed first record")
+
+	cancel()
+
+	if iter.Next() || !mgorp.IsContextError(iter.Err()) {
+		tests.Failed("Should have stopped stream once context was cancelled")
+	}
+	tests.Passed("Should have stopped stream once context was cancelled")
+}
+
+func testReadRepository_ReadBackward(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoReadMaster) {
+	repo, err := hostRepo.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	events, err := repo.Read(context.Background(), cqrskit.ReadBackward, 0, 1)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully retrieved latest record")
+	}
+	tests.Passed("Should have successfully retrieved latest record")
+
+	if len(events) != 1 || events[0].Version != 2 {
+		tests.Info("Received: %+v", events)
+		tests.Failed("Should have retrieved latest record")
+	}
+	tests.Passed("Should have retrieved latest record")
+
+	events, err = repo.Read(context.Background(), cqrskit.ReadBackward, int64(events[0].Version-1), 10)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully retrieved previous page")
+	}
+	tests.Passed("Should have successfully retrieved previous page")
+
+	if len(events) != 1 || events[0].Version != 1 {
+		tests.Info("Received: %+v", events)
+		tests.Failed("Should have retrieved records before latest record")
+	}
+	tests.Passed("Should have retrieved records before latest record")
+
+	events, err = repo.ReadSinceCount(context.Background(), 1)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully retrieved last record")
+	}
+	tests.Passed("Should have successfully retrieved last record")
+
+	if len(events) != 1 || events[0].Version != 2 {
+		tests.Info("Received: %+v", events)
+		tests.Failed("Should have retrieved last record instead of first")
+	}
+	tests.Passed("Should have retrieved last record instead of first")
+}
+
+func testReadRepository_ReadRange(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoReadMaster) {
+	repo, err := hostRepo.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	window := cqrskit.Between(created.Add(-time.Minute), created.Add(time.Minute))
+
+	page, err := repo.ReadRange(context.Background(), window, "", 1)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully retrieved first page")
+	}
+	tests.Passed("Should have successfully retrieved first page")
+
+	if len(page.Commits) != 1 || page.Commits[0].Version != 1 || page.Cursor == "" {
+		tests.Info("Received: %+v", page)
+		tests.Failed("Should have retrieved first page with cursor")
+	}
+	tests.Passed("Should have retrieved first page with cursor")
+
+	page, err = repo.ReadRange(context.Background(), window, page.Cursor, 1)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully retrieved last page")
+	}
+	tests.Passed("Should have successfully retrieved last page")
+
+	if len(page.Commits) != 1 || page.Commits[0].Version != 2 || page.Cursor != "" {
+		tests.Info("Received: %+v", page)
+		tests.Failed("Should have retrieved last page without cursor")
+	}
+	tests.Passed("Should have retrieved last page without cursor")
+
+	page, err = repo.ReadRange(context.Background(), cqrskit.Until(created.Add(-time.Minute)), "", -1)
+	if err != nil || len(page.Commits) != 0 {
+		tests.Failed("Should have retrieved no records before window")
+	}
+	tests.Passed("Should have retrieved no records before window")
+
+	var total int
+	var cursor string
+	for {
+		page, err := hostRepo.ReadRange(context.Background(), window, cursor, 1)
+		if err != nil {
+			tests.FailedWithError(err, "Should have successfully retrieved page across aggregates")
+		}
+
+		total += len(page.Commits)
+		if cursor = page.Cursor; cursor == "" {
+			break
+		}
+	}
+	tests.Passed("Should have successfully retrieved pages across aggregates")
+
+	if total != 2 {
+		tests.Info("Received: %d", total)
+		tests.Failed("Should have retrieved all records across aggregates")
+	}
+	tests.Passed("Should have retrieved all records across aggregates")
+}
+
+func testCatalog(t *testing.T, db mdb.MongoDB, catalog mgorp.MgoCatalog) {
+	aggregates, err := catalog.Aggregates(context.Background())
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully listed aggregates")
+	}
+	tests.Passed("Should have successfully listed aggregates")
+
+	if len(aggregates) != 1 || aggregates[0] != aggregateId {
+		tests.Info("Received: %+v", aggregates)
+		tests.Failed("Should have listed aggregate")
+	}
+	tests.Passed("Should have listed aggregate")
+
+	instances, err := catalog.Instances(context.Background(), aggregateId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully listed instances of aggregate")
+	}
+	tests.Passed("Should have successfully listed instances of aggregate")
+
+	if len(instances) != 1 || instances[0].InstanceID != modelId {
+		tests.Info("Received: %+v", instances)
+		tests.Failed("Should have listed instance of aggregate")
+	}
+	tests.Passed("Should have listed instance of aggregate")
+
+	if instances[0].Commits != 2 || instances[0].LastVersion != 2 {
+		tests.Info("Received: %+v", instances[0])
+		tests.Failed("Should have counted commits of instance")
+	}
+	tests.Passed("Should have counted commits of instance")
+
+	window := cqrskit.Between(created.Add(-time.Minute), created.Add(time.Minute))
+	page, err := catalog.FindByEventType(context.Background(), "UserEmailUpdated", window, "", 0)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully found commits by event type")
+	}
+	tests.Passed("Should have successfully found commits by event type")
+
+	if len(page.Commits) != 1 || page.Commits[0].Version != 2 || page.Cursor != "" {
+		tests.Info("Received: %+v", page)
+		tests.Failed("Should have found commit containing event type")
+	}
+	tests.Passed("Should have found commit containing event type")
+
+	page, err = catalog.FindByEventType(context.Background(), "UserEmailUpdated", cqrskit.Until(created.Add(-time.Minute)), "", 0)
+	if err != nil || len(page.Commits) != 0 {
+		tests.Failed("Should have found no commits outside window")
+	}
+	tests.Passed("Should have found no commits outside window")
+}
+
+func testWriteRepository_Lifecycle(t *testing.T, db mdb.MongoDB, hostRepo mgorp.MgoWriteMaster, readers mgorp.MgoReadMaster) {
+	repo, err := hostRepo.Writer(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	if _, err := repo.TruncateBefore(context.Background(), 3); err != mgorp.ErrTruncateBeyondLastCommit {
+		tests.Failed("Should have rejected truncating beyond last commit")
+	}
+	tests.Passed("Should have rejected truncating beyond last commit")
+
+	truncated, err := repo.TruncateBefore(context.Background(), 2)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully truncated stream")
+	}
+	tests.Passed("Should have successfully truncated stream")
+
+	if count, _ := repo.Count(context.Background()); truncated != 1 || count != 1 {
+		tests.Info("Received: %d truncated, %d left", truncated, count)
+		tests.Failed("Should have removed commits below version")
+	}
+	tests.Passed("Should have removed commits below version")
+
+	if err := repo.Tombstone(context.Background()); err != nil {
+		tests.FailedWithError(err, "Should have successfully tombstoned stream")
+	}
+	tests.Passed("Should have successfully tombstoned stream")
+
+	if _, err := repo.Write(context.Background(), cqrskit.EventCommitRequest{
+		ID:      "436895577674674574567575700",
+		Command: "UpdateUserEmail",
+		Created: created,
+	}); !cqrskit.IsStreamDeleted(err) {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected write to tombstoned stream")
+	}
+	tests.Passed("Should have rejected write to tombstoned stream")
+
+	reader, err := readers.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	if _, err := reader.ReadAll(context.Background()); !cqrskit.IsStreamDeleted(err) {
+		tests.Info("Received: %+v", err)
+		tests.Failed("Should have rejected read of tombstoned stream")
+	}
+	tests.Passed("Should have rejected read of tombstoned stream")
+
+	page, err := readers.ReadRange(context.Background(), cqrskit.TimeRange{}, "", 0)
+	if err != nil || len(page.Commits) != 0 {
+		tests.Info("Received: %+v", page)
+		tests.Failed("Should have skipped commits of tombstoned stream")
+	}
+	tests.Passed("Should have skipped commits of tombstoned stream")
+
+	removed, err := repo.Delete(context.Background())
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully deleted stream")
+	}
+	tests.Passed("Should have successfully deleted stream")
+
+	if removed != 1 {
+		tests.Info("Received: %d", removed)
+		tests.Failed("Should have removed remaining commits of stream")
+	}
+	tests.Passed("Should have removed remaining commits of stream")
+
+	repo, err = hostRepo.Writer(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created new aggregate repository")
+	}
+	tests.Passed("Should have successfully created new aggregate repository")
+
+	header, err := repo.Write(context.Background(), cqrskit.EventCommitRequest{
+		ID:      "433436577674674574567575675",
+		Command: "CreateUser",
+		Created: created,
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully written commit to new stream")
+	}
+	tests.Passed("Should have successfully written commit to new stream")
+
+	if header.Version != 1 {
+		tests.Info("Received: %d", header.Version)
+		tests.Failed("Should have started new stream from first version")
+	}
+	tests.Passed("Should have started new stream from first version")
+}
@@ -0,0 +1,187 @@
+package mgorp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/gokit/cqrskit"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// cursor embodies the position of the last commit of a CommitPage, which is encoded as
+// the opaque token of said page.
+type cursor struct {
+	Version int       `json:"v,omitempty"`
+	Created time.Time `json:"t,omitempty"`
+	ID      string    `json:"id,omitempty"`
+}
+
+// encode returns the cursor as a opaque token.
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the cursor of the giving token.
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// addTimeRange adds the conditions matching the window on the created field of
+// commits to the query.
+func addTimeRange(query bson.M, window cqrskit.TimeRange) {
+	created := bson.M{}
+	if !window.Since.IsZero() {
+		created["$gte"] = window.Since
+	}
+
+	if !window.Until.IsZero() {
+		created["$lt"] = window.Until
+	}
+
+	if len(created) != 0 {
+		query["created"] = created
+	}
+}
+
+// ReadRange returns a page of upto limit commits created within the window, in ascending order
+// of version. The cursor of a returned page is passed to read the next page, where an empty cursor
+// reads the first. A limit of zero or below returns all commits in a single page.
+func (mrr *MgoReadRepository) ReadRange(ctx context.Context, window cqrskit.TimeRange, token string, limit int) (cqrskit.CommitPage, error) {
+	var page cqrskit.CommitPage
+
+	rmQuery := bson.M{
+		"aggregate_id": mrr.aggregateID,
+		"instance_id":  mrr.instanceID,
+	}
+
+	addTimeRange(rmQuery, window)
+
+	if token != "" {
+		last, err := decodeCursor(token)
+		if err != nil {
+			return page, err
+		}
+
+		rmQuery["version"] = bson.M{"$gt": last.Version}
+	}
+
+	zdb, zes, zerr := session(ctx, mrr.db, true)
+	if zerr != nil {
+		return page, zerr
+	}
+
+	defer zes.Close()
+
+	if err := checkTombstone(zdb, mrr.aggregateID, mrr.instanceID); err != nil {
+		return page, contextErr(ctx, err)
+	}
+
+	query := zdb.C(AggregateEventCommitCollection).Find(rmQuery).Sort("version")
+
+	// Read one more than the limit, to know if a next page exists.
+	if limit > 0 {
+		query = query.Limit(limit + 1)
+	}
+
+	if err := query.All(&page.Commits); err != nil {
+		return page, contextErr(ctx, err)
+	}
+
+	if limit > 0 && len(page.Commits) > limit {
+		page.Commits = page.Commits[:limit]
+		page.Cursor = cursor{Version: page.Commits[limit-1].Version}.encode()
+	}
+
+	return page, nil
+}
+
+// rangedCommit embodies a EventCommit with the id of it's record, used to order commits
+// across aggregates.
+type rangedCommit struct {
+	ID                  bson.ObjectId `bson:"_id"`
+	cqrskit.EventCommit `bson:",inline"`
+}
+
+// ReadRange implements the cqrskit.CommitQuery interface, returning a page of upto limit commits
+// of all aggregates and instances created within the window, in ascending order of creation.
+// The cursor of a returned page is passed to read the next page, where an empty cursor reads the
+// first. A limit of zero or below returns all commits in a single page.
+func (mgr MgoReadMaster) ReadRange(ctx context.Context, window cqrskit.TimeRange, token string, limit int) (cqrskit.CommitPage, error) {
+	return readCreatedRange(ctx, mgr.db, bson.M{}, window, token, limit)
+}
+
+// readCreatedRange returns a page of upto limit commits matching the query and created within
+// the window, in ascending order of creation. Commits created at the same time are ordered by
+// their record id, and commits of tombstoned streams are skipped.
+func readCreatedRange(ctx context.Context, db MongoDB, rmQuery bson.M, window cqrskit.TimeRange, token string, limit int) (cqrskit.CommitPage, error) {
+	var page cqrskit.CommitPage
+
+	addTimeRange(rmQuery, window)
+	rmQuery["deleted"] = bson.M{"$exists": false}
+
+	if token != "" {
+		last, err := decodeCursor(token)
+		if err != nil || !bson.IsObjectIdHex(last.ID) {
+			return page, ErrInvalidCursor
+		}
+
+		rmQuery = bson.M{
+			"$and": []bson.M{
+				rmQuery,
+				{
+					"$or": []bson.M{
+						{"created": bson.M{"$gt": last.Created}},
+						{"created": last.Created, "_id": bson.M{"$gt": bson.ObjectIdHex(last.ID)}},
+					},
+				},
+			},
+		}
+	}
+
+	zdb, zes, zerr := session(ctx, db, true)
+	if zerr != nil {
+		return page, zerr
+	}
+
+	defer zes.Close()
+
+	query := zdb.C(AggregateEventCommitCollection).Find(rmQuery).Sort("created", "_id")
+
+	// Read one more than the limit, to know if a next page exists.
+	if limit > 0 {
+		query = query.Limit(limit + 1)
+	}
+
+	var commits []rangedCommit
+	if err := query.All(&commits); err != nil {
+		return page, contextErr(ctx, err)
+	}
+
+	if limit > 0 && len(commits) > limit {
+		commits = commits[:limit]
+
+		last := commits[limit-1]
+		page.Cursor = cursor{Created: last.Created, ID: last.ID.Hex()}.encode()
+	}
+
+	page.Commits = make([]cqrskit.EventCommit, 0, len(commits))
+	for _, commit := range commits {
+		page.Commits = append(page.Commits, commit.EventCommit)
+	}
+
+	return page, nil
+}
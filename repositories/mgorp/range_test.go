@@ -0,0 +1,37 @@
+package mgorp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/gokit/cqrskit/repositories/mgorp"
+)
+
+func TestReadRangeInvalidCursor(t *testing.T) {
+	db := &mockDB{}
+	readers := mgorp.NewReadMaster(db)
+
+	repo, err := readers.Reader(aggregateId, modelId)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten aggregate read repository")
+	}
+	tests.Passed("Should have successfully gotten aggregate read repository")
+
+	if _, err := repo.ReadRange(context.Background(), cqrskit.TimeRange{}, "not-a-cursor!", 10); err != mgorp.ErrInvalidCursor {
+		tests.Failed("Should have rejected invalid cursor of aggregate instance")
+	}
+	tests.Passed("Should have rejected invalid cursor of aggregate instance")
+
+	if _, err := readers.ReadRange(context.Background(), cqrskit.TimeRange{}, "e30", 10); err != mgorp.ErrInvalidCursor {
+		tests.Failed("Should have rejected cursor without record id")
+	}
+	tests.Passed("Should have rejected cursor without record id")
+
+	if db.calls != 0 {
+		tests.Failed("Should have rejected cursors before creating any session")
+	}
+	tests.Passed("Should have rejected cursors before creating any session")
+}
@@ -0,0 +1,117 @@
+package shred
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// KeySize sets the size in bytes of the AES-256 keys created for data subjects.
+const KeySize = 32
+
+// KeyStore defines the interface which stores the encryption key of each data subject. Once
+// the key of a subject is deleted, all fields encrypted with it are unrecoverable.
+type KeyStore interface {
+	// Key returns the key of the subject, creating it if none exists.
+	Key(ctx context.Context, subject string) ([]byte, error)
+
+	// Lookup returns the key of the subject, or ErrKeyNotFound if none exists.
+	Lookup(ctx context.Context, subject string) ([]byte, error)
+
+	// Delete removes the key of the subject.
+	Delete(ctx context.Context, subject string) error
+}
+
+// newKey returns a new random key of KeySize.
+func newKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+//*******************************************************************************
+// File Key Store
+//*******************************************************************************
+
+// FileKeyStore implements the KeyStore interface, storing the key of each subject as a
+// hex encoded file within a directory. Files are named by the sha256 sum of their subject,
+// so subjects never appear on disk.
+type FileKeyStore struct {
+	dir string
+	ml  sync.Mutex
+}
+
+// NewFileKeyStore returns a new instance of FileKeyStore which stores keys within the giving
+// directory, creating it if missing.
+func NewFileKeyStore(dir string) (*FileKeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &FileKeyStore{dir: dir}, nil
+}
+
+// Key implements the KeyStore interface.
+func (fs *FileKeyStore) Key(ctx context.Context, subject string) ([]byte, error) {
+	fs.ml.Lock()
+	defer fs.ml.Unlock()
+
+	key, err := fs.read(subject)
+	if err != ErrKeyNotFound {
+		return key, err
+	}
+
+	if key, err = newKey(); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(fs.path(subject), []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Lookup implements the KeyStore interface.
+func (fs *FileKeyStore) Lookup(ctx context.Context, subject string) ([]byte, error) {
+	fs.ml.Lock()
+	defer fs.ml.Unlock()
+
+	return fs.read(subject)
+}
+
+// Delete implements the KeyStore interface.
+func (fs *FileKeyStore) Delete(ctx context.Context, subject string) error {
+	fs.ml.Lock()
+	defer fs.ml.Unlock()
+
+	if err := os.Remove(fs.path(subject)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (fs *FileKeyStore) read(subject string) ([]byte, error) {
+	data, err := ioutil.ReadFile(fs.path(subject))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	return hex.DecodeString(strings.TrimSpace(string(data)))
+}
+
+func (fs *FileKeyStore) path(subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return filepath.Join(fs.dir, hex.EncodeToString(sum[:])+".key")
+}
@@ -0,0 +1,203 @@
+package shred
+
+import (
+	"context"
+	"time"
+
+	"github.com/gokit/cqrskit"
+)
+
+//*******************************************************************************
+// Write Repository
+//*******************************************************************************
+
+// Writers returns a cqrskit.WriteRepository whose repositories encrypt the events of every
+// commit with the Shredder before writing them to the repositories of the giving one.
+func (s *Shredder) Writers(repo cqrskit.WriteRepository) cqrskit.WriteRepository {
+	return shreddedWriters{repo: repo, shredder: s}
+}
+
+type shreddedWriters struct {
+	repo     cqrskit.WriteRepository
+	shredder *Shredder
+}
+
+// Writer implements the cqrskit.WriteRepository interface.
+func (sw shreddedWriters) Writer(aggregateID string, instanceID string) (cqrskit.WriteRepo, error) {
+	repo, err := sw.repo.Writer(aggregateID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return shreddedWriter{WriteRepo: repo, shredder: sw.shredder}, nil
+}
+
+type shreddedWriter struct {
+	cqrskit.WriteRepo
+	shredder *Shredder
+}
+
+// Write implements the cqrskit.WriteRepo interface.
+func (sw shreddedWriter) Write(ctx context.Context, req cqrskit.EventCommitRequest) (cqrskit.CommitHeader, error) {
+	events, err := sw.shredder.EncryptEvents(ctx, req.Events)
+	if err != nil {
+		return cqrskit.CommitHeader{}, err
+	}
+
+	req.Events = events
+	return sw.WriteRepo.Write(ctx, req)
+}
+
+//*******************************************************************************
+// Read Repository
+//*******************************************************************************
+
+// Readers returns a cqrskit.ReadRepository whose repositories decrypt the events of every
+// commit read from the repositories of the giving one with the Shredder.
+func (s *Shredder) Readers(repo cqrskit.ReadRepository) cqrskit.ReadRepository {
+	return shreddedReaders{repo: repo, shredder: s}
+}
+
+// Query returns a cqrskit.CommitQuery which decrypts the events of every commit read from the
+// giving one with the Shredder.
+func (s *Shredder) Query(query cqrskit.CommitQuery) cqrskit.CommitQuery {
+	return shreddedQuery{query: query, shredder: s}
+}
+
+type shreddedReaders struct {
+	repo     cqrskit.ReadRepository
+	shredder *Shredder
+}
+
+// Reader implements the cqrskit.ReadRepository interface.
+func (sr shreddedReaders) Reader(aggregateID string, instanceID string) (cqrskit.ReadRepo, error) {
+	repo, err := sr.repo.Reader(aggregateID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return shreddedReader{ReadRepo: repo, shredder: sr.shredder}, nil
+}
+
+type shreddedReader struct {
+	cqrskit.ReadRepo
+	shredder *Shredder
+}
+
+// ReadAll implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) ReadAll(ctx context.Context) ([]cqrskit.EventCommit, error) {
+	return sr.decrypt(ctx)(sr.ReadRepo.ReadAll(ctx))
+}
+
+// ReadVersion implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) ReadVersion(ctx context.Context, version int64) (cqrskit.EventCommit, error) {
+	commit, err := sr.ReadRepo.ReadVersion(ctx, version)
+	if err != nil {
+		return commit, err
+	}
+
+	return sr.shredder.DecryptCommit(ctx, commit)
+}
+
+// ReadSinceCount implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) ReadSinceCount(ctx context.Context, count int) ([]cqrskit.EventCommit, error) {
+	return sr.decrypt(ctx)(sr.ReadRepo.ReadSinceCount(ctx, count))
+}
+
+// ReadSinceTime implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) ReadSinceTime(ctx context.Context, last time.Time, limit int) ([]cqrskit.EventCommit, error) {
+	return sr.decrypt(ctx)(sr.ReadRepo.ReadSinceTime(ctx, last, limit))
+}
+
+// ReadSinceVersion implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) ReadSinceVersion(ctx context.Context, version int64, limit int) ([]cqrskit.EventCommit, error) {
+	return sr.decrypt(ctx)(sr.ReadRepo.ReadSinceVersion(ctx, version, limit))
+}
+
+// Read implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) Read(ctx context.Context, direction cqrskit.ReadDirection, fromVersion int64, limit int) ([]cqrskit.EventCommit, error) {
+	return sr.decrypt(ctx)(sr.ReadRepo.Read(ctx, direction, fromVersion, limit))
+}
+
+// ReadRange implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) ReadRange(ctx context.Context, window cqrskit.TimeRange, cursor string, limit int) (cqrskit.CommitPage, error) {
+	return decryptPage(ctx, sr.shredder)(sr.ReadRepo.ReadRange(ctx, window, cursor, limit))
+}
+
+// Stream implements the cqrskit.ReadRepo interface.
+func (sr shreddedReader) Stream(ctx context.Context, fromVersion int64) (cqrskit.CommitIterator, error) {
+	iter, err := sr.ReadRepo.Stream(ctx, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shreddedIterator{CommitIterator: iter, ctx: ctx, shredder: sr.shredder}, nil
+}
+
+func (sr shreddedReader) decrypt(ctx context.Context) func([]cqrskit.EventCommit, error) ([]cqrskit.EventCommit, error) {
+	return func(commits []cqrskit.EventCommit, err error) ([]cqrskit.EventCommit, error) {
+		if err != nil {
+			return commits, err
+		}
+		return sr.shredder.DecryptCommits(ctx, commits)
+	}
+}
+
+type shreddedQuery struct {
+	query    cqrskit.CommitQuery
+	shredder *Shredder
+}
+
+// ReadRange implements the cqrskit.CommitQuery interface.
+func (sq shreddedQuery) ReadRange(ctx context.Context, window cqrskit.TimeRange, cursor string, limit int) (cqrskit.CommitPage, error) {
+	return decryptPage(ctx, sq.shredder)(sq.query.ReadRange(ctx, window, cursor, limit))
+}
+
+func decryptPage(ctx context.Context, shredder *Shredder) func(cqrskit.CommitPage, error) (cqrskit.CommitPage, error) {
+	return func(page cqrskit.CommitPage, err error) (cqrskit.CommitPage, error) {
+		if err != nil {
+			return page, err
+		}
+
+		page.Commits, err = shredder.DecryptCommits(ctx, page.Commits)
+		return page, err
+	}
+}
+
+// shreddedIterator decrypts every commit of a cqrskit.CommitIterator, stopping at the
+// first commit which fails to decrypt.
+type shreddedIterator struct {
+	cqrskit.CommitIterator
+	ctx      context.Context
+	shredder *Shredder
+	commit   cqrskit.EventCommit
+	err      error
+}
+
+// Next implements the cqrskit.CommitIterator interface.
+func (si *shreddedIterator) Next() bool {
+	if si.err != nil || !si.CommitIterator.Next() {
+		return false
+	}
+
+	si.commit, si.err = si.shredder.DecryptCommit(si.ctx, si.CommitIterator.Commit())
+	if si.err != nil {
+		si.CommitIterator.Close()
+		return false
+	}
+
+	return true
+}
+
+// Commit implements the cqrskit.CommitIterator interface.
+func (si *shreddedIterator) Commit() cqrskit.EventCommit {
+	return si.commit
+}
+
+// Err implements the cqrskit.CommitIterator interface.
+func (si *shreddedIterator) Err() error {
+	if si.err != nil {
+		return si.err
+	}
+	return si.CommitIterator.Err()
+}
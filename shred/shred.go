@@ -0,0 +1,400 @@
+// Package shred implements crypto-shredding of personal data held within the events of
+// cqrskit stores. Selected fields of event data are encrypted with a key owned by their
+// data subject, so deleting the key of a subject leaves all of it's personal data
+// unrecoverable while the events themselves are kept for audit.
+//
+// Fields are selected with struct tags on event data:
+//
+//	type UserEmailUpdated struct {
+//		UserID string `shred:"subject"`
+//		Email  string `shred:"pii"`
+//	}
+//
+// or through a Registry for event types whose data is not tagged. Encrypted events are
+// stored with a map as their data, keyed by the json name of each field, and carry the
+// subject in their header under SubjectHeader.
+package shred
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gokit/cqrskit"
+)
+
+// errors ...
+var (
+	ErrKeyNotFound = errors.New("no key found for subject")
+	ErrNoSubject   = errors.New("event data has no subject for encrypted fields")
+)
+
+// consts of values used by encrypted events.
+const (
+	// SubjectHeader is the header of an event holding the subject whose key encrypted it.
+	SubjectHeader = "shred_subject"
+
+	// RedactedValue is the default placeholder of fields whose subject key was deleted.
+	RedactedValue = "[redacted]"
+
+	// cipherPrefix marks the encrypted values of fields.
+	cipherPrefix = "shred:v1:"
+)
+
+//*******************************************************************************
+// Policy Registry
+//*******************************************************************************
+
+// Policy defines the fields of an event's data to be encrypted, and the field holding
+// the data subject they belong to.
+type Policy struct {
+	Subject string
+	Fields  []string
+}
+
+// Registry holds the Policy of event types, used for event data without shred tags.
+type Registry struct {
+	ml       sync.RWMutex
+	policies map[string]Policy
+}
+
+// NewRegistry returns a new instance of Registry.
+func NewRegistry() *Registry {
+	return &Registry{policies: map[string]Policy{}}
+}
+
+// Register sets the Policy of the giving event type.
+func (r *Registry) Register(eventType string, policy Policy) {
+	r.ml.Lock()
+	defer r.ml.Unlock()
+	r.policies[eventType] = policy
+}
+
+// Policy returns the Policy of the giving event type, if registered.
+func (r *Registry) Policy(eventType string) (Policy, bool) {
+	r.ml.RLock()
+	defer r.ml.RUnlock()
+	policy, ok := r.policies[eventType]
+	return policy, ok
+}
+
+//*******************************************************************************
+// Shredder
+//*******************************************************************************
+
+// Shredder encrypts and decrypts the personal data of events with the keys of their
+// subjects. Fields of subjects whose key was deleted are decrypted as Redacted.
+type Shredder struct {
+	Keys     KeyStore
+	Registry *Registry
+	Redacted interface{}
+}
+
+// NewShredder returns a new instance of Shredder using the giving KeyStore and Registry,
+// where the registry may be nil if all event data is tagged.
+func NewShredder(keys KeyStore, registry *Registry) *Shredder {
+	if registry == nil {
+		registry = NewRegistry()
+	}
+
+	return &Shredder{
+		Keys:     keys,
+		Registry: registry,
+		Redacted: RedactedValue,
+	}
+}
+
+// Forget deletes the key of the subject, leaving all it's encrypted fields unrecoverable.
+func (s *Shredder) Forget(ctx context.Context, subject string) error {
+	return s.Keys.Delete(ctx, subject)
+}
+
+// EncryptEvents returns a copy of the events where the selected fields of each are encrypted
+// with the key of it's subject. Events without a policy are returned as is.
+func (s *Shredder) EncryptEvents(ctx context.Context, events []cqrskit.Event) ([]cqrskit.Event, error) {
+	encrypted := make([]cqrskit.Event, 0, len(events))
+	for _, event := range events {
+		data, policy, ok := s.policyData(event)
+		if !ok {
+			encrypted = append(encrypted, event)
+			continue
+		}
+
+		subject := fmt.Sprint(data[policy.Subject])
+		if data[policy.Subject] == nil || subject == "" {
+			return nil, ErrNoSubject
+		}
+
+		key, err := s.Keys.Key(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range policy.Fields {
+			value, ok := data[field]
+			if !ok {
+				continue
+			}
+
+			if data[field], err = encrypt(key, value); err != nil {
+				return nil, err
+			}
+		}
+
+		header := make(map[string]interface{}, len(event.Header)+1)
+		for name, value := range event.Header {
+			header[name] = value
+		}
+		header[SubjectHeader] = subject
+
+		event.Data = data
+		event.Header = header
+		encrypted = append(encrypted, event)
+	}
+
+	return encrypted, nil
+}
+
+// DecryptCommit returns a copy of the commit where the encrypted fields of all events are
+// decrypted, or set to Redacted if their subject's key no longer exists. Decrypted fields
+// hold the json decoded form of their original value.
+func (s *Shredder) DecryptCommit(ctx context.Context, commit cqrskit.EventCommit) (cqrskit.EventCommit, error) {
+	events := make([]cqrskit.Event, 0, len(commit.Events))
+	keys := map[string][]byte{}
+
+	for _, event := range commit.Events {
+		subject, ok := event.Header[SubjectHeader].(string)
+		if !ok {
+			events = append(events, event)
+			continue
+		}
+
+		data, ok := toMap(event.Data)
+		if !ok {
+			events = append(events, event)
+			continue
+		}
+
+		key, seen := keys[subject]
+		if !seen {
+			var err error
+			if key, err = s.Keys.Lookup(ctx, subject); err != nil && err != ErrKeyNotFound {
+				return commit, err
+			}
+			keys[subject] = key
+		}
+
+		for field, value := range data {
+			text, ok := value.(string)
+			if !ok || !strings.HasPrefix(text, cipherPrefix) {
+				continue
+			}
+
+			// a key recreated after shredding fails to decrypt older values,
+			// which are redacted like those of a missing key.
+			decrypted, err := decrypt(key, text)
+			if err != nil {
+				data[field] = s.Redacted
+				continue
+			}
+
+			data[field] = decrypted
+		}
+
+		event.Data = data
+		events = append(events, event)
+	}
+
+	commit.Events = events
+	return commit, nil
+}
+
+// DecryptCommits returns a copy of the commits with all their events decrypted. See
+// Shredder.DecryptCommit.
+func (s *Shredder) DecryptCommits(ctx context.Context, commits []cqrskit.EventCommit) ([]cqrskit.EventCommit, error) {
+	decrypted := make([]cqrskit.EventCommit, 0, len(commits))
+	for _, commit := range commits {
+		next, err := s.DecryptCommit(ctx, commit)
+		if err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, next)
+	}
+	return decrypted, nil
+}
+
+// policyData returns the data of the event as a map with it's Policy, either from the
+// shred tags of it's struct or the registry.
+func (s *Shredder) policyData(event cqrskit.Event) (map[string]interface{}, Policy, bool) {
+	if data, policy, ok := taggedData(event.Data); ok {
+		return data, policy, true
+	}
+
+	policy, ok := s.Registry.Policy(event.Type)
+	if !ok {
+		return nil, policy, false
+	}
+
+	if data, ok := toMap(event.Data); ok {
+		return data, policy, true
+	}
+
+	if data, _, ok := structData(event.Data); ok {
+		return data, policy, true
+	}
+
+	return nil, policy, false
+}
+
+//*******************************************************************************
+// Event Data
+//*******************************************************************************
+
+// taggedData returns the data of a struct as a map with the Policy of it's shred tags.
+func taggedData(value interface{}) (map[string]interface{}, Policy, bool) {
+	data, tags, ok := structData(value)
+	if !ok {
+		return nil, Policy{}, false
+	}
+
+	var policy Policy
+	for name, tag := range tags {
+		switch tag {
+		case "subject":
+			policy.Subject = name
+		case "pii":
+			policy.Fields = append(policy.Fields, name)
+		}
+	}
+
+	if policy.Subject == "" && len(policy.Fields) == 0 {
+		return nil, policy, false
+	}
+
+	return data, policy, true
+}
+
+// structData returns the exported fields of a struct or pointer to one as a map keyed by their
+// json names, with the shred tag of each field.
+func structData(value interface{}) (map[string]interface{}, map[string]string, bool) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+
+	rt := rv.Type()
+	data := make(map[string]interface{}, rt.NumField())
+	tags := map[string]string{}
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if jsonTag := strings.Split(field.Tag.Get("json"), ",")[0]; jsonTag == "-" {
+			continue
+		} else if jsonTag != "" {
+			name = jsonTag
+		}
+
+		data[name] = rv.Field(i).Interface()
+		if tag := field.Tag.Get("shred"); tag != "" {
+			tags[name] = tag
+		}
+	}
+
+	return data, tags, true
+}
+
+// toMap returns a copy of a map with string keys, as read back from stores.
+func toMap(value interface{}) (map[string]interface{}, bool) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	data := make(map[string]interface{}, rv.Len())
+	for _, key := range rv.MapKeys() {
+		data[key.String()] = rv.MapIndex(key).Interface()
+	}
+
+	return data, true
+}
+
+//*******************************************************************************
+// Encryption
+//*******************************************************************************
+
+// encrypt returns the json encoded value encrypted with AES-GCM under the key.
+func encrypt(key []byte, value interface{}) (string, error) {
+	plain, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plain, nil)
+	return cipherPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns the json decoded value of a value encrypted by encrypt.
+func decrypt(key []byte, text string) (interface{}, error) {
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(text, cipherPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted value too short")
+	}
+
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	err = json.Unmarshal(plain, &value)
+	return value, err
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,239 @@
+package shred_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gokit/cqrskit"
+	"github.com/influx6/faux/tests"
+
+	"github.com/gokit/cqrskit/shred"
+)
+
+type UserEmailUpdated struct {
+	UserID string `json:"user_id" shred:"subject"`
+	Email  string `json:"email" shred:"pii"`
+	Plan   string `json:"plan"`
+}
+
+func newKeyStore(t *testing.T) (*shred.FileKeyStore, func()) {
+	dir, err := ioutil.TempDir("", "shred")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created key directory")
+	}
+
+	keys, err := shred.NewFileKeyStore(dir)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully created key store")
+	}
+	tests.Passed("Should have successfully created key store")
+
+	return keys, func() { os.RemoveAll(dir) }
+}
+
+func TestFileKeyStore(t *testing.T) {
+	keys, done := newKeyStore(t)
+	defer done()
+
+	if _, err := keys.Lookup(context.Background(), "user-1"); err != shred.ErrKeyNotFound {
+		tests.Failed("Should have found no key for new subject")
+	}
+	tests.Passed("Should have found no key for new subject")
+
+	key, err := keys.Key(context.Background(), "user-1")
+	if err != nil || len(key) != shred.KeySize {
+		tests.Failed("Should have successfully created key for subject")
+	}
+	tests.Passed("Should have successfully created key for subject")
+
+	found, err := keys.Lookup(context.Background(), "user-1")
+	if err != nil || string(found) != string(key) {
+		tests.Failed("Should have found stored key for subject")
+	}
+	tests.Passed("Should have found stored key for subject")
+
+	if err := keys.Delete(context.Background(), "user-1"); err != nil {
+		tests.FailedWithError(err, "Should have successfully deleted key of subject")
+	}
+	tests.Passed("Should have successfully deleted key of subject")
+
+	if _, err := keys.Lookup(context.Background(), "user-1"); err != shred.ErrKeyNotFound {
+		tests.Failed("Should have found no key for deleted subject")
+	}
+	tests.Passed("Should have found no key for deleted subject")
+}
+
+func TestShredder(t *testing.T) {
+	keys, done := newKeyStore(t)
+	defer done()
+
+	registry := shred.NewRegistry()
+	registry.Register("UserNameUpdated", shred.Policy{Subject: "user_id", Fields: []string{"name"}})
+
+	shredder := shred.NewShredder(keys, registry)
+
+	events, err := shredder.EncryptEvents(context.Background(), []cqrskit.Event{
+		{
+			Type: "UserEmailUpdated",
+			Data: UserEmailUpdated{UserID: "user-1", Email: "bob@example.com", Plan: "pro"},
+		},
+		{
+			Type: "UserNameUpdated",
+			Data: map[string]interface{}{"user_id": "user-1", "name": "Bob"},
+		},
+		{
+			Type: "UserPlanChange",
+			Data: "pro",
+		},
+	})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully encrypted events")
+	}
+	tests.Passed("Should have successfully encrypted events")
+
+	data, ok := events[0].Data.(map[string]interface{})
+	if !ok || data["email"] == "bob@example.com" || data["plan"] != "pro" || data["user_id"] != "user-1" {
+		tests.Info("Received: %+v", events[0].Data)
+		tests.Failed("Should have encrypted only tagged fields")
+	}
+	tests.Passed("Should have encrypted only tagged fields")
+
+	if events[1].Data.(map[string]interface{})["name"] == "Bob" || events[1].Header[shred.SubjectHeader] != "user-1" {
+		tests.Info("Received: %+v", events[1])
+		tests.Failed("Should have encrypted registered fields")
+	}
+	tests.Passed("Should have encrypted registered fields")
+
+	if events[2].Data != "pro" {
+		tests.Failed("Should have left event without policy as is")
+	}
+	tests.Passed("Should have left event without policy as is")
+
+	commit, err := shredder.DecryptCommit(context.Background(), cqrskit.EventCommit{Events: events})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully decrypted commit")
+	}
+	tests.Passed("Should have successfully decrypted commit")
+
+	if commit.Events[0].Data.(map[string]interface{})["email"] != "bob@example.com" ||
+		commit.Events[1].Data.(map[string]interface{})["name"] != "Bob" {
+		tests.Info("Received: %+v", commit.Events)
+		tests.Failed("Should have restored encrypted fields")
+	}
+	tests.Passed("Should have restored encrypted fields")
+
+	commits := []cqrskit.EventCommit{{Events: events}}
+	decrypted, err := shredder.DecryptCommits(context.Background(), commits)
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully decrypted commits")
+	}
+	tests.Passed("Should have successfully decrypted commits")
+
+	if decrypted[0].Events[1].Data.(map[string]interface{})["name"] != "Bob" {
+		tests.Info("Received: %+v", decrypted[0].Events)
+		tests.Failed("Should have restored encrypted fields of commits")
+	}
+	tests.Passed("Should have restored encrypted fields of commits")
+
+	if commits[0].Events[1].Data.(map[string]interface{})["name"] == "Bob" || events[1].Data.(map[string]interface{})["name"] == "Bob" {
+		tests.Info("Received: %+v", commits[0].Events)
+		tests.Failed("Should have left provided commits encrypted")
+	}
+	tests.Passed("Should have left provided commits encrypted")
+
+	if err := shredder.Forget(context.Background(), "user-1"); err != nil {
+		tests.FailedWithError(err, "Should have successfully forgotten subject")
+	}
+	tests.Passed("Should have successfully forgotten subject")
+
+	commit, err = shredder.DecryptCommit(context.Background(), cqrskit.EventCommit{Events: events})
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully decrypted commit")
+	}
+	tests.Passed("Should have successfully decrypted commit")
+
+	data = commit.Events[0].Data.(map[string]interface{})
+	if data["email"] != shred.RedactedValue || data["plan"] != "pro" {
+		tests.Info("Received: %+v", data)
+		tests.Failed("Should have redacted fields of forgotten subject")
+	}
+	tests.Passed("Should have redacted fields of forgotten subject")
+
+	if _, err := shredder.EncryptEvents(context.Background(), []cqrskit.Event{
+		{Type: "UserEmailUpdated", Data: UserEmailUpdated{Email: "bob@example.com"}},
+	}); err != shred.ErrNoSubject {
+		tests.Failed("Should have rejected event without subject")
+	}
+	tests.Passed("Should have rejected event without subject")
+}
+
+func TestShredderRepositories(t *testing.T) {
+	keys, done := newKeyStore(t)
+	defer done()
+
+	shredder := shred.NewShredder(keys, nil)
+	store := &memoryRepo{}
+
+	writer, err := shredder.Writers(store).Writer("users", "user-1")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten write repository")
+	}
+	tests.Passed("Should have successfully gotten write repository")
+
+	if _, err := writer.Write(context.Background(), cqrskit.EventCommitRequest{
+		ID: "1",
+		Events: []cqrskit.Event{
+			{Type: "UserEmailUpdated", Data: UserEmailUpdated{UserID: "user-1", Email: "bob@example.com"}},
+		},
+	}); err != nil {
+		tests.FailedWithError(err, "Should have successfully written commit")
+	}
+	tests.Passed("Should have successfully written commit")
+
+	if store.commits[0].Events[0].Data.(map[string]interface{})["email"] == "bob@example.com" {
+		tests.Failed("Should have stored encrypted fields")
+	}
+	tests.Passed("Should have stored encrypted fields")
+
+	reader, err := shredder.Readers(store).Reader("users", "user-1")
+	if err != nil {
+		tests.FailedWithError(err, "Should have successfully gotten read repository")
+	}
+	tests.Passed("Should have successfully gotten read repository")
+
+	commits, err := reader.ReadAll(context.Background())
+	if err != nil || commits[0].Events[0].Data.(map[string]interface{})["email"] != "bob@example.com" {
+		tests.Failed("Should have read decrypted fields")
+	}
+	tests.Passed("Should have read decrypted fields")
+}
+
+// memoryRepo implements a single stream cqrskit.WriteRepository and cqrskit.ReadRepository.
+type memoryRepo struct {
+	cqrskit.WriteRepo
+	cqrskit.ReadRepo
+	commits []cqrskit.EventCommit
+}
+
+func (m *memoryRepo) Writer(aggregateID string, instanceID string) (cqrskit.WriteRepo, error) {
+	return m, nil
+}
+
+func (m *memoryRepo) Reader(aggregateID string, instanceID string) (cqrskit.ReadRepo, error) {
+	return m, nil
+}
+
+func (m *memoryRepo) Count(ctx context.Context) (int, error) {
+	return len(m.commits), nil
+}
+
+func (m *memoryRepo) Write(ctx context.Context, req cqrskit.EventCommitRequest) (cqrskit.CommitHeader, error) {
+	m.commits = append(m.commits, cqrskit.EventCommit{CommitID: req.ID, Version: len(m.commits) + 1, Events: req.Events})
+	return cqrskit.CommitHeader{}, nil
+}
+
+func (m *memoryRepo) ReadAll(ctx context.Context) ([]cqrskit.EventCommit, error) {
+	return append([]cqrskit.EventCommit(nil), m.commits...), nil
+}